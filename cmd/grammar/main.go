@@ -1,33 +1,44 @@
 package main
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
+	"os"
+	"path"
 	"strings"
 	"time"
 
 	io "github.com/DanielRasho/Computation-Theory/internal/IO"
-	ast "github.com/DanielRasho/Computation-Theory/internal/abstract_syntax_tree"
 	"github.com/DanielRasho/Computation-Theory/internal/grammar"
-	nfaAutomata "github.com/DanielRasho/Computation-Theory/internal/nfa"
-	runner "github.com/DanielRasho/Computation-Theory/internal/runner_simulation"
-	shuttingyard "github.com/DanielRasho/Computation-Theory/internal/shuntingyard"
 )
 
-// REGEX DEFINITIONS
-const LETTERS string = "[abcdefghijklmnopqrstuvwxyz ]"
-const CAPITAL_LETTERS string = "[ABCDEFGHIJKLMNOPQRSTUVWXYZ]"
-const OPERATORS string = "[\\+\\*\\(\\)]"
-const DIGITS string = "[0123456789]"
-const NON_TERMINALS string = "\\{([ABCDEFGHIJKLMNOPQRSTUVWXYZ])+\\}"
+func main() {
+	startFlag := flag.String("start", "", "Símbolo inicial de la gramática (ej: S). Tiene prioridad sobre la directiva %start")
+	exportFlag := flag.String("export", "", "Archivo donde exportar la gramática simplificada: .y (bison), .g4 (ANTLR), .js (tree-sitter), .txt (formato A -> b{A}) o .jff (JFLAP)")
+	jsonFlag := flag.String("json", "", "Archivo donde guardar en JSON las gramáticas de cada etapa y el resultado de CYK")
+	stagesFlag := flag.String("save-stages", "", "Carpeta donde guardar la gramática de cada etapa de la simplificación en formato A -> b{A}")
+	batchFlag := flag.Bool("batch", false, "Simplificar todas las gramáticas y revisar sus ejemplos accept:/reject: en lugar de pedir una cadena")
+	passesFlag := flag.String("passes", grammar.PipelineSimplify, "Pasadas a aplicar: un pipeline (simplify, cnf, gnf, ll1-prep, clean) o una lista separada por comas (left-factor, left-recursion, epsilon, unit, useless, chomsky, greibach)")
+	skipFlag := flag.String("skip", "", "Pasadas a omitir, separadas por comas (ej: left-factor,left-recursion)")
+	minimizeFlag := flag.String("minimize", "", "Con --verify, archivo donde guardar en formato A -> b{A} la gramática y la cadena más pequeñas con las que el lenguaje sigue cambiando")
+	verifyFlag := flag.Int("verify", 0, "Revisar con Earley que cada etapa acepte las mismas cadenas de hasta N terminales que la gramática original, en lugar de pedir una cadena")
+	flag.Parse()
 
-// PRODUCTIONS_REGEX for matching grammar productions
-var PRODUCTIONS_REGEX = fmt.Sprintf("(%s)+ -> ((%s|%s|%s|%s|%s|ε)+\\|)*(%s|%s|%s|%s|%s|ε)+",
-	CAPITAL_LETTERS,
-	OPERATORS, NON_TERMINALS, LETTERS, CAPITAL_LETTERS, DIGITS,
-	OPERATORS, NON_TERMINALS, LETTERS, CAPITAL_LETTERS, DIGITS)
+	pipeline, err := buildPipeline(*passesFlag, *skipFlag)
+	if err != nil {
+		fmt.Printf("❌ ERROR: %v\n", err)
+		os.Exit(1)
+	}
+	if err := checkExportPath(*exportFlag); err != nil {
+		fmt.Printf("❌ ERROR: %v\n", err)
+		os.Exit(1)
+	}
 
-func main() {
 	filepath := "./input_data/grammars.txt"
+	if flag.NArg() > 0 {
+		filepath = flag.Arg(0)
+	}
 
 	fileReader, err := io.ReadFile(filepath)
 
@@ -38,77 +49,447 @@ func main() {
 
 	defer fileReader.Close()
 
-	// Creamos un NFA para validar las producciones
-	nfa := NFA_initializer()
-	currentGrammar := grammar.Grammar{Productions: make(map[grammar.Symbol][][]grammar.Symbol)}
-	grammarCounter := 1
-
-	fmt.Println("\n=================================")
-	fmt.Printf("📝 Procesando gramática %d:\n", grammarCounter)
-	fmt.Println("=================================")
-
+	lines := make([]string, 0)
 	var line string
 	for fileReader.NextLine(&line) {
+		lines = append(lines, line)
+	}
 
-		// Detecta el delimitador que separa las gramáticas
-		if line == "---" {
-			grammar.SimplifyGrammar(&currentGrammar, true)
+	// Validar y leer todas las gramáticas del archivo
+	sections, err := readSections(filepath, lines)
+	if err != nil {
+		fmt.Printf("❌ ERROR: gramática incorrecta\n%v\n", err)
+		os.Exit(1)
+	}
+	if len(sections) == 0 {
+		fmt.Printf("❌ ERROR: el archivo %s no contiene ninguna gramática\n", filepath)
+		os.Exit(1)
+	}
 
-			grammarCounter++
-			fmt.Println("\n=================================")
-			fmt.Printf("📝 Procesando gramática %d:\n", grammarCounter)
-			fmt.Println("=================================")
+	var newGrammar, cnfGrammar *grammar.Grammar
+	report := jsonReport{File: filepath}
+	for index, section := range sections {
+		currentGrammar := section.Grammar
+		fmt.Println("\n=================================")
+		fmt.Printf("📝 Procesando gramática %s:\n", sectionTitle(section, index))
+		fmt.Println("=================================")
 
-			// Preparar para la siguiente gramática
-			currentGrammar = grammar.Grammar{Productions: make(map[grammar.Symbol][][]grammar.Symbol)}
-			continue
+		if !applyStartSymbol(currentGrammar, *startFlag) {
+			os.Exit(1)
 		}
 
-		// Ignorar líneas vacías o comentarios
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
+		// Simplify Grammar
+
+		// Capturar el tiempo de inicio
+		start := time.Now()
+		// Los pasos de la simplificación se muestran como texto a medida que ocurren
+		simplification := pipeline.RunResultWith(currentGrammar, grammar.TextObserver(os.Stdout))
+		newGrammar = simplification.Grammar
+		// CYK necesita la forma normal de Chomsky, aunque el pipeline no termine en
+		// ella. Se parte de la gramática original porque después de la pasada
+		// epsilon ya no se sabe si el símbolo inicial generaba ε
+		cnfGrammar = newGrammar
+		if cnfPipeline := pipeline.WithChomsky(); cnfPipeline != pipeline {
+			cnfGrammar = cnfPipeline.Run(currentGrammar, false, nil)
 		}
+		// Capturar el tiempo después de la simplificación
+		elapsed := time.Since(start)
+		report.Grammars = append(report.Grammars, simplification)
+		// El símbolo inicial puede cambiar si la forma normal de Chomsky agrega uno nuevo
+		fmt.Println(newGrammar.Productions[newGrammar.GetStartSymbol()])
+		// Imprimir el tiempo que tomó la simplificación
+		fmt.Printf("Tiempo de simplificación: %s\n", elapsed)
+		printMetrics(simplification.Stages)
 
-		fmt.Printf("Checking %s :", line)
-		conclusion := runner.RunnerNFA(nfa, line)
-		if conclusion {
-			fmt.Printf(" is ✅\n")
-			currentGrammar.AddProductionFromString(line)
-		} else {
-			fmt.Printf(" is ❌\n ERROR: incorrect grammar\n")
-			return
+		if *stagesFlag != "" {
+			saveStages(*stagesFlag, simplification.Stages, index+1, len(sections))
+		}
+
+		if *exportFlag != "" {
+			exportPath := numberedPath(*exportFlag, index, len(sections))
+			if err := exportGrammar(newGrammar, exportPath, filepath); err != nil {
+				fmt.Printf("❌ ERROR: no se pudo exportar la gramática\n%v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("💾 Gramática exportada a %s\n", exportPath)
+		}
+
+		if *verifyFlag > 0 {
+			check := grammar.VerifyStages(simplification.Stages, *verifyFlag)
+			report.Languages = append(report.Languages, languageCheck{Name: sectionTitle(section, index), LanguageCheck: check})
+
+			if !check.Preserved() && *minimizeFlag != "" {
+				minimizePath := numberedPath(*minimizeFlag, index, len(sections))
+				if err := saveReproduction(currentGrammar, check.Change.Symbols, pipeline, minimizePath); err != nil {
+					fmt.Printf("❌ ERROR: no se pudo guardar la reproducción mínima\n%v\n", err)
+					os.Exit(1)
+				}
+			}
+		}
+
+		if *batchFlag {
+			if err := grammar.CheckInputTerminals(cnfGrammar); err != nil && len(section.Examples) > 0 {
+				fmt.Printf("❌ ERROR: los ejemplos no se pueden revisar\n%v\n", err)
+				os.Exit(1)
+			}
+			results := grammar.CheckExamples(cnfGrammar, section.Examples)
+			report.Checks = append(report.Checks, grammarCheck{
+				Name:     sectionTitle(section, index),
+				Passed:   grammar.ExamplesPassed(results),
+				Examples: results,
+			})
 		}
 	}
 
-	startSymbol := currentGrammar.NonTerminals[0]
-	// Simplify Grammar
+	if *batchFlag || *verifyFlag > 0 {
+		passed := true
+		if *batchFlag {
+			printChecks(report.Checks)
+			for _, check := range report.Checks {
+				passed = passed && check.Passed
+			}
+		}
+		if *verifyFlag > 0 {
+			printLanguageChecks(report.Languages)
+			for _, check := range report.Languages {
+				passed = passed && check.Preserved()
+			}
+		}
+		saveJSON(*jsonFlag, report)
+		if !passed {
+			os.Exit(1)
+		}
+		return
+	}
 
-	// Capturar el tiempo de inicio
-	start := time.Now()
-	newGrammar := grammar.SimplifyGrammar(&currentGrammar, true)
-	// Capturar el tiempo después de la simplificación
-	elapsed := time.Since(start)
-	fmt.Println(newGrammar.Productions[startSymbol])
-	// Imprimir el tiempo que tomó la simplificación
-	fmt.Printf("Tiempo de simplificación: %s\n", elapsed)
+	// Los tokens de ANTLR nunca coinciden con los caracteres de la entrada
+	if err := grammar.CheckInputTerminals(cnfGrammar); err != nil {
+		fmt.Printf("❌ ERROR: la gramática no se puede revisar con CYK\n%v\n", err)
+		os.Exit(1)
+	}
 
 	// Get User Input
 	var input string
 	fmt.Print("🔰Ingresar valor para verificar: ")
 	fmt.Scanln(&input)
 
-	accepted := grammar.CYKParse(newGrammar, input, startSymbol)
-	if accepted {
+	result := grammar.CYKParseWith(cnfGrammar, input, cnfGrammar.GetStartSymbol(), grammar.TextObserver(os.Stdout))
+	if result.Accepted {
 		fmt.Println("La cadena es aceptada por la gramática.")
 	} else {
 		fmt.Println("La cadena NO es aceptada por la gramática.")
 	}
+
+	if *jsonFlag != "" {
+		report.Parse = &result
+		saveJSON(*jsonFlag, report)
+	}
+}
+
+// Results of a run of the program, as written by --json.
+type jsonReport struct {
+	File      string                    `json:"file"`
+	Grammars  []*grammar.Simplification `json:"grammars"`
+	Parse     *grammar.CYKResult        `json:"parse,omitempty"`     // CYK of the input with the last grammar.
+	Checks    []grammarCheck            `json:"checks,omitempty"`    // Examples of every grammar, with --batch.
+	Languages []languageCheck           `json:"languages,omitempty"` // Language of every stage of every grammar, with --verify.
+}
+
+// Result of checking the language of the stages of a grammar with --verify.
+type languageCheck struct {
+	Name string `json:"name"`
+	grammar.LanguageCheck
+}
+
+// Result of checking the examples of a grammar with --batch.
+type grammarCheck struct {
+	Name     string                  `json:"name"`
+	Passed   bool                    `json:"passed"`
+	Examples []grammar.ExampleResult `json:"examples"`
+}
+
+// Writes the report to jsonPath when the --json flag is given.
+func saveJSON(jsonPath string, report jsonReport) {
+	if jsonPath == "" {
+		return
+	}
+	if err := writeJSON(jsonPath, report); err != nil {
+		fmt.Printf("❌ ERROR: no se pudo escribir el JSON\n%v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("💾 Resultados guardados en %s\n", jsonPath)
+}
+
+func writeJSON(jsonPath string, report jsonReport) error {
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(jsonPath, append(content, '\n'), 0644)
+}
+
+// Builds the pipeline selected by the --passes flag, without the passes of
+// the --skip flag.
+func buildPipeline(passes string, skip string) (*grammar.Pipeline, error) {
+	pipeline, err := grammar.ParsePipeline(passes)
+	if err != nil || skip == "" {
+		return pipeline, err
+	}
+
+	names := strings.Split(skip, ",")
+	for index, name := range names {
+		// Revisar que el nombre exista para no ignorar errores de escritura
+		if _, err := grammar.PassByName(name); err != nil {
+			return nil, err
+		}
+		names[index] = strings.TrimSpace(name)
+	}
+	return pipeline.Without(names...), nil
+}
+
+// Reads the grammars of a file. Only the A -> b{A} format has named
+// sections and examples, the grammars of other formats have neither.
+func readSections(filepath string, lines []string) ([]*grammar.GrammarSection, error) {
+	parse := parserFor(filepath)
+	if parse == nil {
+		return grammar.ParseGrammarSections(filepath, lines)
+	}
+
+	grammars, err := parse(filepath, lines)
+	sections := make([]*grammar.GrammarSection, 0, len(grammars))
+	for _, g := range grammars {
+		sections = append(sections, &grammar.GrammarSection{Grammar: g})
+	}
+	return sections, err
+}
+
+// Returns: the name of a section, or its number if it has no name.
+func sectionTitle(section *grammar.GrammarSection, index int) string {
+	if section.Name != "" {
+		return section.Name
+	}
+	return fmt.Sprint(index + 1)
+}
+
+// Prints whether the examples of every grammar passed, with the examples
+// that failed.
+func printChecks(checks []grammarCheck) {
+	fmt.Println("\n=================================")
+	fmt.Println("📋 Resultados de los ejemplos:")
+	fmt.Println("=================================")
+
+	passedGrammars := 0
+	for _, check := range checks {
+		passed := 0
+		for _, result := range check.Examples {
+			if result.Passed {
+				passed++
+			}
+		}
+
+		switch {
+		case len(check.Examples) == 0:
+			fmt.Printf("⚪ %s: sin ejemplos\n", check.Name)
+		case check.Passed:
+			fmt.Printf("✅ %s: %d/%d ejemplos correctos\n", check.Name, passed, len(check.Examples))
+		default:
+			fmt.Printf("❌ %s: %d/%d ejemplos correctos\n", check.Name, passed, len(check.Examples))
+		}
+		if check.Passed {
+			passedGrammars++
+		}
+
+		for _, result := range check.Examples {
+			if result.Passed {
+				continue
+			}
+			input := result.Input
+			if input == "" {
+				input = "ε"
+			}
+			if result.Accept {
+				fmt.Printf("   línea %d: %q debía ser aceptada, pero fue rechazada\n", result.Line, input)
+			} else {
+				fmt.Printf("   línea %d: %q debía ser rechazada, pero fue aceptada\n", result.Line, input)
+			}
+		}
+	}
+	fmt.Printf("\n%d de %d gramáticas pasaron\n", passedGrammars, len(checks))
+}
+
+// Returns: the path of the file of a grammar. When the input has more than
+// one grammar every grammar has its own file: salida.y -> salida_2.y
+func numberedPath(filePath string, index int, count int) string {
+	if count <= 1 {
+		return filePath
+	}
+	extension := path.Ext(filePath)
+	return fmt.Sprintf("%s_%d%s", strings.TrimSuffix(filePath, extension), index+1, extension)
+}
+
+// Shrinks a grammar whose language changed, keeping the witness changed by
+// the pipeline, and saves the reproduction in the A -> b{A} format.
+func saveReproduction(g *grammar.Grammar, witness []grammar.Symbol, pipeline *grammar.Pipeline, reproductionPath string) error {
+	reproduction, err := grammar.Minimize(g, witness, grammar.ChangesMembership(pipeline))
+	if err != nil {
+		return err
+	}
+	content, err := reproduction.Native()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(reproductionPath, []byte(content), 0644); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n🔬 Reproducción mínima (%d pruebas):\n%s", reproduction.Tests, content)
+	fmt.Printf("💾 Reproducción guardada en %s\n", reproductionPath)
+	return nil
+}
+
+// Prints whether every grammar kept its language in all the stages, with
+// the first string that changed and the pass that changed it.
+func printLanguageChecks(checks []languageCheck) {
+	fmt.Println("\n=================================")
+	fmt.Println("🔎 Verificación del lenguaje:")
+	fmt.Println("=================================")
+
+	preserved := 0
+	for _, check := range checks {
+		if check.RequestedLength > 0 {
+			fmt.Printf("⚠️  %s: se revisan cadenas de hasta %d terminales en lugar de %d, para no pasar de %d cadenas\n", check.Name, check.MaxLength, check.RequestedLength, grammar.MaxVerifiedStrings)
+		}
+		if check.Preserved() {
+			preserved++
+			fmt.Printf("✅ %s: %d cadenas de hasta %d terminales, mismo lenguaje en %d etapas\n", check.Name, check.Strings, check.MaxLength, len(check.Stages))
+			continue
+		}
+
+		change := check.Change
+		fmt.Printf("❌ %s: la pasada %s cambió el lenguaje en la etapa %s\n", check.Name, change.Pass, change.Stage)
+		if change.Accepted {
+			fmt.Printf("   %q es aceptada por la gramática original y por %s, pero no por %s\n", change.Input, change.Previous, change.Stage)
+		} else {
+			fmt.Printf("   %q es rechazada por la gramática original y por %s, pero no por %s\n", change.Input, change.Previous, change.Stage)
+		}
+	}
+	fmt.Printf("\n%d de %d gramáticas conservaron su lenguaje\n", preserved, len(checks))
+}
+
+// Chooses how to read a grammar file by its extension. Files without a known
+// extension use the A -> b{A} format, and then it returns nil.
+func parserFor(filepath string) func(string, []string) ([]*grammar.Grammar, error) {
+	switch strings.ToLower(path.Ext(filepath)) {
+	case ".bnf":
+		return grammar.ParseBNF
+	case ".abnf":
+		return grammar.ParseABNF
+	case ".ebnf":
+		return grammar.ParseW3CEBNF
+	case ".g4":
+		return grammar.ParseANTLR
+	case ".jff":
+		return grammar.ParseJFLAP
+	default:
+		return nil
+	}
+}
+
+// Writes a grammar in the format given by the extension of exportPath. The
+// ANTLR grammar is named after the output file, as ANTLR requires, and the
+// tree-sitter grammar after the input file.
+func exportGrammar(g *grammar.Grammar, exportPath string, inputPath string) error {
+	baseName := func(p string) string {
+		return strings.TrimSuffix(path.Base(p), path.Ext(p))
+	}
+
+	var content string
+	var err error
+	switch strings.ToLower(path.Ext(exportPath)) {
+	case ".y":
+		content = grammar.ExportBison(g)
+	case ".g4":
+		if err := checkExportPath(exportPath); err != nil {
+			return err
+		}
+		content = grammar.ExportANTLR(g, baseName(exportPath))
+	case ".js":
+		content = grammar.ExportTreeSitter(g, baseName(inputPath))
+	case ".txt":
+		content, err = grammar.ExportNative(g)
+	case ".jff":
+		content, err = grammar.ExportJFLAP(g)
+	default:
+		return fmt.Errorf("formato de exportación desconocido: %s, usa .y, .g4, .js, .txt o .jff", exportPath)
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(exportPath, []byte(content), 0644)
+}
+
+// Checks that a grammar can be exported to exportPath. ANTLR only reads a
+// grammar from a .g4 file with its name, so the name of the file must be a
+// valid grammar name. The numbers added for several grammars keep it valid.
+func checkExportPath(exportPath string) error {
+	if strings.ToLower(path.Ext(exportPath)) != ".g4" {
+		return nil
+	}
+	name := strings.TrimSuffix(path.Base(exportPath), path.Ext(exportPath))
+	if antlrName := grammar.ANTLRGrammarName(name); antlrName != name {
+		return fmt.Errorf("%s no es un nombre de gramática de ANTLR válido, usa %s", path.Base(exportPath), antlrName+path.Ext(exportPath))
+	}
+	return nil
 }
 
-// Creates the NFA for checking if a production is valid
-func NFA_initializer() *nfaAutomata.NFA {
-	_, postfix, _ := shuttingyard.RegexToPostfix(PRODUCTIONS_REGEX, false)
-	root := ast.BuildAST(postfix)
-	nfa := nfaAutomata.BuildNFA(root)
-	return nfa
+// Sets the start symbol given by the --start flag, if any, and checks that
+// the grammar has productions for its start symbol.
+func applyStartSymbol(g *grammar.Grammar, startFlag string) bool {
+	if startFlag != "" {
+		g.SetStartSymbol(startFlag)
+	}
+
+	startSymbol := g.GetStartSymbol()
+	if _, exist := g.Productions[startSymbol]; !exist {
+		fmt.Printf("❌ ERROR: el símbolo inicial %s no tiene producciones\n", startSymbol.Value)
+		return false
+	}
+	return true
+}
+
+// Prints the size of the grammar of every stage, as a table.
+func printMetrics(stages []grammar.SimplificationStage) {
+	fmt.Println("\n📊 Tamaño de cada etapa:")
+	fmt.Printf("  %-24s %13s %10s %12s %16s\n", "Etapa", "No terminales", "Terminales", "Producciones", "Cuerpo más largo")
+	for _, stage := range stages {
+		metrics := stage.Metrics
+		fmt.Printf("  %-24s %13d %10d %12d %16d\n", stage.Name, metrics.NonTerminals, metrics.Terminals, metrics.Productions, metrics.MaxBodyLength)
+	}
+}
+
+// Saves every stage of the simplification of a grammar in dir, as
+// 01_original.txt, 02_left-factored.txt... When the file has more than one
+// grammar the files start with the grammar number.
+func saveStages(dir string, stages []grammar.SimplificationStage, grammarNumber int, grammarCount int) {
+	prefix := ""
+	if grammarCount > 1 {
+		prefix = fmt.Sprintf("g%d_", grammarNumber)
+	}
+
+	for index, stage := range stages {
+		stagePath := path.Join(dir, fmt.Sprintf("%s%02d_%s.txt", prefix, index+1, stage.Name))
+
+		content, err := grammar.ExportNative(stage.Grammar)
+		if err == nil {
+			err = os.MkdirAll(dir, 0755)
+		}
+		if err == nil {
+			err = os.WriteFile(stagePath, []byte(content), 0644)
+		}
+		if err != nil {
+			fmt.Printf("❌ ERROR: no se pudo guardar la etapa %s\n%v\n", stage.Name, err)
+			continue
+		}
+		fmt.Printf("💾 Etapa %s guardada en %s\n", stage.Name, stagePath)
+	}
 }
@@ -0,0 +1,494 @@
+package grammar
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	ast "github.com/DanielRasho/Computation-Theory/internal/abstract_syntax_tree"
+)
+
+/*
+Lector de gramáticas escritas en ABNF (RFC 5234), como las de los RFC:
+
+	request-line = method SP uri SP "HTTP/" 1*DIGIT "." 1*DIGIT
+	method       = "GET" / "POST"
+	uri          = 1*( ALPHA / DIGIT / "/" / "." )
+
+Soporta:
+  - Definiciones con = y alternativas incrementales con =/.
+  - Alternativas con /, concatenación con espacios, grupos ( ) y opcionales [ ].
+  - Repeticiones n*m, n* , *m y n antes de un elemento, como 1*DIGIT o 2HEXDIG.
+  - Valores numéricos %x41, %d65, %b1000001, concatenaciones %x48.49 y rangos %x30-39.
+  - Cadenas "abc", que no distinguen mayúsculas de minúsculas, y %s"abc" que sí.
+  - Las reglas base del apéndice B (ALPHA, DIGIT, SP, ...) si la gramática las
+    usa sin definirlas.
+
+Los nombres de las reglas no distinguen mayúsculas de minúsculas, se usa el
+primero que aparece en el archivo, y sus guiones se convierten en guiones bajos.
+Los operadores se traducen a producciones con el mismo desugarer de %ebnf.
+*/
+
+// Rules of RFC 5234 Appendix B, added to a grammar that uses them without defining them.
+var abnfCoreRules = map[string]string{
+	"ALPHA":  "%x41-5A / %x61-7A",
+	"BIT":    `"0" / "1"`,
+	"CHAR":   "%x01-7F",
+	"CR":     "%x0D",
+	"CRLF":   "CR LF",
+	"CTL":    "%x00-1F / %x7F",
+	"DIGIT":  "%x30-39",
+	"DQUOTE": "%x22",
+	"HEXDIG": `DIGIT / "A" / "B" / "C" / "D" / "E" / "F"`,
+	"HTAB":   "%x09",
+	"LF":     "%x0A",
+	"LWSP":   "*(WSP / CRLF WSP)",
+	"OCTET":  "%x00-FF",
+	"SP":     "%x20",
+	"VCHAR":  "%x21-7E",
+	"WSP":    "SP / HTAB",
+}
+
+// Limit that keeps repetitions from creating huge grammars.
+const abnfMaxRepetition = 64
+
+// abnfParser reads the ABNF rules of a file, one rule at a time.
+type abnfParser struct {
+	ruleReader
+	names map[string]string // Rule names by their lowercase spelling.
+}
+
+// Parses the lines of an ABNF file. Like ParseGrammars, grammars are
+// separated by a "---" line. A rule continues on the following lines while
+// they are more indented than the first line of the grammar.
+//
+// Returns: the list of grammars, and a ParseErrors if any error was found.
+func ParseABNF(file string, lines []string) ([]*Grammar, error) {
+	p := &abnfParser{ruleReader: ruleReader{grammarParser: &grammarParser{file: file}}}
+	grammars := make([]*Grammar, 0)
+	p.resetABNFGrammar()
+
+	baseIndent := -1 // Indentation of the rules of the current grammar.
+	for index, line := range lines {
+		p.line = index + 1
+
+		if line == GrammarSeparator {
+			p.parseABNFRule()
+			if g := p.closeABNFGrammar(); g != nil {
+				grammars = append(grammars, g)
+			}
+			baseIndent = -1
+			continue
+		}
+
+		chars := stripABNFComment(line, p.line)
+		if len(chars) == 0 {
+			continue
+		}
+
+		indent := chars[0].column - 1
+		if baseIndent == -1 {
+			baseIndent = indent
+		}
+		if indent > baseIndent && len(p.rule) > 0 {
+			// Continuation of the current rule
+			p.rule = append(p.rule, sourceChar{char: ' ', line: p.line, column: 0})
+		} else {
+			p.parseABNFRule()
+		}
+		p.rule = append(p.rule, chars...)
+	}
+	p.parseABNFRule()
+	if g := p.closeABNFGrammar(); g != nil {
+		grammars = append(grammars, g)
+	}
+
+	if len(p.errors) > 0 {
+		return grammars, p.errors
+	}
+	return grammars, nil
+}
+
+func (p *abnfParser) resetABNFGrammar() {
+	p.resetGrammar()
+	p.names = make(map[string]string)
+}
+
+// Adds the core rules used by the current grammar and closes it.
+func (p *abnfParser) closeABNFGrammar() *Grammar {
+	for added := true; added; {
+		added = false
+		for _, nonTerminal := range p.current.NonTerminals {
+			if _, defined := p.current.Productions[nonTerminal]; defined {
+				continue
+			}
+			name := strings.ToUpper(nonTerminal.Value)
+			if body, isCore := abnfCoreRules[name]; isCore {
+				p.rule = toSourceChars(name+" = "+body, p.line)
+				p.parseABNFRule()
+				added = true
+			}
+		}
+	}
+
+	g := p.closeGrammar()
+	p.names = make(map[string]string)
+	return g
+}
+
+// Parses the rule accumulated in p.rule, adding its productions to the current grammar.
+func (p *abnfParser) parseABNFRule() {
+	if len(p.rule) == 0 {
+		return
+	}
+	defer func() { p.rule, p.i, p.symbols = nil, 0, nil }()
+
+	// NAME
+	p.skipSpaces()
+	if !p.atLetter() {
+		p.errorAtCurrent("missing rule name", "rules have the form name = elements")
+		return
+	}
+	head := p.ruleName()
+
+	// = or =/
+	p.skipSpaces()
+	if p.peek() != '=' {
+		p.errorAtCurrent("missing '=' after the rule name", "rules have the form name = elements, or name =/ elements to add alternatives")
+		return
+	}
+	p.i++
+	if p.peek() == '/' {
+		p.i++
+	}
+
+	node, ok := p.alternation()
+	if !ok {
+		return
+	}
+	p.skipSpaces()
+	if p.i < len(p.rule) {
+		p.errorAtCurrent("unexpected character", "elements are separated by spaces and alternatives by '/'")
+		return
+	}
+
+	p.addRule(head, node)
+}
+
+// alternation = concatenation *("/" concatenation)
+func (p *abnfParser) alternation() (ast.Node, bool) {
+	node, ok := p.concatenation()
+	if !ok {
+		return nil, false
+	}
+	for {
+		p.skipSpaces()
+		if p.peek() != '/' {
+			return node, true
+		}
+		p.i++
+		next, ok := p.concatenation()
+		if !ok {
+			return nil, false
+		}
+		node = ast.NewOperatorNode("|", []ast.Node{node, next})
+	}
+}
+
+// concatenation = repetition *(spaces repetition)
+func (p *abnfParser) concatenation() (ast.Node, bool) {
+	nodes := make([]ast.Node, 0)
+	for {
+		p.skipSpaces()
+		if p.i == len(p.rule) || strings.ContainsRune("/)]", p.peek()) {
+			break
+		}
+		node, ok := p.repetition()
+		if !ok {
+			return nil, false
+		}
+		nodes = append(nodes, node)
+	}
+
+	if len(nodes) == 0 {
+		p.errorAtCurrent("empty alternative", `use "" to write the empty string`)
+		return nil, false
+	}
+	return p.concatenate(nodes), true
+}
+
+// repetition = [n] ["*" [m]] element
+func (p *abnfParser) repetition() (ast.Node, bool) {
+	start := p.i
+	minimum, hasMinimum := p.number()
+	maximum, hasMaximum := minimum, hasMinimum
+	isRange := false
+	if p.peek() == '*' {
+		p.i++
+		isRange = true
+		maximum, hasMaximum = p.number()
+		if !hasMinimum {
+			minimum = 0
+		}
+	}
+
+	element, ok := p.element()
+	if !ok {
+		return nil, false
+	}
+	if !hasMinimum && !isRange {
+		return element, true
+	}
+
+	if minimum > abnfMaxRepetition || (hasMaximum && maximum > abnfMaxRepetition) {
+		p.errorAt(p.rule[start], "repetition too large", fmt.Sprintf("repetitions can have at most %d elements", abnfMaxRepetition))
+		return nil, false
+	}
+	if hasMaximum && maximum < minimum {
+		p.errorAt(p.rule[start], "repetition maximum is less than its minimum", "write the repetition as min*max")
+		return nil, false
+	}
+
+	// x^n seguido de x* si no hay máximo, o de max-n opcionales anidados
+	nodes := make([]ast.Node, 0, minimum+1)
+	for i := 0; i < minimum; i++ {
+		nodes = append(nodes, element)
+	}
+	switch {
+	case isRange && !hasMaximum && minimum == 0:
+		return ast.NewOperatorNode("*", []ast.Node{element}), true
+	case isRange && !hasMaximum && minimum == 1:
+		return ast.NewOperatorNode("+", []ast.Node{element}), true
+	case isRange && !hasMaximum:
+		nodes = append(nodes, ast.NewOperatorNode("*", []ast.Node{element}))
+	case maximum > minimum:
+		var optional ast.Node = ast.NewOperatorNode("?", []ast.Node{element})
+		for i := minimum + 1; i < maximum; i++ {
+			optional = ast.NewOperatorNode("?", []ast.Node{
+				ast.NewOperatorNode("·", []ast.Node{element, optional}),
+			})
+		}
+		nodes = append(nodes, optional)
+	}
+	return p.concatenate(nodes), true
+}
+
+// element = rulename / group / option / char-val / num-val
+func (p *abnfParser) element() (ast.Node, bool) {
+	if p.i == len(p.rule) {
+		p.errorAtCurrent("missing element", "write a rule name, a string or a value after the repetition")
+		return nil, false
+	}
+
+	switch current := p.peek(); {
+	case p.atLetter():
+		return p.operand(p.ruleName()), true
+
+	case current == '(' || current == '[':
+		open := p.rule[p.i]
+		closing := map[rune]rune{'(': ')', '[': ']'}[current]
+		p.i++
+		node, ok := p.alternation()
+		if !ok {
+			return nil, false
+		}
+		p.skipSpaces()
+		if p.peek() != closing {
+			p.errorAt(open, fmt.Sprintf("unclosed '%c'", current), fmt.Sprintf("close it with '%c'", closing))
+			return nil, false
+		}
+		p.i++
+		if current == '[' {
+			return ast.NewOperatorNode("?", []ast.Node{node}), true
+		}
+		return node, true
+
+	case current == '"':
+		return p.charValue(false)
+
+	case current == '%':
+		return p.numValue()
+
+	case current == '<':
+		p.errorAtCurrent("prose values are not supported", "replace the description between < > with ABNF rules")
+		return nil, false
+
+	default:
+		p.errorAtCurrent("unexpected character", `elements are rule names, strings like "a", values like %x41, groups ( ) and options [ ]`)
+		return nil, false
+	}
+}
+
+// Parses a string between quotes, starting at the quote.
+func (p *abnfParser) charValue(caseSensitive bool) (ast.Node, bool) {
+	open := p.rule[p.i]
+	p.i++
+	nodes := make([]ast.Node, 0)
+	for p.i < len(p.rule) && p.peek() != '"' {
+		char := p.peek()
+		lower, upper := unicode.ToLower(char), unicode.ToUpper(char)
+		if caseSensitive || lower == upper {
+			nodes = append(nodes, p.operand(Symbol{Value: string(char), IsTerminal: true, Id: 0}))
+		} else {
+			nodes = append(nodes, ast.NewOperatorNode("|", []ast.Node{
+				p.operand(Symbol{Value: string(lower), IsTerminal: true, Id: 0}),
+				p.operand(Symbol{Value: string(upper), IsTerminal: true, Id: 0}),
+			}))
+		}
+		p.i++
+	}
+	if p.i == len(p.rule) {
+		p.errorAt(open, "unclosed string", `close the string with '"'`)
+		return nil, false
+	}
+	p.i++
+
+	return p.concatenate(nodes), true
+}
+
+// Parses %x, %d and %b values, and %s or %i strings, starting at the "%".
+func (p *abnfParser) numValue() (ast.Node, bool) {
+	percent := p.rule[p.i]
+	p.i++
+
+	base := 0
+	switch unicode.ToLower(p.peek()) {
+	case 'x':
+		base = 16
+	case 'd':
+		base = 10
+	case 'b':
+		base = 2
+	case 's', 'i':
+		caseSensitive := unicode.ToLower(p.peek()) == 's'
+		p.i++
+		if p.peek() != '"' {
+			p.errorAtCurrent("missing string after %s or %i", `write the string between quotes, like %s"abc"`)
+			return nil, false
+		}
+		return p.charValue(caseSensitive)
+	default:
+		p.errorAtCurrent("unknown value base", "values start with %x (hexadecimal), %d (decimal) or %b (binary)")
+		return nil, false
+	}
+	p.i++
+
+	first, ok := p.value(base)
+	if !ok {
+		return nil, false
+	}
+
+	// Rango %x30-39
+	if p.peek() == '-' {
+		p.i++
+		last, ok := p.value(base)
+		if !ok {
+			return nil, false
+		}
+		if last < first {
+			p.errorAt(percent, "empty value range", "write the smallest value first, like %x30-39")
+			return nil, false
+		}
+		if last-first+1 > maxValueRange {
+			p.errorAt(percent, "value range too large", fmt.Sprintf("value ranges can have at most %d characters", maxValueRange))
+			return nil, false
+		}
+		chars := make([]rune, 0, last-first+1)
+		for char := first; char <= last; char++ {
+			chars = append(chars, char)
+		}
+		return p.alternateChars(chars), true
+	}
+
+	// Concatenación %x48.49
+	nodes := []ast.Node{p.operand(Symbol{Value: string(first), IsTerminal: true, Id: 0})}
+	for p.peek() == '.' {
+		p.i++
+		next, ok := p.value(base)
+		if !ok {
+			return nil, false
+		}
+		nodes = append(nodes, p.operand(Symbol{Value: string(next), IsTerminal: true, Id: 0}))
+	}
+	return p.concatenate(nodes), true
+}
+
+// Reads the digits of a value in the given base.
+func (p *abnfParser) value(base int) (rune, bool) {
+	start := p.i
+	for p.i < len(p.rule) && strings.ContainsRune("0123456789abcdefABCDEF", p.peek()) {
+		p.i++
+	}
+	digits := sourceString(p.rule[start:p.i])
+	value, err := strconv.ParseInt(digits, base, 32)
+	if digits == "" || err != nil || value > unicode.MaxRune {
+		if start == len(p.rule) {
+			p.errorAtCurrent("missing value", "write the value digits after the base, like %x41")
+		} else {
+			p.errorAt(p.rule[start], "invalid value", "write the value digits after the base, like %x41")
+		}
+		return 0, false
+	}
+	return rune(value), true
+}
+
+// Reads a rule name and returns its NON terminal. Names are case insensitive.
+func (p *abnfParser) ruleName() Symbol {
+	start := p.i
+	for p.i < len(p.rule) && (p.atLetter() || unicode.IsDigit(p.peek()) || p.peek() == '-') {
+		p.i++
+	}
+	name := strings.ReplaceAll(sourceString(p.rule[start:p.i]), "-", "_")
+
+	key := strings.ToLower(name)
+	if _, exist := p.names[key]; !exist {
+		p.names[key] = name
+	}
+	return Symbol{Value: p.names[key], IsTerminal: false, Id: 0}
+}
+
+// Reads a decimal number.
+//
+// Returns: the number and true if there was one.
+func (p *abnfParser) number() (int, bool) {
+	start := p.i
+	for p.i < len(p.rule) && p.peek() >= '0' && p.peek() <= '9' {
+		p.i++
+	}
+	if start == p.i {
+		return 0, false
+	}
+	number, _ := strconv.Atoi(sourceString(p.rule[start:p.i]))
+	return number, true
+}
+
+func (p *abnfParser) atLetter() bool {
+	char := p.peek()
+	return (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z')
+}
+
+// Converts a line to positioned characters, removing its comment and trailing spaces.
+func stripABNFComment(line string, lineNumber int) []sourceChar {
+	chars := toSourceChars(line, lineNumber)
+
+	inString := false
+	for index, c := range chars {
+		if c.char == '"' {
+			inString = !inString
+		}
+		if c.char == ';' && !inString {
+			chars = chars[:index]
+			break
+		}
+	}
+
+	// Quitar espacios al inicio y al final
+	for len(chars) > 0 && unicode.IsSpace(chars[len(chars)-1].char) {
+		chars = chars[:len(chars)-1]
+	}
+	for len(chars) > 0 && unicode.IsSpace(chars[0].char) {
+		chars = chars[1:]
+	}
+	return chars
+}
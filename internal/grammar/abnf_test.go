@@ -0,0 +1,136 @@
+package grammar
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseABNF(t *testing.T) {
+	lines := []string{
+		"; Reglas de ejemplo",
+		`   greeting = "hi" / %x48.49 ; saludo`,
+		`   greeting =/ sign`,
+		`   sign     = [ "+" / "-" ]`,
+		`   pair     = 2bit`,
+		`   bit      = %x30-31`,
+	}
+
+	grammars, err := ParseABNF("test.abnf", lines)
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+
+	expected := `{greeting_0} -> {greeting_1}{greeting_2}|HI|{sign_0}
+{greeting_1} -> h|H
+{greeting_2} -> i|I
+{sign_0} -> {sign_1}
+{sign_1} -> +|-|ε
+{pair_0} -> {bit_0}{bit_0}
+{bit_0} -> 0|1
+`
+	if got := grammars[0].String(false); got != expected {
+		t.Errorf("Expected %q,\n but got %q", expected, got)
+	}
+	if start := grammars[0].GetStartSymbol(); start.Value != "greeting" {
+		t.Errorf("Expected start symbol greeting, but got %s", start.String())
+	}
+}
+
+func TestParseABNFCoreRulesAndContinuationLines(t *testing.T) {
+	lines := []string{
+		"date = 4DIGIT",
+		`       "-" 2digit`,
+		"hex  = %s\"0x\" 1*HEXDIG",
+	}
+
+	grammars, err := ParseABNF("test.abnf", lines)
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+
+	g := grammars[0]
+	// Rule names are case insensitive, so digit and DIGIT are the same rule
+	digit := Symbol{IsTerminal: false, Value: "DIGIT", Id: 0}
+	if len(g.Productions[digit]) != 10 {
+		t.Errorf("Expected DIGIT to have 10 bodies, but got %v", g.Productions[digit])
+	}
+	hexdig := Symbol{IsTerminal: false, Value: "HEXDIG", Id: 0}
+	if _, exist := g.Productions[hexdig]; !exist {
+		t.Errorf("Expected the core rule HEXDIG to be added")
+	}
+	if _, exist := g.Productions[Symbol{IsTerminal: false, Value: "ALPHA", Id: 0}]; exist {
+		t.Errorf("Expected the unused core rule ALPHA to not be added")
+	}
+}
+
+func TestSimplifyGrammarABNF(t *testing.T) {
+	grammars, err := ParseABNF("test.abnf", []string{
+		`number = 1*DIGIT ["." 1*3DIGIT]`,
+		`method = "get" / %s"POST"`,
+	})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+
+	tests := []struct {
+		start    string
+		input    string
+		expected bool
+	}{
+		{"number", "7", true},
+		{"number", "12.345", true},
+		{"number", "12.3456", false},
+		{"number", "12.", false},
+		{"method", "GeT", true},
+		{"method", "POST", true},
+		{"method", "post", false},
+	}
+	for _, tt := range tests {
+		grammars[0].SetStartSymbol(tt.start)
+		simplified := SimplifyGrammar(grammars[0], false)
+		if got := CYKParse(simplified, tt.input, simplified.GetStartSymbol()); got != tt.expected {
+			t.Errorf("CYKParse(%s, %q) = %v, expected %v", tt.start, tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestParseABNFErrors(t *testing.T) {
+	lines := []string{
+		`= "a"`,
+		`a "b"`,
+		`b = ( "x" / "y"`,
+		`c = %q41 / 3*2"x"`,
+		`d = %x00-FFF`,
+		`e = "x" / <prose>`,
+		`f = "a" / / "b"`,
+	}
+
+	expected := []ParseError{
+		{Line: 1, Column: 1, Char: "=", Message: "missing rule name"},
+		{Line: 2, Column: 3, Char: "\"", Message: "missing '=' after the rule name"},
+		{Line: 3, Column: 5, Char: "(", Message: "unclosed '('"},
+		{Line: 4, Column: 6, Char: "q", Message: "unknown value base"},
+		{Line: 5, Column: 5, Char: "%", Message: "value range too large"},
+		{Line: 6, Column: 11, Char: "<", Message: "prose values are not supported"},
+		{Line: 7, Column: 11, Char: "/", Message: "empty alternative"},
+	}
+
+	_, err := ParseABNF("test.abnf", lines)
+
+	var parseErrors ParseErrors
+	if !errors.As(err, &parseErrors) {
+		t.Fatalf("Expected ParseErrors, but got %v", err)
+	}
+	if len(parseErrors) != len(expected) {
+		t.Fatalf("Expected %d errors, but got %d:\n%v", len(expected), len(parseErrors), err)
+	}
+	for i, e := range expected {
+		got := parseErrors[i]
+		if got.File != "test.abnf" || got.Line != e.Line || got.Column != e.Column || got.Char != e.Char || got.Message != e.Message {
+			t.Errorf("Expected error %d to be %v, but got %v", i, e, got)
+		}
+		if got.Hint == "" {
+			t.Errorf("Expected error %d to have a hint", i)
+		}
+	}
+}
@@ -1,43 +1,66 @@
 package grammar
 
-import "fmt"
+// Resultado del algoritmo CYK para una cadena.
+type CYKResult struct {
+	Input    string `json:"input"`
+	Start    Symbol `json:"start"`
+	Accepted bool   `json:"accepted"`
+	// Table[i][j] son los no terminales que producen la subcadena de
+	// largo i+1 que empieza en la posición j de la entrada.
+	Table [][][]Symbol `json:"table"`
+	// Árbol de derivación de la entrada, nil si no es aceptada.
+	Tree *ParseTree `json:"tree,omitempty"`
+}
+
+// Nodo de un árbol de derivación. Las hojas son terminales, o ε cuando se
+// deriva la cadena vacía.
+type ParseTree struct {
+	Symbol   Symbol       `json:"symbol"`
+	Children []*ParseTree `json:"children,omitempty"`
+}
 
 // Función para determinar si una cadena es aceptada por una gramática en forma normal de Chomsky (CNF).
 func CYKParse(grammar *Grammar, cadena string, initialSymbol Symbol) bool {
+	return CYKParseWith(grammar, cadena, initialSymbol, nil).Accepted
+}
+
+// Aplica el algoritmo CYK como CYKParse.
+//
+// Returns: la tabla del algoritmo y, si la cadena es aceptada, su árbol de derivación.
+func CYKParseResult(grammar *Grammar, cadena string, initialSymbol Symbol) CYKResult {
+	return CYKParseWith(grammar, cadena, initialSymbol, nil)
+}
+
+// Aplica el algoritmo CYK como CYKParseResult, enviando al observer (si no
+// es nil) un EventChartRow cada vez que se completa una fila de la matriz.
+func CYKParseWith(grammar *Grammar, cadena string, initialSymbol Symbol, observer Observer) CYKResult {
 	lista_cadena := []rune(cadena)
+	result := CYKResult{Input: cadena, Start: initialSymbol, Table: [][][]Symbol{}}
+
+	// La cadena vacía solo es aceptada si el símbolo inicial produce ε directamente
+	if len(lista_cadena) == 0 {
+		result.Accepted = containsSymbolSlice(grammar.Productions[initialSymbol], []Symbol{EpsilonSymbol})
+		if result.Accepted {
+			result.Tree = &ParseTree{Symbol: initialSymbol, Children: []*ParseTree{{Symbol: EpsilonSymbol}}}
+		}
+		return result
+	}
 
 	// Crear una matriz vacía de tamaño len(lista_cadena) x len(lista_cadena)
-	matrixT := make([][][]string, len(lista_cadena))
+	matrixT := make([][][]Symbol, len(lista_cadena))
 	for i := range matrixT {
-		matrixT[i] = make([][]string, len(lista_cadena))
+		matrixT[i] = make([][]Symbol, len(lista_cadena))
 	}
 
 	// Llenar la matriz según la fila
 	for i := range matrixT {
 		for j := range lista_cadena {
-			if i == 0 { // Llenar la fila 0 con los heads que producen directamente los terminales
-				listado := FindHeadsProducingTerminal(grammar, string(lista_cadena[j]))
-				matrixT[i][j] = removeDuplicatesString(listado) // Eliminar duplicados y asignar a la matriz
-
-			} else if i == 1 && j < len(lista_cadena)-1 {
-				values1 := matrixT[0][j]   // Obtener los valores de la columna actual y la primera fila
-				values2 := matrixT[0][j+1] // Obtener los valores de la siguiente columna siguiente de la primera fila
-				listado := []string{}      // Crear un slice para almacenar los resultados
-
-				// Combinar valores de values1 y values2
-				for _, v1 := range values1 {
-					for _, v2 := range values2 {
-						// Llamar a FindHeadsProducingNonTerminals para el par de valores (v1, v2)
-						listado = append(listado, FindHeadsProducingNonTerminals(grammar, Symbol{Value: v1, IsTerminal: false}, Symbol{Value: v2, IsTerminal: false})...)
-					}
-				}
+			listado := []string{} // Crear un slice para almacenar los resultados
 
-				// Almacenar el resultado en la fila 1, columna j
-				matrixT[i][j] = removeDuplicatesString(listado) // Eliminar duplicados
-
-			} else if i > 1 && j < len(lista_cadena)-i { // Para las filas superiores evitar la ultima columna
-				listado := []string{} // Crear un slice para almacenar los resultados
+			if i == 0 { // Llenar la fila 0 con los heads que producen directamente los terminales
+				listado = FindHeadsProducingTerminal(grammar, string(lista_cadena[j]))
 
+			} else if j < len(lista_cadena)-i { // Para las filas superiores evitar las últimas columnas
 				// Probar todas las posibles particiones de la subcadena
 				for k := 0; k < i; k++ {
 					values1 := matrixT[k][j]         // Valores de la partición izquierda
@@ -46,39 +69,87 @@ func CYKParse(grammar *Grammar, cadena string, initialSymbol Symbol) bool {
 					// Combinar valores de values1 y values2
 					for _, v1 := range values1 {
 						for _, v2 := range values2 {
-							// Llamar a FindHeadsProducingNonTerminals para el par de valores (v1, v2)
-							listado = append(listado, FindHeadsProducingNonTerminals(grammar, Symbol{Value: v1, IsTerminal: false}, Symbol{Value: v2, IsTerminal: false})...)
+							listado = append(listado, FindHeadsProducingNonTerminals(grammar, v1, v2)...)
 						}
 					}
 				}
+			}
 
-				// Almacenar el resultado en la posición correspondiente
-				matrixT[i][j] = removeDuplicatesString(listado)
+			// Almacenar el resultado sin duplicados, las celdas restantes quedan vacías
+			matrixT[i][j] = make([]Symbol, 0, len(listado))
+			for _, head := range removeDuplicatesString(listado) {
+				matrixT[i][j] = append(matrixT[i][j], nonTerminalFromString(head))
+			}
+		}
+
+		observer.notify(Event{Kind: EventChartRow, Title: "Matriz después de completar la fila", Row: i, Table: matrixT})
+	}
+	result.Table = matrixT
+
+	// Si el símbolo inicial está en la última celda, entonces la cadena es aceptada
+	last := len(lista_cadena) - 1
+	result.Accepted = containsSymbol(matrixT[last][0], initialSymbol)
+	if result.Accepted {
+		result.Tree = cykTree(grammar, lista_cadena, matrixT, last, 0, initialSymbol)
+	}
+	return result
+}
 
-			} else {
-				// Para las filas restantes, sino tiene nada dejar vacío
-				matrixT[i][j] = []string{}
+// Construye el árbol de derivación de la subcadena de largo i+1 que empieza
+// en j a partir de head, que debe estar en la celda matrixT[i][j].
+func cykTree(grammar *Grammar, lista_cadena []rune, matrixT [][][]Symbol, i int, j int, head Symbol) *ParseTree {
+	for _, body := range grammar.Productions[head] {
+		// Fila 0: head -> terminal
+		if i == 0 {
+			if len(body) == 1 && body[0].IsTerminal && body[0] != EpsilonSymbol && body[0].Value == string(lista_cadena[j]) {
+				return &ParseTree{Symbol: head, Children: []*ParseTree{{Symbol: body[0]}}}
 			}
+			continue
 		}
 
-		// Imprimir el estado actual de la matriz después de completar la fila 'i'
-		fmt.Printf("Matriz después de completar la fila %d:\n", i)
-		for fi, fila := range matrixT {
-			fmt.Printf("Fila %d:\n", fi)
-			for j, heads := range fila {
-				fmt.Printf("  Columna %d: %v\n", j, heads)
+		// Filas superiores: head -> {B}{C}, con la primera partición que funcione
+		if len(body) != 2 || body[0].IsTerminal || body[1].IsTerminal {
+			continue
+		}
+		for k := 0; k < i; k++ {
+			if containsSymbol(matrixT[k][j], body[0]) && containsSymbol(matrixT[i-k-1][j+k+1], body[1]) {
+				return &ParseTree{Symbol: head, Children: []*ParseTree{
+					cykTree(grammar, lista_cadena, matrixT, k, j, body[0]),
+					cykTree(grammar, lista_cadena, matrixT, i-k-1, j+k+1, body[1]),
+				}}
 			}
 		}
 	}
+	return nil
+}
 
-	lastCell := matrixT[len(lista_cadena)-1][0]
+// Convierte una celda de la matriz ({Value_Id}) de vuelta en el no terminal que representa.
+func nonTerminalFromString(value string) Symbol {
+	symbol, _ := SymbolFromString(value)
+	return symbol
+}
 
-	// Si el símbolo inicial está en la última celda, entonces la cadena es aceptada
-	for _, head := range lastCell {
-		if head == initialSymbol.Value {
-			return true
-		}
+// Convierte cada símbolo a su forma {Value_Id}, como se imprimen las celdas de la matriz.
+func symbolsToStrings(symbols []Symbol) []string {
+	values := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		values = append(values, symbol.String())
 	}
+	return values
+}
 
-	return false
+// Returns: los terminales de las hojas de izquierda a derecha, es decir, la
+// cadena que deriva el árbol.
+func (tree *ParseTree) Yield() string {
+	if len(tree.Children) == 0 {
+		if tree.Symbol.IsTerminal && tree.Symbol != EpsilonSymbol {
+			return tree.Symbol.Value
+		}
+		return ""
+	}
+	yield := ""
+	for _, child := range tree.Children {
+		yield += child.Yield()
+	}
+	return yield
 }
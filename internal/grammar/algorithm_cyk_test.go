@@ -36,3 +36,28 @@ func TestCYKParse(t *testing.T) {
 		t.Logf("La cadena '%s' fue aceptada correctamente por la gramática.", cadena)
 	}
 }
+
+func TestCYKParseEmptyString(t *testing.T) {
+	// S -> a{S}b|ε genera la cadena vacía
+	nullableGrammar := &Grammar{Productions: make(map[Symbol][][]Symbol)}
+	nullableGrammar.AddProductionFromString("S -> a{S}b|ε")
+	simplified := SimplifyGrammar(nullableGrammar, false)
+
+	if !CYKParse(simplified, "", simplified.NonTerminals[0]) {
+		t.Errorf("Error: La cadena vacía debería ser aceptada por la gramática, pero fue rechazada.")
+	}
+	for _, cadena := range []string{"ab", "aabb"} {
+		if !CYKParse(simplified, cadena, simplified.NonTerminals[0]) {
+			t.Errorf("Error: La cadena '%s' debería ser aceptada por la gramática, pero fue rechazada.", cadena)
+		}
+	}
+
+	// S -> a{S}b|ab no genera la cadena vacía
+	nonNullableGrammar := &Grammar{Productions: make(map[Symbol][][]Symbol)}
+	nonNullableGrammar.AddProductionFromString("S -> a{S}b|ab")
+	simplified = SimplifyGrammar(nonNullableGrammar, false)
+
+	if CYKParse(simplified, "", simplified.NonTerminals[0]) {
+		t.Errorf("Error: La cadena vacía debería ser rechazada por la gramática, pero fue aceptada.")
+	}
+}
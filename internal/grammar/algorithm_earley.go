@@ -0,0 +1,183 @@
+package grammar
+
+/*
+Reconocedor de Earley. A diferencia de CYK funciona con cualquier gramática
+libre de contexto, sin convertirla a la forma normal de Chomsky: acepta
+producciones ε, producciones unarias y recursión por la izquierda. Por eso
+sirve para comparar el lenguaje de una gramática antes y después de cada
+pasada.
+
+Cada conjunto de la tabla tiene los items A -> α.β con el inicio del item.
+Con las producciones ε, al predecir un no terminal anulable también se avanza
+el punto sobre él (Aycock y Horspool), para no perder los items que se
+completan en el mismo conjunto.
+*/
+
+// Item de Earley: la regla rule con el punto antes del símbolo dot,
+// empezado en el conjunto origin.
+type earleyItem struct {
+	rule   int
+	dot    int
+	origin int
+}
+
+// Producción de la gramática con los símbolos numerados: un no terminal es
+// su número y un terminal es -1 menos el número de su valor.
+type earleyRule struct {
+	head int
+	body []int
+}
+
+// Checks with the Earley algorithm whether the grammar derives the input
+// from the start symbol. Terminals are compared by their value, like CYK, so
+// an input symbol can be a single character or a whole token.
+//
+// Returns: true if the input is in the language of the grammar.
+func EarleyRecognize(g *Grammar, input []Symbol, start Symbol) bool {
+	return newEarleyRecognizer(g, start).recognize(input)
+}
+
+// Gramática preparada para reconocer varias cadenas con Earley.
+type earleyRecognizer struct {
+	start     int
+	rules     []earleyRule
+	byHead    [][]int        // Reglas de cada no terminal.
+	nullables []bool         // No terminales que derivan la cadena vacía.
+	terminals map[string]int // Número de cada valor de terminal.
+}
+
+func newEarleyRecognizer(g *Grammar, start Symbol) *earleyRecognizer {
+	r := &earleyRecognizer{terminals: make(map[string]int)}
+	nonTerminals := make(map[Symbol]int)
+	number := func(symbol Symbol) int {
+		if symbol.IsTerminal {
+			if _, exist := r.terminals[symbol.Value]; !exist {
+				r.terminals[symbol.Value] = len(r.terminals)
+			}
+			return -1 - r.terminals[symbol.Value]
+		}
+		if _, exist := nonTerminals[symbol]; !exist {
+			nonTerminals[symbol] = len(nonTerminals)
+			r.byHead = append(r.byHead, nil)
+		}
+		return nonTerminals[symbol]
+	}
+
+	r.start = number(start)
+	for head, bodies := range g.Productions {
+		for _, body := range bodies {
+			// Un cuerpo ε queda vacío
+			rule := earleyRule{head: number(head), body: make([]int, 0, len(body))}
+			for _, symbol := range body {
+				if symbol != EpsilonSymbol {
+					rule.body = append(rule.body, number(symbol))
+				}
+			}
+			r.byHead[rule.head] = append(r.byHead[rule.head], len(r.rules))
+			r.rules = append(r.rules, rule)
+		}
+	}
+
+	r.nullables = make([]bool, len(r.byHead))
+	for changed := true; changed; {
+		changed = false
+		for _, rule := range r.rules {
+			nullable := !r.nullables[rule.head]
+			for _, symbol := range rule.body {
+				nullable = nullable && symbol >= 0 && r.nullables[symbol]
+			}
+			if nullable {
+				r.nullables[rule.head] = true
+				changed = true
+			}
+		}
+	}
+	return r
+}
+
+// Returns: true if the input is in the language of the grammar.
+func (r *earleyRecognizer) recognize(input []Symbol) bool {
+	tokens := make([]int, len(input))
+	for i, symbol := range input {
+		terminal, exist := r.terminals[symbol.Value]
+		if !exist {
+			// Ningún cuerpo tiene ese terminal
+			return false
+		}
+		tokens[i] = -1 - terminal
+	}
+
+	sets := make([][]earleyItem, len(tokens)+1)
+	seen := make([]map[earleyItem]bool, len(tokens)+1)
+	add := func(position int, item earleyItem) {
+		if !seen[position][item] {
+			seen[position][item] = true
+			sets[position] = append(sets[position], item)
+		}
+	}
+
+	seen[0] = make(map[earleyItem]bool)
+	for _, rule := range r.byHead[r.start] {
+		add(0, earleyItem{rule: rule})
+	}
+
+	for position := 0; position <= len(tokens); position++ {
+		if position < len(tokens) {
+			seen[position+1] = make(map[earleyItem]bool)
+		}
+		// El conjunto crece mientras se recorre
+		for i := 0; i < len(sets[position]); i++ {
+			item := sets[position][i]
+			rule := r.rules[item.rule]
+
+			// Completar: el item terminó, avanzar los que esperaban a su head
+			if item.dot == len(rule.body) {
+				for j := 0; j < len(sets[item.origin]); j++ {
+					waiting := sets[item.origin][j]
+					waitingBody := r.rules[waiting.rule].body
+					if waiting.dot < len(waitingBody) && waitingBody[waiting.dot] == rule.head {
+						add(position, earleyItem{rule: waiting.rule, dot: waiting.dot + 1, origin: waiting.origin})
+					}
+				}
+				continue
+			}
+
+			next := rule.body[item.dot]
+			if next < 0 {
+				// Leer: el terminal coincide con el siguiente símbolo de la entrada
+				if position < len(tokens) && tokens[position] == next {
+					add(position+1, earleyItem{rule: item.rule, dot: item.dot + 1, origin: item.origin})
+				}
+				continue
+			}
+
+			// Predecir: los cuerpos del no terminal empiezan en este conjunto
+			for _, predicted := range r.byHead[next] {
+				add(position, earleyItem{rule: predicted, origin: position})
+			}
+			if r.nullables[next] {
+				add(position, earleyItem{rule: item.rule, dot: item.dot + 1, origin: item.origin})
+			}
+		}
+	}
+
+	for _, item := range sets[len(tokens)] {
+		rule := r.rules[item.rule]
+		if rule.head == r.start && item.origin == 0 && item.dot == len(rule.body) {
+			return true
+		}
+	}
+	return false
+}
+
+// Checks a string like EarleyRecognize, taking every character as a
+// terminal, like CYKParse.
+//
+// Returns: true if the string is in the language of the grammar.
+func EarleyParse(g *Grammar, cadena string, start Symbol) bool {
+	input := make([]Symbol, 0, len(cadena))
+	for _, char := range cadena {
+		input = append(input, Symbol{IsTerminal: true, Value: string(char)})
+	}
+	return EarleyRecognize(g, input, start)
+}
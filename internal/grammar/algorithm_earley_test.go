@@ -0,0 +1,61 @@
+package grammar
+
+import "testing"
+
+func TestEarleyParseCNF(t *testing.T) {
+	// Earley acepta las mismas cadenas que CYK con una gramática en CNF
+	for _, cadena := range []string{"baaba", "ab", "bb", "a", "aab", "bbbaa", "abab", "b"} {
+		expected := CYKParse(testGrammar, cadena, SCYK)
+		if got := EarleyParse(testGrammar, cadena, SCYK); got != expected {
+			t.Errorf("%q: expected %v like CYK, but got %v", cadena, expected, got)
+		}
+	}
+}
+
+func TestEarleyParse(t *testing.T) {
+	tests := []struct {
+		productions []string
+		accepted    []string
+		rejected    []string
+	}{
+		{[]string{"S -> a{S}b|ε"}, []string{"", "ab", "aaabbb"}, []string{"a", "abb", "ba"}},
+		// Recursión por la izquierda y producciones unarias
+		{[]string{"E -> {E}+{T}|{T}", "T -> {T}*{F}|{F}", "F -> ({E})|x"}, []string{"x", "x+x*x", "(x+x)*x"}, []string{"", "x+", "(x", "x**x"}},
+		// Anulables que se completan en el mismo conjunto
+		{[]string{"S -> {A}{A}x{B}", "A -> ε|{B}", "B -> {A}|y"}, []string{"x", "yx", "yyxy"}, []string{"", "y", "yyyx"}},
+		// Terminales de varios caracteres y el caracter ε
+		{[]string{`S -> \ε{S}|ε`}, []string{"", "ε", "εε"}, []string{"e"}},
+	}
+	for _, test := range tests {
+		grammars, err := ParseGrammars("test.txt", test.productions)
+		if err != nil {
+			t.Fatalf("Expected no errors, but got:\n%v", err)
+		}
+		g := grammars[0]
+		for _, cadena := range test.accepted {
+			if !EarleyParse(g, cadena, g.GetStartSymbol()) {
+				t.Errorf("%v: expected %q to be accepted", test.productions, cadena)
+			}
+		}
+		for _, cadena := range test.rejected {
+			if EarleyParse(g, cadena, g.GetStartSymbol()) {
+				t.Errorf("%v: expected %q to be rejected", test.productions, cadena)
+			}
+		}
+	}
+}
+
+func TestEarleyRecognizeTokens(t *testing.T) {
+	g := &Grammar{Productions: make(map[Symbol][][]Symbol)}
+	ID := Symbol{IsTerminal: true, Value: "ID"}
+	comma := Symbol{IsTerminal: true, Value: ","}
+	list := g.AddProduction("list", [][]Symbol{{ID}})
+	g.AddProductionBodies(*list, [][]Symbol{{*list, comma, ID}})
+
+	if !EarleyRecognize(g, []Symbol{ID, comma, ID}, *list) {
+		t.Errorf("Expected ID , ID to be accepted")
+	}
+	if EarleyRecognize(g, []Symbol{ID, comma}, *list) {
+		t.Errorf("Expected ID , to be rejected")
+	}
+}
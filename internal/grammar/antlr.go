@@ -0,0 +1,478 @@
+package grammar
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	ast "github.com/DanielRasho/Computation-Theory/internal/abstract_syntax_tree"
+)
+
+/*
+Importador de las reglas de parser de una gramática de ANTLR 4 (.g4):
+
+	grammar Expr;
+	expr : left=term ('+' term)*   # Sum
+	     | '-' expr                # Negation
+	     ;
+	term : ID | INT | '(' expr ')' ;
+	ID   : [a-z]+ ;
+
+Soporta reglas con alternativas, grupos y los operadores ?, * y + (también
+sus versiones no codiciosas ??, *? y +?). Se ignoran las etiquetas (x=, x+=
+y # Nombre), acciones { }, predicados { }?, opciones < >, los bloques
+options, tokens, channels y @header, y las definiciones de reglas de lexer.
+
+Las cadenas como '+' o 'while' se dividen en un terminal por caracter, como
+en el resto de formatos. Las referencias a reglas de lexer (ID, INT) son un
+solo terminal con el nombre del token, y EOF se omite. CYK lee la entrada un
+caracter a la vez, así que CheckInputTerminals rechaza esos terminales antes
+de revisar una cadena. El símbolo inicial es la primera regla de parser.
+*/
+
+// antlrParser reads the parser rules of an ANTLR 4 grammar. The whole file
+// is read as a single sequence of characters, since rules are free format.
+type antlrParser struct {
+	ruleReader
+}
+
+// Blocks at the top level of a grammar that have no parser rules inside.
+var antlrSkippedBlocks = map[string]bool{"options": true, "tokens": true, "channels": true}
+
+// Statements at the top level of a grammar that end with ";" and have no parser rules inside.
+var antlrSkippedStatements = map[string]bool{"grammar": true, "parser": true, "lexer": true, "import": true, "mode": true}
+
+// Parses the parser rules of an ANTLR 4 grammar file.
+//
+// Returns: a list with the grammar, and a ParseErrors if any error was found.
+func ParseANTLR(file string, lines []string) ([]*Grammar, error) {
+	p := &antlrParser{ruleReader: ruleReader{grammarParser: &grammarParser{file: file}}}
+	grammars := make([]*Grammar, 0)
+	p.resetGrammar()
+
+	for index, line := range lines {
+		p.rule = append(p.rule, toSourceChars(line, index+1)...)
+		p.rule = append(p.rule, sourceChar{char: '\n', line: index + 1, column: len([]rune(line)) + 1})
+	}
+	p.line = len(lines)
+
+	for {
+		p.skipTrivia()
+		if p.i >= len(p.rule) {
+			break
+		}
+
+		switch current := p.peek(); {
+		case current == '@':
+			// @header { ... } o @parser::members { ... }
+			p.i++
+			p.identifier()
+			for p.peek() == ':' {
+				p.i++
+			}
+			p.identifier()
+			p.skipTrivia()
+			p.skipBlock()
+
+		case isANTLRIdentifierStart(current):
+			word := p.identifier()
+			switch {
+			case antlrSkippedStatements[word]:
+				p.skipUntilSemicolon()
+			case antlrSkippedBlocks[word]:
+				p.skipTrivia()
+				p.skipBlock()
+			case word == "fragment" || unicode.IsUpper([]rune(word)[0]):
+				// Reglas de lexer
+				p.skipUntilSemicolon()
+			default:
+				if !p.parserRule(Symbol{Value: word, IsTerminal: false, Id: 0}) {
+					p.skipUntilSemicolon()
+				}
+			}
+
+		default:
+			p.errorAtCurrent("unexpected character", "rules have the form name : alternatives ;")
+			p.skipUntilSemicolon()
+		}
+	}
+
+	if g := p.closeGrammar(); g != nil {
+		grammars = append(grammars, g)
+	}
+	if len(p.errors) > 0 {
+		return grammars, p.errors
+	}
+	return grammars, nil
+}
+
+// Parses a parser rule after its name, up to and including the ";".
+//
+// Returns: false if the rule had an error and the rest of it must be skipped.
+func (p *antlrParser) parserRule(head Symbol) bool {
+	// Argumentos, returns [..], locals [..], throws, options { } y @init { }
+	// antes de los dos puntos
+	for {
+		p.skipTrivia()
+		switch current := p.peek(); {
+		case current == ':':
+			p.i++
+		case current == '[':
+			p.skipBlock()
+			continue
+		case current == '@':
+			p.i++
+			p.identifier()
+			p.skipTrivia()
+			p.skipBlock()
+			continue
+		case isANTLRIdentifierStart(current):
+			if p.identifier() == "options" {
+				p.skipTrivia()
+				p.skipBlock()
+			}
+			continue
+		default:
+			p.errorAtCurrent("missing ':' after the rule name", "rules have the form name : alternatives ;")
+			return false
+		}
+		break
+	}
+
+	node, ok := p.alternatives()
+	if !ok {
+		return false
+	}
+	p.skipTrivia()
+	switch p.peek() {
+	case ';':
+		p.i++
+	case ')':
+		p.errorAtCurrent("unexpected ')'", "remove it or open the group with '('")
+		return false
+	default:
+		p.errorAtCurrent("missing ';' at the end of the rule", "rules end with ';'")
+		return false
+	}
+
+	p.addRule(head, node)
+	return true
+}
+
+// alternatives = alternative ("|" alternative)*
+func (p *antlrParser) alternatives() (ast.Node, bool) {
+	node, ok := p.alternative()
+	if !ok {
+		return nil, false
+	}
+	for {
+		p.skipTrivia()
+		if p.peek() != '|' {
+			return node, true
+		}
+		p.i++
+		next, ok := p.alternative()
+		if !ok {
+			return nil, false
+		}
+		node = ast.NewOperatorNode("|", []ast.Node{node, next})
+	}
+}
+
+// alternative = element* ["#" label]. An empty alternative is ε.
+func (p *antlrParser) alternative() (ast.Node, bool) {
+	nodes := make([]ast.Node, 0)
+	for {
+		p.skipTrivia()
+		switch current := p.peek(); {
+		case p.i >= len(p.rule) || current == '|' || current == ')' || current == ';':
+			return p.concatenate(nodes), true
+
+		case current == '#':
+			// Etiqueta de la alternativa
+			p.i++
+			p.skipTrivia()
+			p.identifier()
+
+		case current == '{':
+			// Acción o predicado
+			p.skipBlock()
+			if p.peek() == '?' {
+				p.i++
+			}
+
+		case current == '<':
+			// Opciones del elemento, como <assoc=right>
+			for p.i < len(p.rule) && p.peek() != '>' {
+				p.i++
+			}
+			p.i++
+
+		default:
+			node, ok := p.element()
+			if !ok {
+				return nil, false
+			}
+			if node != nil {
+				nodes = append(nodes, node)
+			}
+		}
+	}
+}
+
+// element = [label ("=" | "+=")] atom [("?" | "*" | "+") ["?"]]
+//
+// Returns: the element, nil if it must be omitted, and true if it had no errors.
+func (p *antlrParser) element() (ast.Node, bool) {
+	var node ast.Node
+	switch current := p.peek(); {
+	case isANTLRIdentifierStart(current):
+		name := p.identifier()
+
+		// Etiqueta x= o x+=
+		save := p.i
+		p.skipTrivia()
+		if p.peek() == '=' || (p.peek() == '+' && p.i+1 < len(p.rule) && p.rule[p.i+1].char == '=') {
+			if p.peek() == '+' {
+				p.i++
+			}
+			p.i++
+			p.skipTrivia()
+			return p.element()
+		}
+		p.i = save
+
+		switch {
+		case name == "EOF":
+			node = nil
+		case unicode.IsUpper([]rune(name)[0]):
+			node = p.operand(Symbol{Value: name, IsTerminal: true, Id: 0})
+		default:
+			node = p.operand(Symbol{Value: name, IsTerminal: false, Id: 0})
+		}
+
+	case current == '\'':
+		literal, ok := p.literal()
+		if !ok {
+			return nil, false
+		}
+		p.skipTrivia()
+		if strings.HasPrefix(sourceString(p.rule[p.i:min(p.i+2, len(p.rule))]), "..") {
+			p.errorAtCurrent("character ranges are not supported in parser rules", "move the range to a lexer rule")
+			return nil, false
+		}
+		nodes := make([]ast.Node, 0, len(literal))
+		for _, char := range literal {
+			nodes = append(nodes, p.operand(Symbol{Value: string(char), IsTerminal: true, Id: 0}))
+		}
+		node = p.concatenate(nodes)
+
+	case current == '(':
+		open := p.rule[p.i]
+		p.i++
+		group, ok := p.alternatives()
+		if !ok {
+			return nil, false
+		}
+		p.skipTrivia()
+		if p.peek() != ')' {
+			p.errorAt(open, "unclosed '('", "close the group with ')'")
+			return nil, false
+		}
+		p.i++
+		node = group
+
+	case current == '.' || current == '~':
+		p.errorAtCurrent("wildcards and negated sets are not supported", "list the allowed tokens as alternatives")
+		return nil, false
+
+	case current == '?' || current == '*' || current == '+':
+		p.errorAtCurrent("operator without operand", "write the element or group it applies to before it")
+		return nil, false
+
+	default:
+		p.errorAtCurrent("unexpected character", "elements are rule names, token names, literals like '+' and groups ( )")
+		return nil, false
+	}
+
+	// Operadores, con su versión no codiciosa. Puede haber espacios o
+	// comentarios antes del operador, como en (a | b) *
+	p.skipTrivia()
+	if operator := p.peek(); operator == '?' || operator == '*' || operator == '+' {
+		p.i++
+		if p.peek() == '?' {
+			p.i++
+		}
+		if node != nil {
+			node = ast.NewOperatorNode(string(operator), []ast.Node{node})
+		}
+	}
+	return node, true
+}
+
+// Reads a literal between single quotes, starting at the quote.
+//
+// Returns: the characters of the literal and true if it was valid.
+func (p *antlrParser) literal() ([]rune, bool) {
+	open := p.rule[p.i]
+	p.i++
+
+	chars := make([]rune, 0)
+	for p.i < len(p.rule) && p.peek() != '\'' && p.peek() != '\n' {
+		if p.peek() != '\\' {
+			chars = append(chars, p.peek())
+			p.i++
+			continue
+		}
+
+		escape := p.rule[p.i]
+		p.i++
+		switch p.peek() {
+		case 'n':
+			chars = append(chars, '\n')
+		case 'r':
+			chars = append(chars, '\r')
+		case 't':
+			chars = append(chars, '\t')
+		case 'b':
+			chars = append(chars, '\b')
+		case 'f':
+			chars = append(chars, '\f')
+		case '\\', '\'':
+			chars = append(chars, p.peek())
+		case 'u':
+			end := min(p.i+5, len(p.rule))
+			value, err := strconv.ParseInt(sourceString(p.rule[p.i+1:end]), 16, 32)
+			if err != nil || end-p.i != 5 {
+				p.errorAt(escape, "invalid unicode escape", `write unicode characters as \uXXXX`)
+				p.skipString()
+				p.i++
+				return nil, false
+			}
+			chars = append(chars, rune(value))
+			p.i += 4
+		default:
+			p.errorAt(escape, "unknown escape sequence", `valid escape sequences are \n \r \t \b \f \\ \' and \uXXXX`)
+			p.skipString()
+			p.i++
+			return nil, false
+		}
+		p.i++
+	}
+
+	if p.peek() != '\'' {
+		p.errorAt(open, "unclosed literal", "close the literal with '")
+		return nil, false
+	}
+	p.i++
+	if len(chars) == 0 {
+		p.errorAt(open, "empty literal", "leave the alternative empty to match the empty string")
+		return nil, false
+	}
+	return chars, true
+}
+
+// Reads an identifier, or nothing if there is none.
+func (p *antlrParser) identifier() string {
+	start := p.i
+	for p.i < len(p.rule) && (isANTLRIdentifierStart(p.peek()) || unicode.IsDigit(p.peek())) {
+		p.i++
+	}
+	return sourceString(p.rule[start:p.i])
+}
+
+// Skips a block between braces or brackets, including nested blocks and
+// strings inside it. Does nothing if the current character does not open a block.
+func (p *antlrParser) skipBlock() {
+	closing := map[rune]rune{'{': '}', '[': ']'}[p.peek()]
+	if closing == 0 {
+		return
+	}
+	open := p.rule[p.i]
+
+	depth := 0
+	for ; p.i < len(p.rule); p.i++ {
+		switch p.peek() {
+		case open.char:
+			depth++
+		case closing:
+			depth--
+			if depth == 0 {
+				p.i++
+				return
+			}
+		case '"', '\'':
+			p.skipString()
+		}
+	}
+	p.errorAt(open, "unclosed '"+string(open.char)+"'", "close the block with '"+string(closing)+"'")
+}
+
+// Skips a string, starting at its opening quote or inside it, leaving the
+// position at its closing quote.
+func (p *antlrParser) skipString() {
+	quote := p.peek()
+	if quote != '"' && quote != '\'' {
+		quote = '\''
+	}
+	for p.i++; p.i < len(p.rule) && p.peek() != quote && p.peek() != '\n'; p.i++ {
+		if p.peek() == '\\' {
+			p.i++
+		}
+	}
+}
+
+// Skips everything up to and including the next ";" outside blocks and literals.
+func (p *antlrParser) skipUntilSemicolon() {
+	for p.i < len(p.rule) {
+		p.skipTrivia()
+		switch p.peek() {
+		case ';':
+			p.i++
+			return
+		case '{', '[':
+			p.skipBlock()
+		case '\'':
+			p.skipString()
+			p.i++
+		default:
+			p.i++
+		}
+	}
+}
+
+// Skips spaces, line breaks and comments.
+func (p *antlrParser) skipTrivia() {
+	for p.i < len(p.rule) {
+		next := rune(0)
+		if p.i+1 < len(p.rule) {
+			next = p.rule[p.i+1].char
+		}
+
+		switch {
+		case unicode.IsSpace(p.peek()):
+			p.i++
+		case p.peek() == '/' && next == '/':
+			for p.i < len(p.rule) && p.peek() != '\n' {
+				p.i++
+			}
+		case p.peek() == '/' && next == '*':
+			open := p.rule[p.i]
+			p.i += 2
+			for p.i < len(p.rule) && !(p.peek() == '*' && p.i+1 < len(p.rule) && p.rule[p.i+1].char == '/') {
+				p.i++
+			}
+			if p.i >= len(p.rule) {
+				p.errorAt(open, "unclosed comment", "close the comment with */")
+				return
+			}
+			p.i += 2
+		default:
+			return
+		}
+	}
+}
+
+func isANTLRIdentifierStart(char rune) bool {
+	return unicode.IsLetter(char) || char == '_'
+}
@@ -0,0 +1,134 @@
+package grammar
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseANTLR(t *testing.T) {
+	lines := []string{
+		"grammar Expr;",
+		"options { language = Java; }",
+		"@header { package expr; }",
+		"",
+		"// Reglas de parser",
+		"prog : stat+ EOF ;",
+		"stat : left=expr ';'     # Print",
+		"     | ids+=ID '=' expr? # Assign",
+		"     |                   # Empty",
+		"     ;",
+		"expr",
+		"  : <assoc=right> expr '^' expr",
+		"  | '(' expr ')' {System.out.println(\"}\");}",
+		"  | INT",
+		"  ;",
+		"",
+		"/* Reglas de lexer */",
+		"ID  : [a-z]+ ;",
+		"fragment DIGIT : '0'..'9' ;",
+		"INT : DIGIT+ ;",
+		"WS  : [ \\t\\r\\n]+ -> skip ;",
+	}
+
+	grammars, err := ParseANTLR("Expr.g4", lines)
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+
+	expected := `{prog_0} -> {prog_1}
+{prog_1} -> {stat_0}{prog_1}|{stat_0}
+{stat_0} -> {expr_0};|ID={stat_1}|ε
+{stat_1} -> {expr_0}|ε
+{expr_0} -> {expr_0}^{expr_0}|({expr_0})|INT
+`
+	if got := grammars[0].String(false); got != expected {
+		t.Errorf("Expected %q,\n but got %q", expected, got)
+	}
+	if start := grammars[0].GetStartSymbol(); start.Value != "prog" {
+		t.Errorf("Expected start symbol prog, but got %s", start.String())
+	}
+}
+
+func TestParseANTLROperatorsAfterSpaces(t *testing.T) {
+	compact, err := ParseANTLR("List.g4", []string{"list : '[' (item (',' item)*)? ']' ;", "item : 'x'+ | list ;"})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+	spaced, err := ParseANTLR("List.g4", []string{"list : '[' (item (',' item) *) ? ']' ;", "item : 'x' /* una o más */ + | list ;"})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+
+	if expected, got := compact[0].String(false), spaced[0].String(false); got != expected {
+		t.Errorf("Expected %q,\n but got %q", expected, got)
+	}
+}
+
+func TestSimplifyGrammarANTLR(t *testing.T) {
+	grammars, err := ParseANTLR("List.g4", []string{
+		"grammar List;",
+		"list : '[' (item (',' item)*)? ']' ;",
+		"item : 'x' | list ;",
+	})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+
+	simplified := SimplifyGrammar(grammars[0], false)
+	start := simplified.GetStartSymbol()
+
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"[]", true},
+		{"[x,[x,x],[]]", true},
+		{"[x,]", false},
+		{"[x", false},
+	}
+	for _, tt := range tests {
+		if got := CYKParse(simplified, tt.input, start); got != tt.expected {
+			t.Errorf("CYKParse(%q) = %v, expected %v", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestParseANTLRErrors(t *testing.T) {
+	lines := []string{
+		"grammar Bad;",
+		"a : 'x' ( 'y' ;",
+		"b 'x' ;",
+		"c : . ;",
+		"d : 'x' ",
+		"  ;",
+		"e : 'q\\z' ;",
+		"f : * 'x' ;",
+	}
+
+	expected := []ParseError{
+		{Line: 2, Column: 9, Char: "(", Message: "unclosed '('"},
+		{Line: 3, Column: 3, Char: "'", Message: "missing ':' after the rule name"},
+		{Line: 4, Column: 5, Char: ".", Message: "wildcards and negated sets are not supported"},
+		{Line: 7, Column: 7, Char: "\\", Message: "unknown escape sequence"},
+		{Line: 8, Column: 5, Char: "*", Message: "operator without operand"},
+	}
+
+	_, err := ParseANTLR("Bad.g4", lines)
+
+	var parseErrors ParseErrors
+	if !errors.As(err, &parseErrors) {
+		t.Fatalf("Expected ParseErrors, but got %v", err)
+	}
+	if len(parseErrors) != len(expected) {
+		t.Fatalf("Expected %d errors, but got %d:\n%v", len(expected), len(parseErrors), err)
+	}
+	for i, e := range expected {
+		got := parseErrors[i]
+		if got.File != "Bad.g4" || got.Line != e.Line || got.Column != e.Column || got.Char != e.Char || got.Message != e.Message {
+			t.Errorf("Expected error %d to be %v, but got %v", i, e, got)
+		}
+		if got.Hint == "" {
+			t.Errorf("Expected error %d to have a hint", i)
+		}
+	}
+}
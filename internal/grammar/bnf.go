@@ -0,0 +1,237 @@
+package grammar
+
+import (
+	"strings"
+)
+
+/*
+Lector de gramáticas escritas en BNF:
+
+	<expr> ::= <term> "+" <expr> | <term>
+	<term> ::= "x" | "(" <expr> ")"
+	       | "-" <term>
+
+Los no terminales se escriben entre < >, y los terminales entre comillas
+dobles o simples. Cada caracter de un terminal es un terminal de la gramática,
+y "" representa la cadena vacía. Una línea que empieza con | continúa la regla
+anterior. El símbolo inicial es la cabeza de la primera regla.
+*/
+
+// Symbol that separates the head of a BNF rule from its alternatives.
+const BNFDefinition = "::="
+
+// Parses the lines of a BNF file. Like ParseGrammars, grammars are separated
+// by a "---" line, and empty lines and lines starting with "#" or ";" are ignored.
+//
+// Returns: the list of grammars, and a ParseErrors if any error was found.
+func ParseBNF(file string, lines []string) ([]*Grammar, error) {
+	p := &grammarParser{file: file}
+	grammars := make([]*Grammar, 0)
+	p.resetGrammar()
+
+	var lastHead *Symbol // Head of the last rule, continued by lines starting with "|"
+	for index, line := range lines {
+		p.line = index + 1
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case line == GrammarSeparator:
+			if g := p.closeGrammar(); g != nil {
+				grammars = append(grammars, g)
+			}
+			lastHead = nil
+		case trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";"):
+			continue
+		default:
+			head, bodies, ok := p.parseBNFRule([]rune(line), lastHead)
+			if head != nil {
+				lastHead = head
+			}
+			if ok {
+				p.current.addProductionSymbols(*lastHead, bodies)
+			}
+		}
+	}
+	if g := p.closeGrammar(); g != nil {
+		grammars = append(grammars, g)
+	}
+
+	if len(p.errors) > 0 {
+		return grammars, p.errors
+	}
+	return grammars, nil
+}
+
+// Parses a rule with the shape <head> ::= alternatives, or a line starting
+// with "|" that adds alternatives to lastHead.
+//
+// Returns: the head of the rule (nil for continuation lines or if it could
+// not be read), the bodies and true if the line had no errors.
+func (p *grammarParser) parseBNFRule(line []rune, lastHead *Symbol) (*Symbol, [][]Symbol, bool) {
+	errorCount := len(p.errors)
+	i := skipBNFSpaces(line, 0)
+
+	if line[i] == '|' {
+		if lastHead == nil {
+			p.addError(bnfToken(line, i), "alternatives without a rule", "start the rule with <name> ::=")
+			return nil, nil, false
+		}
+		bodies := p.parseBNFAlternatives(line, i+1)
+		return nil, bodies, len(p.errors) == errorCount
+	}
+
+	// HEAD
+	if line[i] != '<' {
+		if strings.HasPrefix(string(line[i:]), BNFDefinition) {
+			p.addError(bnfToken(line, i), "missing rule head", "write the nonterminal being defined before '::='")
+		} else {
+			p.addError(bnfToken(line, i), "unexpected character at the start of a rule", "rules have the form <name> ::= alternatives")
+		}
+		return nil, nil, false
+	}
+	head, i, ok := p.parseBNFNonTerminal(line, i)
+	if !ok {
+		return nil, nil, false
+	}
+
+	// ::=
+	i = skipBNFSpaces(line, i)
+	if !strings.HasPrefix(string(line[i:]), BNFDefinition) {
+		p.addError(bnfToken(line, i), "missing '::=' after the rule head", "rules have the form <name> ::= alternatives")
+		return &head, nil, false
+	}
+
+	// BODIES
+	bodies := p.parseBNFAlternatives(line, i+len(BNFDefinition))
+	return &head, bodies, len(p.errors) == errorCount
+}
+
+// Parses the alternatives of a rule, starting at line[i].
+func (p *grammarParser) parseBNFAlternatives(line []rune, i int) [][]Symbol {
+	bodies := make([][]Symbol, 0)
+	body := make([]Symbol, 0)
+	errorCount := len(p.errors)
+
+	for {
+		i = skipBNFSpaces(line, i)
+
+		switch {
+		case i == len(line) || line[i] == '|':
+			// An alternative left empty by other errors was already reported
+			if len(body) == 0 && len(p.errors) == errorCount {
+				p.addError(bnfToken(line, i), "empty alternative", `use "" to write the empty string`)
+			}
+			bodies = append(bodies, body)
+			if i == len(line) {
+				return bodies
+			}
+			body = make([]Symbol, 0)
+			errorCount = len(p.errors)
+			i++
+
+		case line[i] == '<':
+			nonTerminal, next, ok := p.parseBNFNonTerminal(line, i)
+			if ok {
+				body = append(body, nonTerminal)
+			}
+			i = next
+
+		case line[i] == '"' || line[i] == '\'':
+			terminals, next, ok := p.parseBNFString(line, i)
+			if ok {
+				body = appendBNFSymbols(body, terminals)
+			}
+			i = next
+
+		default:
+			p.addError(bnfToken(line, i), "unexpected character",
+				`terminals are written between quotes, like "a", and nonterminals between angle brackets, like <expr>`)
+			i++
+		}
+	}
+}
+
+// Parses a nonterminal written between angle brackets, starting at the "<"
+// in line[i]. Hyphens in the name are replaced by underscores.
+//
+// Returns: the nonterminal, the index after it, and true if it had no errors.
+func (p *grammarParser) parseBNFNonTerminal(line []rune, i int) (Symbol, int, bool) {
+	end := i + 1
+	for end < len(line) && line[end] != '>' {
+		end++
+	}
+	if end == len(line) {
+		p.addError(bnfToken(line, i), "unclosed '<'", "close the nonterminal name with '>'")
+		return Symbol{}, end, false
+	}
+
+	name := strings.ReplaceAll(string(line[i+1:end]), "-", "_")
+	if name == "" {
+		p.addError(bnfToken(line, end), "empty nonterminal name", "write the name of the nonterminal between the angle brackets, like <expr>")
+		return Symbol{}, end + 1, false
+	}
+	for offset, char := range []rune(name) {
+		if !isNonTerminalChar(char) {
+			p.addError(bnfToken(line, i+1+offset), "invalid character in nonterminal name",
+				"nonterminal names may only use letters, digits, '-', '_' and primes, like <expr-list>")
+			return Symbol{}, end + 1, false
+		}
+	}
+
+	return Symbol{Value: name, IsTerminal: false, Id: 0}, end + 1, true
+}
+
+// Parses a quoted string, starting at the quote in line[i].
+//
+// Returns: a terminal for each character of the string (ε for ""), the index
+// after the closing quote, and true if the string was closed.
+func (p *grammarParser) parseBNFString(line []rune, i int) ([]Symbol, int, bool) {
+	quote := line[i]
+	end := i + 1
+	for end < len(line) && line[end] != quote {
+		end++
+	}
+	if end == len(line) {
+		p.addError(bnfToken(line, i), "unclosed string", "close the terminal with "+string(quote))
+		return nil, end, false
+	}
+
+	if end == i+1 {
+		return []Symbol{EpsilonSymbol}, end + 1, true
+	}
+	terminals := make([]Symbol, 0, end-i-1)
+	for _, char := range line[i+1 : end] {
+		terminals = append(terminals, Symbol{Value: string(char), IsTerminal: true, Id: 0})
+	}
+	return terminals, end + 1, true
+}
+
+// Appends symbols to a body, keeping ε only when it is the whole body.
+func appendBNFSymbols(body []Symbol, symbols []Symbol) []Symbol {
+	for _, symbol := range symbols {
+		switch {
+		case symbol == EpsilonSymbol && len(body) > 0:
+			continue
+		case len(body) == 1 && body[0] == EpsilonSymbol:
+			body[0] = symbol
+		default:
+			body = append(body, symbol)
+		}
+	}
+	return body
+}
+
+func skipBNFSpaces(line []rune, i int) int {
+	for i < len(line) && (line[i] == ' ' || line[i] == '\t') {
+		i++
+	}
+	return i
+}
+
+// returns: a token pointing to line[i], used to report errors.
+func bnfToken(line []rune, i int) token {
+	if i >= len(line) {
+		return token{kind: tokenEOL, value: "", column: len(line) + 1}
+	}
+	return token{kind: tokenChar, value: string(line[i]), column: i + 1}
+}
@@ -0,0 +1,88 @@
+package grammar
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseBNF(t *testing.T) {
+	lines := []string{
+		"; Expresiones",
+		`<expr> ::= <term> "+" <expr> | <term>`,
+		`<term> ::= 'x' | "(" <expr> ")"`,
+		`       | "-" <term>`,
+		`<opt-sign> ::= "" | "+-"`,
+	}
+
+	grammars, err := ParseBNF("test.bnf", lines)
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+	if len(grammars) != 1 {
+		t.Fatalf("Expected 1 grammar, but got %d", len(grammars))
+	}
+
+	expected := `{expr_0} -> {term_0}+{expr_0}|{term_0}
+{term_0} -> x|({expr_0})|-{term_0}
+{opt_sign_0} -> ε|+-
+`
+	if got := grammars[0].String(false); got != expected {
+		t.Errorf("Expected %q,\n but got %q", expected, got)
+	}
+	if start := grammars[0].GetStartSymbol(); start.Value != "expr" {
+		t.Errorf("Expected start symbol expr, but got %s", start.String())
+	}
+}
+
+func TestParseBNFMatchesParseGrammars(t *testing.T) {
+	bnf, err := ParseBNF("test.bnf", []string{`<S> ::= "a" <S> "b" | ""`})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+	native, err := ParseGrammars("test.txt", []string{"S -> a{S}b|ε"})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+	if bnf[0].String(true) != native[0].String(true) {
+		t.Errorf("Expected %q,\n but got %q", native[0].String(true), bnf[0].String(true))
+	}
+}
+
+func TestParseBNFErrors(t *testing.T) {
+	lines := []string{
+		`| "a"`,
+		`<S> = "a"`,
+		`<S> ::= "a | <A`,
+		`<S> ::= a | |<>`,
+		`<A.b> ::= "x"`,
+	}
+
+	expected := []ParseError{
+		{Line: 1, Column: 1, Char: "|", Message: "alternatives without a rule"},
+		{Line: 2, Column: 5, Char: "=", Message: "missing '::=' after the rule head"},
+		{Line: 3, Column: 9, Char: "\"", Message: "unclosed string"},
+		{Line: 4, Column: 9, Char: "a", Message: "unexpected character"},
+		{Line: 4, Column: 13, Char: "|", Message: "empty alternative"},
+		{Line: 4, Column: 15, Char: ">", Message: "empty nonterminal name"},
+		{Line: 5, Column: 3, Char: ".", Message: "invalid character in nonterminal name"},
+	}
+
+	_, err := ParseBNF("test.bnf", lines)
+
+	var parseErrors ParseErrors
+	if !errors.As(err, &parseErrors) {
+		t.Fatalf("Expected ParseErrors, but got %v", err)
+	}
+	if len(parseErrors) != len(expected) {
+		t.Fatalf("Expected %d errors, but got %d:\n%v", len(expected), len(parseErrors), err)
+	}
+	for i, e := range expected {
+		got := parseErrors[i]
+		if got.File != "test.bnf" || got.Line != e.Line || got.Column != e.Column || got.Char != e.Char || got.Message != e.Message {
+			t.Errorf("Expected error %d to be %v, but got %v", i, e, got)
+		}
+		if got.Hint == "" {
+			t.Errorf("Expected error %d to have a hint", i)
+		}
+	}
+}
@@ -1,21 +1,92 @@
 package grammar
 
+/*
+Agrega un nuevo símbolo inicial S' -> S|ε cuando el símbolo inicial es anulable
+o aparece en el cuerpo de alguna producción, de modo que la forma normal de
+Chomsky conserve la cadena vacía sin que ε aparezca en otro cuerpo.
+
+Como la gramática ya no tiene producciones unarias, S' -> S se expande
+directamente con los cuerpos de S. El nuevo símbolo queda como el símbolo
+inicial y el primer no terminal de la lista.
+*/
+func CNFAddStartSymbol(originalGrammar *Grammar, nullable bool) *Grammar {
+	originalGrammar = originalGrammar.Clone()
+	startSymbol := originalGrammar.GetStartSymbol()
+	if !nullable && !appearsOnRightHandSide(originalGrammar, startSymbol) {
+		return originalGrammar
+	}
+
+	// Buscar un Id libre para el nuevo símbolo inicial
+	newStart := originalGrammar.freshNonTerminal(startSymbol.Value)
+
+	newGrammar := &Grammar{
+		terminals:    originalGrammar.terminals,
+		NonTerminals: append([]Symbol{newStart}, originalGrammar.NonTerminals...),
+		StartSymbol:  newStart,
+		Productions:  make(map[Symbol][][]Symbol),
+	}
+	for head, bodies := range originalGrammar.Productions {
+		newGrammar.Productions[head] = bodies
+	}
+
+	// S' -> S, ya sin producciones unarias
+	newBodies := make([][]Symbol, 0, len(originalGrammar.Productions[startSymbol])+1)
+	newBodies = append(newBodies, originalGrammar.Productions[startSymbol]...)
+	// S' -> ε, sin registrar ε como terminal
+	if nullable {
+		newBodies = append(newBodies, []Symbol{EpsilonSymbol})
+	}
+	newGrammar.Productions[newStart] = newBodies
+
+	return newGrammar
+}
+
+/*
+Verifica si un símbolo ya es un no terminal de la gramática.
+*/
+func isNonTerminalOf(grammar *Grammar, symbol Symbol) bool {
+	if _, exist := grammar.Productions[symbol]; exist {
+		return true
+	}
+	return containsSymbol(grammar.NonTerminals, symbol)
+}
+
+/*
+Verifica si un símbolo aparece en el cuerpo de alguna producción.
+*/
+func appearsOnRightHandSide(grammar *Grammar, symbol Symbol) bool {
+	for _, bodies := range grammar.Productions {
+		for _, body := range bodies {
+			if containsSymbol(body, symbol) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 /*
 Reemplazar los cuerpos de las producciones de longitud mayor o igual a 2 que contienen terminales, creando nuevos no terminales para cada terminal.
 */
 func CNFTerminalSubstitution(originalGrammar *Grammar) *Grammar {
+	originalGrammar = originalGrammar.Clone()
 	// Paso 1: Crear una copia de la gramática original para añadir las nuevas producciones
 	newGrammar := &Grammar{
 		terminals:    originalGrammar.terminals,
 		NonTerminals: originalGrammar.NonTerminals,
+		StartSymbol:  originalGrammar.GetStartSymbol(),
 		Productions:  make(map[Symbol][][]Symbol),
 	}
 
 	// Paso 2: Crear un nuevo no terminal por cada terminal que aparece en producciones de longitud >= 2
 	terminalToNonTerminal := make(map[Symbol]Symbol)
 	for _, terminal := range originalGrammar.terminals {
-		// Crear un nuevo símbolo no terminal que reemplazará al terminal
+		// Crear un nuevo símbolo no terminal que reemplazará al terminal, sin chocar
+		// con un no terminal que ya tenga el mismo nombre
 		newNonTerminal := Symbol{IsTerminal: false, Value: terminal.Value, Id: terminal.Id + 1}
+		for isNonTerminalOf(originalGrammar, newNonTerminal) {
+			newNonTerminal.Id++
+		}
 		terminalToNonTerminal[terminal] = newNonTerminal
 
 		// Añadir el nuevo no terminal a la lista de no terminales
@@ -26,7 +97,8 @@ func CNFTerminalSubstitution(originalGrammar *Grammar) *Grammar {
 	}
 
 	// Paso 3: Modificar las producciones de la gramática original, reemplazando terminales por no terminales
-	for head, productions := range originalGrammar.Productions {
+	for _, head := range productionHeads(originalGrammar) {
+		productions := originalGrammar.Productions[head]
 		newProductions := make([][]Symbol, 0)
 
 		for _, production := range productions {
@@ -68,16 +140,19 @@ func replaceTerminalsWithNonTerminals(production []Symbol, terminalToNonTerminal
 Divide producciones largas (de longitud > 2) en producciones binarias.
 */
 func CNFSplitLargeProductions(originalGrammar *Grammar) *Grammar {
+	originalGrammar = originalGrammar.Clone()
 	// Crear una nueva gramática para almacenar las producciones resultantes
 	newGrammar := &Grammar{
 		terminals:    originalGrammar.terminals,
 		NonTerminals: originalGrammar.NonTerminals,
+		StartSymbol:  originalGrammar.GetStartSymbol(),
 		Productions:  make(map[Symbol][][]Symbol),
 	}
 
-	// Iterar sobre las producciones de la gramática original
-	for head, productions := range originalGrammar.Productions {
-		for _, production := range productions {
+	// Iterar sobre las producciones de la gramática original, en el orden de
+	// los no terminales para que los nuevos símbolos sean siempre los mismos
+	for _, head := range productionHeads(originalGrammar) {
+		for _, production := range originalGrammar.Productions[head] {
 			// Si la producción tiene más de 2 símbolos, se debe dividir
 			for len(production) > 2 {
 				// Obtener los dos últimos símbolos de la producción
@@ -93,6 +168,12 @@ func CNFSplitLargeProductions(originalGrammar *Grammar) *Grammar {
 					Value:      lastSymbol2.Value + "_" + lastSymbol1.Value, // Nombre combinado de los símbolos
 					Id:         0,                                           // id 0 porque son nuevas producciones que no derivan de nada
 				}
+				// Si el nombre ya está en uso por otro no terminal, buscar el siguiente Id libre.
+				// Un símbolo ya creado para el mismo par se reutiliza.
+				for isNonTerminalOf(originalGrammar, newSymbol) ||
+					(newGrammar.Productions[newSymbol] != nil && !areSymbolSlicesEqual(newGrammar.Productions[newSymbol][0], []Symbol{lastSymbol2, lastSymbol1})) {
+					newSymbol.Id++
+				}
 
 				// Añadir el nuevo no terminal a la lista de no terminales si no está presente
 				newGrammar.NonTerminals = append(newGrammar.NonTerminals, newSymbol)
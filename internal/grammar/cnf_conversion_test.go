@@ -75,3 +75,61 @@ func TestCNFSplitLargeProductions(t *testing.T) {
 		t.Errorf("Error: La gramática resultante de CNFSplitLargeProductions no coincide con la esperada.\nEsperado: %v\nObtenido: %v", expectedTestCNFSplitLargeProductions.String(true), result.String(true))
 	}
 }
+
+// Símbolos para la prueba del nuevo símbolo inicial
+var S3 = Symbol{IsTerminal: false, Value: "S", Id: 0}
+var S3New = Symbol{IsTerminal: false, Value: "S", Id: 1}
+var a3 = Symbol{IsTerminal: true, Value: "a", Id: 0}
+var b3 = Symbol{IsTerminal: true, Value: "b", Id: 0}
+
+// S -> aSb|ab, donde S aparece en el cuerpo de una producción
+var grammarTestCNFAddStartSymbol = &Grammar{
+	terminals:    []Symbol{a3, b3},
+	NonTerminals: []Symbol{S3},
+	StartSymbol:  S3,
+	Productions: map[Symbol][][]Symbol{
+		S3: {{a3, S3, b3}, {a3, b3}},
+	},
+}
+
+// Resultado esperado si S era anulable en la gramática original
+var expectedTestCNFAddStartSymbol = &Grammar{
+	terminals:    []Symbol{a3, b3},
+	NonTerminals: []Symbol{S3New, S3},
+	Productions: map[Symbol][][]Symbol{
+		S3New: {{a3, S3, b3}, {a3, b3}, {EpsilonSymbol}},
+		S3:    {{a3, S3, b3}, {a3, b3}},
+	},
+}
+
+// Test para la función CNFAddStartSymbol
+func TestCNFAddStartSymbol(t *testing.T) {
+	result := CNFAddStartSymbol(grammarTestCNFAddStartSymbol, true)
+
+	if newStart := result.GetStartSymbol(); newStart != S3New {
+		t.Errorf("Error: Se esperaba el nuevo símbolo inicial %s, pero se obtuvo %s", S3New.String(), newStart.String())
+	}
+	if result.NonTerminals[0] != S3New {
+		t.Errorf("Error: El nuevo símbolo inicial debería ser el primer no terminal, pero se obtuvo %s", result.NonTerminals[0].String())
+	}
+	if !compareGrammars(result, expectedTestCNFAddStartSymbol) {
+		t.Errorf("Error: La gramática resultante de CNFAddStartSymbol no coincide con la esperada.\nEsperado: %v\nObtenido: %v", expectedTestCNFAddStartSymbol.String(true), result.String(true))
+	}
+}
+
+// Si el símbolo inicial no es anulable ni aparece en un cuerpo, la gramática no cambia
+func TestCNFAddStartSymbolNotNeeded(t *testing.T) {
+	g := &Grammar{
+		terminals:    []Symbol{a3},
+		NonTerminals: []Symbol{S3},
+		Productions: map[Symbol][][]Symbol{
+			S3: {{a3}},
+		},
+	}
+
+	result := CNFAddStartSymbol(g, false)
+
+	if newStart := result.GetStartSymbol(); newStart != S3 || result.String(true) != g.String(true) {
+		t.Errorf("Error: No se esperaba un nuevo símbolo inicial, pero se obtuvo %s", newStart.String())
+	}
+}
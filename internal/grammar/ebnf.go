@@ -0,0 +1,259 @@
+package grammar
+
+import (
+	"strconv"
+
+	ast "github.com/DanielRasho/Computation-Theory/internal/abstract_syntax_tree"
+	"github.com/DanielRasho/Computation-Theory/internal/shuntingyard"
+)
+
+/*
+Operadores EBNF que se pueden usar dentro de los cuerpos de una gramática con
+la directiva %ebnf:
+
+	(a|b)  agrupa alternativas
+	x?     x es opcional
+	x*     x se repite cero o más veces
+	x+     x se repite una o más veces
+
+Antes de simplificar la gramática los operadores se traducen a producciones
+normales, creando no terminales auxiliares con el mismo nombre que la cabeza
+y un Id nuevo:
+
+	{Args} -> {Expr}(,{Expr})*
+
+	{Args_0} -> {Expr_0}{Args_1}
+	{Args_1} -> ,{Expr_0}{Args_1}|ε
+*/
+
+// Characters that are EBNF operators inside a %ebnf grammar.
+var ebnfOperators = map[string]bool{"(": true, ")": true, "?": true, "*": true, "+": true}
+
+// ebnfItem is an operand or operator of an EBNF body, with the token it was read from.
+type ebnfItem struct {
+	symbol   Symbol // Grammar symbol, only for operands.
+	operator string // Operator, empty for operands.
+	at       token
+}
+
+// Parses the bodies of a production that may use EBNF operators, starting
+// at tokens[i], and adds the helper productions they need to the current grammar.
+//
+// Returns: the bodies of the head and true if they had no errors.
+func (p *grammarParser) parseEBNFBodies(tokens []token, i int, head Symbol) ([][]Symbol, bool) {
+	errorCount := len(p.errors)
+	items := p.readEBNFItems(tokens, i)
+	if len(p.errors) != errorCount {
+		return nil, false
+	}
+	if !p.checkEBNFItems(items) {
+		return nil, false
+	}
+
+	// Convertir los items a símbolos del shunting yard. Los operandos se
+	// guardan como el índice del símbolo de la gramática que representan.
+	operands := make([]Symbol, 0)
+	infix := make([]shuntingyard.Symbol, 0, len(items))
+	for _, item := range items[:len(items)-1] {
+		if item.operator != "" {
+			infix = append(infix, shuntingyard.OPERATORS[item.operator])
+			continue
+		}
+		infix = append(infix, shuntingyard.NewCharacter(strconv.Itoa(len(operands))))
+		operands = append(operands, item.symbol)
+	}
+
+	postfix, err := shuntingyard.SymbolsToPostfix(infix, false)
+	if err != nil {
+		p.addError(tokens[i], err.Error(), "")
+		return nil, false
+	}
+
+	// La cabeza se registra antes que los no terminales auxiliares para que
+	// estos reciban un Id distinto
+	if _, exist := p.current.Productions[head]; !exist {
+		p.current.addProductionSymbols(head, nil)
+	}
+
+	d := &ebnfDesugarer{grammar: p.current, head: head, symbols: operands}
+	return d.alternatives(ast.BuildAST(postfix)), true
+}
+
+// Reads the operands and operators of an EBNF body, starting at tokens[i].
+func (p *grammarParser) readEBNFItems(tokens []token, i int) []ebnfItem {
+	items := make([]ebnfItem, 0)
+
+	for tokens[i].kind != tokenEOL {
+		current := tokens[i]
+		switch current.kind {
+		case tokenPipe:
+			items = append(items, ebnfItem{operator: "|", at: current})
+			i++
+
+		case tokenEpsilon:
+			items = append(items, ebnfItem{symbol: EpsilonSymbol, at: current})
+			i++
+
+		case tokenLBrace:
+			nonTerminal, next, ok := p.parseNonTerminal(tokens, i)
+			if ok {
+				items = append(items, ebnfItem{symbol: nonTerminal, at: current})
+			}
+			i = max(next, i+1)
+
+		case tokenRBrace:
+			p.addError(current, "unexpected '}'", "nonterminals are written between braces, like {A}")
+			i++
+
+		case tokenEscape:
+			if terminal, ok := p.parseEscape(current); ok {
+				items = append(items, ebnfItem{symbol: terminal, at: current})
+			}
+			i++
+
+		default:
+			if ebnfOperators[current.value] {
+				items = append(items, ebnfItem{operator: current.value, at: current})
+			} else {
+				items = append(items, ebnfItem{symbol: Symbol{Value: current.value, IsTerminal: true, Id: 0}, at: current})
+			}
+			i++
+		}
+	}
+
+	return append(items, ebnfItem{operator: "", at: tokens[i]})
+}
+
+// Checks that the operators of an EBNF body are well placed. The last item
+// must be the end of the line.
+func (p *grammarParser) checkEBNFItems(items []ebnfItem) bool {
+	errorCount := len(p.errors)
+	openGroups := make([]ebnfItem, 0)
+	previous := "|" // The start of the body behaves like the start of an alternative.
+
+	for index, item := range items {
+		isEOL := index == len(items)-1
+		switch {
+		case isEOL || item.operator == "|":
+			if previous == "|" || previous == "(" {
+				p.addError(item.at, "empty alternative", "use ε to write the empty string")
+			}
+			if isEOL && len(openGroups) > 0 {
+				p.addError(openGroups[len(openGroups)-1].at, "unclosed '('", "close the group with ')'")
+			}
+
+		case item.operator == "(":
+			openGroups = append(openGroups, item)
+
+		case item.operator == ")":
+			switch {
+			case len(openGroups) == 0:
+				p.addError(item.at, "unexpected ')'", `use \) to write the character ')'`)
+			case previous == "(":
+				p.addError(item.at, "empty group", "write at least one symbol inside the parentheses")
+			case previous == "|":
+				p.addError(item.at, "empty alternative", "use ε to write the empty string")
+			}
+			if len(openGroups) > 0 {
+				openGroups = openGroups[:len(openGroups)-1]
+			}
+
+		case item.operator != "":
+			// ?, * and +
+			if previous == "|" || previous == "(" {
+				p.addError(item.at, "operator without operand",
+					"write the symbol or group it applies to before it, or escape it like \\"+item.operator)
+			}
+		}
+
+		previous = item.operator
+	}
+
+	return len(p.errors) == errorCount
+}
+
+// ebnfDesugarer translates the AST of an EBNF body into plain productions.
+type ebnfDesugarer struct {
+	grammar *Grammar
+	head    Symbol
+	symbols []Symbol // Grammar symbols of the operands, by index.
+}
+
+// returns: the bodies represented by a node, using ε for empty bodies.
+func (d *ebnfDesugarer) alternatives(node ast.Node) [][]Symbol {
+	bodies := d.rawAlternatives(node)
+	for index, body := range bodies {
+		if len(body) == 0 {
+			bodies[index] = []Symbol{EpsilonSymbol}
+		}
+	}
+	return removeDuplicatesSlices(bodies)
+}
+
+// returns: the bodies represented by a node, without ε, so empty bodies are empty slices.
+func (d *ebnfDesugarer) rawAlternatives(node ast.Node) [][]Symbol {
+	if operator, isOperator := node.(*ast.OperatorNode); isOperator && operator.Value == "|" {
+		return append(d.rawAlternatives(operator.Operands[0]), d.rawAlternatives(operator.Operands[1])...)
+	}
+	return [][]Symbol{d.sequence(node)}
+}
+
+// returns: the symbols represented by a node that is not an alternative,
+// creating helper productions for groups and repetitions.
+func (d *ebnfDesugarer) sequence(node ast.Node) []Symbol {
+	if character, isCharacter := node.(*ast.CharacterNode); isCharacter {
+		index, _ := strconv.Atoi(character.Value)
+		if d.symbols[index] == EpsilonSymbol {
+			return []Symbol{}
+		}
+		return []Symbol{d.symbols[index]}
+	}
+
+	operator := node.(*ast.OperatorNode)
+	if operator.Value == shuntingyard.CONCAT_SYMBOL {
+		return append(d.sequence(operator.Operands[0]), d.sequence(operator.Operands[1])...)
+	}
+
+	// Las alternativas internas se calculan antes de crear el auxiliar, así
+	// los auxiliares anidados quedan con un Id menor
+	if operator.Value == "|" {
+		return []Symbol{d.addHelper(d.alternatives(node))}
+	}
+	inner := d.rawAlternatives(operator.Operands[0])
+
+	helper := d.grammar.freshNonTerminal(d.head.Value)
+	bodies := make([][]Symbol, 0)
+	switch operator.Value {
+	case "?":
+		// H -> x|ε
+		bodies = append(bodies, inner...)
+		bodies = append(bodies, []Symbol{})
+	case "*":
+		// H -> xH|ε
+		for _, body := range inner {
+			bodies = append(bodies, append(append([]Symbol{}, body...), helper))
+		}
+		bodies = append(bodies, []Symbol{})
+	case "+":
+		// H -> xH|x
+		for _, body := range inner {
+			bodies = append(bodies, append(append([]Symbol{}, body...), helper))
+		}
+		bodies = append(bodies, inner...)
+	}
+
+	for index, body := range bodies {
+		if len(body) == 0 {
+			bodies[index] = []Symbol{EpsilonSymbol}
+		}
+	}
+	d.grammar.addProductionSymbols(helper, bodies)
+	return []Symbol{helper}
+}
+
+// Adds a helper NON terminal with the given bodies.
+func (d *ebnfDesugarer) addHelper(bodies [][]Symbol) Symbol {
+	helper := d.grammar.freshNonTerminal(d.head.Value)
+	d.grammar.addProductionSymbols(helper, bodies)
+	return helper
+}
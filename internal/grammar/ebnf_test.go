@@ -0,0 +1,141 @@
+package grammar
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseGrammarsEBNF(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		expected string
+	}{
+		{
+			name:  "Repetition of a group",
+			lines: []string{"%ebnf", "{Args} -> {Expr}(,{Expr})*", "Expr -> x"},
+			expected: `{Args_0} -> {Expr_0}{Args_1}
+{Args_1} -> ,{Expr_0}{Args_1}|ε
+{Expr_0} -> x
+`,
+		},
+		{
+			name:  "Optional alternatives",
+			lines: []string{"%ebnf", "{Opt} -> (a|b)?"},
+			expected: `{Opt_0} -> {Opt_1}
+{Opt_1} -> a|b|ε
+`,
+		},
+		{
+			name:  "Top level group is not a helper",
+			lines: []string{"%ebnf", "S -> (a|b)|c"},
+			expected: `{S_0} -> a|b|c
+`,
+		},
+		{
+			name:  "Repetition of alternatives",
+			lines: []string{"%ebnf", "S -> a(b|c)+d"},
+			expected: `{S_0} -> a{S_1}d
+{S_1} -> b{S_1}|c{S_1}|b|c
+`,
+		},
+		{
+			name:  "Nested groups",
+			lines: []string{"%ebnf", "S -> a((b|c)d)?"},
+			expected: `{S_0} -> a{S_2}
+{S_1} -> b|c
+{S_2} -> {S_1}d|ε
+`,
+		},
+		{
+			name:  "Escaped operators are terminals",
+			lines: []string{"%ebnf", `S -> \(a\)\*\+\?`},
+			expected: `{S_0} -> (a)*+?
+`,
+		},
+		{
+			name:  "Operators are terminals without the directive",
+			lines: []string{"S -> (a)*"},
+			expected: `{S_0} -> (a)*
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grammars, err := ParseGrammars("test.txt", tt.lines)
+			if err != nil {
+				t.Fatalf("Expected no errors, but got:\n%v", err)
+			}
+			if got := grammars[0].String(false); got != tt.expected {
+				t.Errorf("Expected %q,\n but got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseGrammarsEBNFErrors(t *testing.T) {
+	lines := []string{
+		"%ebnf",
+		"S -> (a|b",
+		"S -> a)|*b",
+		"S -> ()|(a|)",
+		"S -> (a(b)",
+	}
+
+	expected := []ParseError{
+		{Line: 2, Column: 6, Char: "(", Message: "unclosed '('"},
+		{Line: 3, Column: 7, Char: ")", Message: "unexpected ')'"},
+		{Line: 3, Column: 9, Char: "*", Message: "operator without operand"},
+		{Line: 4, Column: 7, Char: ")", Message: "empty group"},
+		{Line: 4, Column: 12, Char: ")", Message: "empty alternative"},
+		{Line: 5, Column: 6, Char: "(", Message: "unclosed '('"},
+	}
+
+	_, err := ParseGrammars("test.txt", lines)
+
+	var parseErrors ParseErrors
+	if !errors.As(err, &parseErrors) {
+		t.Fatalf("Expected ParseErrors, but got %v", err)
+	}
+	if len(parseErrors) != len(expected) {
+		t.Fatalf("Expected %d errors, but got %d:\n%v", len(expected), len(parseErrors), err)
+	}
+	for i, e := range expected {
+		got := parseErrors[i]
+		if got.Line != e.Line || got.Column != e.Column || got.Char != e.Char || got.Message != e.Message {
+			t.Errorf("Expected error %d to be %v, but got %v", i, e, got)
+		}
+	}
+}
+
+func TestSimplifyGrammarEBNF(t *testing.T) {
+	grammars, err := ParseGrammars("test.txt", []string{
+		"%ebnf",
+		"{Args} -> {Expr}(,{Expr})*",
+		"{Expr} -> x|[{Args}]",
+	})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+
+	simplified := SimplifyGrammar(grammars[0], false)
+	start := simplified.GetStartSymbol()
+
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"x", true},
+		{"x,x,x", true},
+		{"[x,x],x", true},
+		{"x,", false},
+		{",x", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := CYKParse(simplified, tt.input, start); got != tt.expected {
+			t.Errorf("CYKParse(%q) = %v, expected %v", tt.input, got, tt.expected)
+		}
+	}
+}
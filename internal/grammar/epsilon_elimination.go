@@ -6,10 +6,12 @@ func identifyDirectNullables(grammar *Grammar) *[]Symbol {
 
 	directNullables := make([]Symbol, 0, 3)
 
-	for head, bodies := range grammar.Productions {
-		for _, body := range bodies {
+	// En el orden de los no terminales, para que los cuerpos nuevos siempre
+	// queden en el mismo orden
+	for _, head := range productionHeads(grammar) {
+		for _, body := range grammar.Productions[head] {
 			for _, symbol := range body {
-				if symbol.Value == "ε" && symbol.IsTerminal {
+				if symbol == EpsilonSymbol {
 					directNullables = append(directNullables, head)
 					continue
 				}
@@ -35,7 +37,8 @@ func identifyIndirectNullables(grammar *Grammar, nullabes []Symbol) *[]Symbol {
 	//    no new production where found, hence ALL NULLABLE PRODUCTION WHERE FOUND
 	// 3. Else, repeat step 1 and 2.
 	for {
-		for head, bodies := range grammar.Productions {
+		for _, head := range productionHeads(grammar) {
+			bodies := grammar.Productions[head]
 			// If the production is already nullable dont analize it.
 			if containsSymbol(pastNullables, head) {
 				continue
@@ -62,9 +65,11 @@ func identifyIndirectNullables(grammar *Grammar, nullabes []Symbol) *[]Symbol {
 
 // ReplaceNullables reemplaza las producciones que contienen símbolos anulables.
 func ReplaceNullables(grammar *Grammar, nullables []Symbol) *Grammar {
+	grammar = grammar.Clone()
 	newGrammar := Grammar{
 		terminals:    grammar.terminals,
 		NonTerminals: grammar.NonTerminals,
+		StartSymbol:  grammar.GetStartSymbol(),
 		Productions:  make(map[Symbol][][]Symbol)}
 
 	// Paso 1. Leer cada body de la gramática por cada head
@@ -126,10 +131,12 @@ func CombinationNullables(nullable *Symbol, baseProduction *[]Symbol) *[][]Symbo
 
 // RemoveEpsilons elimina los caracteres epsilon de la producción y elimina duplicados
 func RemoveEpsilons(grammar *Grammar) *Grammar {
+	grammar = grammar.Clone()
 	// Crear una nueva gramática para almacenar las producciones sin epsilon
 	newGrammar := Grammar{
 		terminals:    grammar.terminals,
 		NonTerminals: grammar.NonTerminals,
+		StartSymbol:  grammar.GetStartSymbol(),
 		Productions:  make(map[Symbol][][]Symbol)}
 
 	// Iterar sobre las cabezas de la gramática y sus producciones
@@ -0,0 +1,130 @@
+package grammar
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+/*
+Eventos de las transformaciones. Las funciones de la librería no imprimen
+nada: avisan de cada paso a un Observer, y quien las llama decide qué hacer
+con los eventos. TextObserver los escribe como texto, como los muestra el
+programa de línea de comandos:
+
+	pipeline.RunWith(g, TextObserver(os.Stdout))
+	CYKParseWith(g, "ab", g.GetStartSymbol(), TextObserver(os.Stdout))
+
+Los eventos de una pasada llegan en orden: primero EventPassStarted y luego
+los resultados intermedios de la pasada.
+*/
+
+// Kind of an Event.
+type EventKind int
+
+const (
+	EventPassStarted EventKind = iota // A pass of a pipeline started.
+	EventGrammar                      // A pass produced an intermediate grammar, in Grammar.
+	EventNullables                    // The epsilon pass found a set of nullable symbols, in Symbols.
+	EventUnitPairs                    // The unit pass found the unit pairs, in UnitPairs.
+	EventChartRow                     // CYK completed the row Row of Table.
+	EventViolations                   // The grammar of the stage Stage does not have the form of the stage, the productions that break it are in Violations.
+)
+
+// Something that happened during a transformation or a parse.
+type Event struct {
+	Kind  EventKind
+	Pass  string // Name of the pass that produced the event, empty outside of a pass.
+	Title string // Description of the event, in the words printed by the CLI.
+
+	Stage   string   // Name of the stage of the grammar, empty if it is not a stage.
+	Grammar *Grammar // Grammar of an EventGrammar, it must not be modified.
+
+	Symbols   []Symbol            // Symbols of an EventNullables.
+	UnitPairs map[Symbol][]Symbol // For every NON terminal A, the NON terminals B with A =>* B.
+
+	Violations []ProductionViolation // Productions of an EventViolations.
+
+	Row   int          // Row completed by an EventChartRow.
+	Table [][][]Symbol // Table of CYK, rows after Row are still empty. It must not be modified.
+}
+
+// Receives the events of a transformation. A nil Observer ignores them.
+type Observer func(event Event)
+
+// Sends an event to the observer, if there is one.
+func (observer Observer) notify(event Event) {
+	if observer != nil {
+		observer(event)
+	}
+}
+
+// Returns: an observer that calls every given observer, skipping the nil ones.
+func MultiObserver(observers ...Observer) Observer {
+	return func(event Event) {
+		for _, observer := range observers {
+			observer.notify(event)
+		}
+	}
+}
+
+// Returns: an observer that writes the events to w as text. Passes are
+// numbered from 2, the original grammar being the step 1, and the results
+// of every pass are numbered inside it.
+func TextObserver(w io.Writer) Observer {
+	step, subStep := 1, 0
+	return func(event Event) {
+		switch event.Kind {
+		case EventPassStarted:
+			step++
+			subStep = 0
+			fmt.Fprintf(w, "\n%s  %s:\n", stepNumber(step), event.Title)
+		case EventGrammar:
+			if event.Pass == "" {
+				fmt.Fprintf(w, "\n%s  %s:\n", stepNumber(1), event.Title)
+			} else {
+				subStep++
+				fmt.Fprintf(w, "\n🔴  %d.%d %s:\n", step, subStep, event.Title)
+			}
+			fmt.Fprintln(w, event.Grammar.String(true))
+		case EventNullables:
+			subStep++
+			fmt.Fprintf(w, "\n🔴  %d.%d %s:\n", step, subStep, event.Title)
+			fmt.Fprintf(w, "\t%v\n", symbolsToStrings(event.Symbols))
+		case EventUnitPairs:
+			subStep++
+			fmt.Fprintf(w, "\n🔴  %d.%d %s:\n", step, subStep, event.Title)
+			heads := make([]Symbol, 0, len(event.UnitPairs))
+			for head := range event.UnitPairs {
+				heads = append(heads, head)
+			}
+			sort.Slice(heads, func(i, j int) bool { return heads[i].String() < heads[j].String() })
+			for _, head := range heads {
+				fmt.Fprintf(w, "\t%s: %s\n", head.String(), strings.Join(symbolsToStrings(event.UnitPairs[head]), " "))
+			}
+		case EventViolations:
+			fmt.Fprintf(w, "\n⚠️  %s:\n", event.Title)
+			for _, violation := range event.Violations {
+				fmt.Fprintf(w, "\t%s\n", violation.String())
+			}
+		case EventChartRow:
+			// Imprimir el estado actual de la matriz después de completar la fila
+			fmt.Fprintf(w, "Matriz después de completar la fila %d:\n", event.Row)
+			for fi, fila := range event.Table {
+				fmt.Fprintf(w, "Fila %d:\n", fi)
+				for j, heads := range fila {
+					fmt.Fprintf(w, "  Columna %d: %v\n", j, symbolsToStrings(heads))
+				}
+			}
+		}
+	}
+}
+
+// Returns: the number of a step as a keycap emoji, like 2️⃣, up to 9.
+func stepNumber(step int) string {
+	if step < 0 || step > 9 {
+		return fmt.Sprintf("%d.", step)
+	}
+	return fmt.Sprintf("%d\uFE0F\u20E3", step)
+}
@@ -0,0 +1,97 @@
+package grammar
+
+import (
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPipelineEvents(t *testing.T) {
+	grammars, err := ParseGrammars("test.txt", []string{"S -> a{S}b|{A}", "A -> ε|c"})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+
+	kinds := []EventKind{}
+	passes := []string{}
+	NewPipeline(EpsilonPass(), UnitPass()).RunWith(grammars[0], func(event Event) {
+		kinds = append(kinds, event.Kind)
+		passes = append(passes, event.Pass)
+		if event.Kind == EventNullables && event.Title == "Todos los símbolos anulables encontrados" {
+			if names := symbolsToStrings(event.Symbols); !reflect.DeepEqual(names, []string{"{A_0}", "{S_0}"}) {
+				t.Errorf("Expected A and S to be nullable, but got %v", names)
+			}
+		}
+		if event.Kind == EventUnitPairs && !containsSymbol(event.UnitPairs[Symbol{Value: "S"}], Symbol{Value: "A"}) {
+			t.Errorf("Expected (S, A) to be a unit pair, but got %v", event.UnitPairs)
+		}
+	})
+
+	expectedKinds := []EventKind{
+		EventGrammar,
+		EventPassStarted, EventNullables, EventNullables, EventGrammar, EventGrammar,
+		EventPassStarted, EventUnitPairs, EventGrammar,
+	}
+	expectedPasses := []string{"", "epsilon", "epsilon", "epsilon", "epsilon", "epsilon", "unit", "unit", "unit"}
+	if !reflect.DeepEqual(kinds, expectedKinds) {
+		t.Errorf("Expected events %v, but got %v", expectedKinds, kinds)
+	}
+	if !reflect.DeepEqual(passes, expectedPasses) {
+		t.Errorf("Expected passes %v, but got %v", expectedPasses, passes)
+	}
+}
+
+func TestTextObserver(t *testing.T) {
+	grammars, err := ParseGrammars("test.txt", []string{"S -> a{S}b|ε"})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+
+	var sb strings.Builder
+	NewPipeline(EpsilonPass()).RunWith(grammars[0], TextObserver(&sb))
+	expected := "\n1️⃣  Gramática ANTES de la simplificación:\n" +
+		"NonTerminals: [{S_0}]\nTerminals: [a,b,ε]\n\n{S_0} -> a{S_0}b|ε\n\n" +
+		"\n2️⃣  ELIMINACIÓN DE EPSILON:\n" +
+		"\n🔴  2.1 Símbolos anulables directos encontrados:\n\t[{S_0}]\n" +
+		"\n🔴  2.2 Todos los símbolos anulables encontrados:\n\t[{S_0}]\n" +
+		"\n🔴  2.3 Gramática DESPUÉS de reemplazar los anulables:\n" +
+		"NonTerminals: [{S_0}]\nTerminals: [a,b,ε]\n\n{S_0} -> a{S_0}b|ε|aεb\n\n" +
+		"\n🔴  2.4 Gramática DESPUÉS de eliminar las producciones epsilon:\n" +
+		"NonTerminals: [{S_0}]\nTerminals: [a,b]\n\n{S_0} -> a{S_0}b|ab\n\n"
+	if sb.String() != expected {
+		t.Errorf("Expected %q,\n but got %q", expected, sb.String())
+	}
+
+	sb.Reset()
+	simplified := SimplifyGrammar(grammars[0], false)
+	CYKParseWith(simplified, "ab", simplified.GetStartSymbol(), TextObserver(&sb))
+	if rows := strings.Count(sb.String(), "Matriz después de completar la fila"); rows != 2 {
+		t.Errorf("Expected 2 rows, but got %d in %q", rows, sb.String())
+	}
+}
+
+func TestLibraryIsSilent(t *testing.T) {
+	grammars, err := ParseGrammars("test.txt", []string{"E -> {E}+{T}|{T}", "T -> ({E})|x|{A}", "A -> ε"})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	simplified := SimplifyGrammarResult(grammars[0], false).Grammar
+	CYKParse(simplified, "(x)+x", simplified.GetStartSymbol())
+	CYKParseResult(simplified, "x+", simplified.GetStartSymbol())
+	os.Stdout = stdout
+	writer.Close()
+
+	output, _ := io.ReadAll(reader)
+	if len(output) != 0 {
+		t.Errorf("Expected no output, but got %q", output)
+	}
+}
@@ -0,0 +1,58 @@
+package grammar
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Result of checking an example string against a grammar.
+type ExampleResult struct {
+	Example
+	Accepted bool `json:"accepted"` // True if the grammar accepted the input.
+	Passed   bool `json:"passed"`   // True if the grammar did what the example expects.
+}
+
+// Checks every example of a section against a grammar in Chomsky Normal
+// Form, usually the result of SimplifyGrammar for the grammar of the section.
+//
+// Returns: the result of every example, in the same order.
+func CheckExamples(g *Grammar, examples []Example) []ExampleResult {
+	results := make([]ExampleResult, 0, len(examples))
+	for _, example := range examples {
+		accepted := CYKParseResult(g, example.Input, g.GetStartSymbol()).Accepted
+		results = append(results, ExampleResult{Example: example, Accepted: accepted, Passed: accepted == example.Accept})
+	}
+	return results
+}
+
+// Returns: true if every example passed.
+func ExamplesPassed(results []ExampleResult) bool {
+	for _, result := range results {
+		if !result.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// Checks that an input string can match every terminal of the grammar. CYK
+// and the examples read the input one character at a time, so a terminal
+// with more than one character, like a token of ANTLR, is never matched.
+//
+// Returns: an error with the terminals of more than one character, or nil.
+func CheckInputTerminals(g *Grammar) error {
+	tokens := make([]string, 0)
+	for _, terminal := range g.terminals {
+		if terminal != EpsilonSymbol && len([]rune(terminal.Value)) > 1 {
+			tokens = append(tokens, terminal.Value)
+		}
+	}
+	if len(tokens) == 0 {
+		return nil
+	}
+	subject := fmt.Sprintf("the terminals %s have", strings.Join(tokens, ", "))
+	if len(tokens) == 1 {
+		subject = fmt.Sprintf("the terminal %s has", tokens[0])
+	}
+	return fmt.Errorf("%s more than one character and the input is read one character at a time, replace them with their characters, like '+' or 'while'", subject)
+}
@@ -0,0 +1,59 @@
+package grammar
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckExamples(t *testing.T) {
+	sections, err := ParseGrammarSections("test.txt", []string{
+		"S -> a{S}b|ε",
+		"accept:",
+		"accept: aabb",
+		"reject: aab",
+		"accept: ab ab",
+	})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+
+	simplified := SimplifyGrammar(sections[0].Grammar, false)
+	results := CheckExamples(simplified, sections[0].Examples)
+
+	expected := []struct{ accepted, passed bool }{{true, true}, {true, true}, {false, true}, {false, false}}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d results, but got %d", len(expected), len(results))
+	}
+	for i, result := range results {
+		if result.Example != sections[0].Examples[i] || result.Accepted != expected[i].accepted || result.Passed != expected[i].passed {
+			t.Errorf("Expected result %d to be %v, but got %+v", i, expected[i], result)
+		}
+	}
+	if ExamplesPassed(results) {
+		t.Errorf("Expected the examples to fail")
+	}
+	if !ExamplesPassed(results[:3]) {
+		t.Errorf("Expected the first three examples to pass")
+	}
+}
+
+func TestCheckInputTerminals(t *testing.T) {
+	grammars, err := ParseANTLR("Expr.g4", []string{"expr : expr '+' ID | INT | '(' expr ')' ;"})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+
+	// Los tokens INT e ID nunca coinciden con un caracter de la entrada
+	err = CheckInputTerminals(SimplifyGrammar(grammars[0], false))
+	if err == nil || !strings.Contains(err.Error(), "the terminals INT, ID have more than one character") {
+		t.Errorf("Expected an error with the terminals INT and ID, but got %v", err)
+	}
+
+	sections, err := ParseGrammarSections("test.txt", []string{"S -> a{S}b|ε"})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+	if err := CheckInputTerminals(SimplifyGrammar(sections[0].Grammar, false)); err != nil {
+		t.Errorf("Expected no errors, but got %v", err)
+	}
+}
@@ -0,0 +1,503 @@
+package grammar
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+/*
+Exportadores que escriben una gramática en el formato de otras herramientas:
+bison (.y), ANTLR 4 (.g4) y tree-sitter (grammar.js), y en el formato propio
+A -> b{A} para poder volver a leerla con ParseGrammars.
+
+Los nombres internos como {A_1} se convierten en identificadores válidos en
+cada formato: el Id se agrega solo si no es 0 (A, A_1), las primas se escriben
+como _prime, los guiones como _ y cualquier otro caracter que no sea ASCII
+como _uXXXX. Si dos
+símbolos terminan con el mismo nombre, o el nombre es una palabra reservada,
+se les agrega un sufijo.
+
+Los terminales de un caracter se escriben como literales. Los terminales de
+varios caracteres con forma de identificador, como ID o INT al importar de
+ANTLR, son tokens que debe definir el lexer de la herramienta.
+*/
+
+// Matches the terminals that are tokens defined by a lexer instead of literals.
+var tokenTerminal = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Reserved words of each format, which cannot be used as rule names.
+var (
+	bisonReserved = map[string]bool{"error": true, "YYEOF": true, "YYerror": true, "YYUNDEF": true}
+	antlrReserved = map[string]bool{
+		"import": true, "fragment": true, "lexer": true, "parser": true, "grammar": true,
+		"returns": true, "locals": true, "throws": true, "catch": true, "finally": true,
+		"mode": true, "options": true, "tokens": true, "channels": true, "rule": true, "EOF": true,
+	}
+	treeSitterReserved = map[string]bool{}
+)
+
+// nameStyle describes the identifiers of a format.
+type nameStyle struct {
+	lowerRules  bool                // Rule names must start with a lowercase letter.
+	upperTokens bool                // Token names must start with an uppercase letter.
+	reserved    map[string]bool     // Words that cannot be used.
+	identifier  func(Symbol) string // Legal name of a symbol, identifier when nil.
+}
+
+// exportNames assigns a legal and unique identifier to every NON terminal of
+// the grammar, and to every token terminal.
+type exportNames struct {
+	style  nameStyle
+	names  map[Symbol]string
+	used   map[string]bool
+	tokens []Symbol // Token terminals, in the order of the grammar.
+}
+
+func newExportNames(g *Grammar, style nameStyle) *exportNames {
+	n := &exportNames{style: style, names: make(map[Symbol]string), used: make(map[string]bool)}
+	for _, nonTerminal := range g.NonTerminals {
+		if _, exist := g.Productions[nonTerminal]; exist {
+			n.add(nonTerminal, style.lowerRules, false)
+		}
+	}
+	for _, terminal := range g.terminals {
+		if isTokenTerminal(terminal) {
+			n.add(terminal, false, style.upperTokens)
+			n.tokens = append(n.tokens, terminal)
+		}
+	}
+	return n
+}
+
+// Registers a symbol, making its name unique.
+func (n *exportNames) add(symbol Symbol, lowerFirst bool, upperFirst bool) {
+	if _, exist := n.names[symbol]; exist {
+		return
+	}
+	toName := identifier
+	if n.style.identifier != nil {
+		toName = n.style.identifier
+	}
+	base := []rune(toName(symbol))
+	if lowerFirst {
+		base[0] = unicode.ToLower(base[0])
+	}
+	if upperFirst {
+		base[0] = unicode.ToUpper(base[0])
+	}
+	if n.style.reserved[string(base)] {
+		base = append(base, '_')
+	}
+
+	name := string(base)
+	for suffix := 2; n.used[name]; suffix++ {
+		name = fmt.Sprintf("%s_%d", string(base), suffix)
+	}
+	n.used[name] = true
+	n.names[symbol] = name
+}
+
+func (n *exportNames) of(symbol Symbol) string {
+	return n.names[symbol]
+}
+
+// Returns: the name that ExportANTLR writes for a grammar, a valid ANTLR
+// identifier. ANTLR only reads the grammar from a .g4 file with this name.
+func ANTLRGrammarName(name string) string {
+	return identifier(Symbol{Value: name, IsTerminal: true})
+}
+
+// returns: an ASCII identifier for a symbol, without checking if it is unique.
+func identifier(symbol Symbol) string {
+	var sb strings.Builder
+	for _, char := range symbol.Value {
+		switch {
+		case char < unicode.MaxASCII && (unicode.IsLetter(char) || unicode.IsDigit(char) || char == '_'):
+			sb.WriteRune(char)
+		case char == '\'' || char == '′':
+			sb.WriteString("_prime")
+		case char == '-' || char == ' ':
+			sb.WriteRune('_')
+		default:
+			sb.WriteString(fmt.Sprintf("_u%04X", char))
+		}
+	}
+	if !symbol.IsTerminal && symbol.Id != 0 {
+		sb.WriteString(fmt.Sprintf("_%d", symbol.Id))
+	}
+
+	name := []rune(sb.String())
+	if len(name) == 0 || !unicode.IsLetter(name[0]) {
+		name = append([]rune("r"), name...)
+	}
+	return string(name)
+}
+
+// Checks if a terminal is a token defined by a lexer, like ID, instead of a literal.
+func isTokenTerminal(symbol Symbol) bool {
+	return symbol.IsTerminal && symbol != EpsilonSymbol && len([]rune(symbol.Value)) > 1 && tokenTerminal.MatchString(symbol.Value)
+}
+
+// Returns the productions of a grammar in the order of its NON terminals,
+// with the start symbol first.
+func orderedHeads(g *Grammar) []Symbol {
+	heads := make([]Symbol, 0, len(g.Productions))
+	for _, nonTerminal := range moveSymbolToFront(g.NonTerminals, g.GetStartSymbol()) {
+		if _, exist := g.Productions[nonTerminal]; exist && !containsSymbol(heads, nonTerminal) {
+			heads = append(heads, nonTerminal)
+		}
+	}
+	return heads
+}
+
+// Orders the heads like the reader registers the NON terminals: the start
+// symbol, then every NON terminal in the order it first appears in the bodies
+// written so far, and then the heads that were not reached, in the order of
+// orderedHeads. Reading the written grammar gives back the same order.
+//
+// Returns: the heads of orderedHeads, in reading order.
+func readingOrderHeads(g *Grammar) []Symbol {
+	heads := orderedHeads(g)
+	seen := make([]Symbol, 0, len(g.NonTerminals))
+	written := make([]Symbol, 0, len(heads))
+	for len(written) < len(heads) {
+		// La primera cabeza vista que no se ha escrito, o la siguiente sin alcanzar
+		next := Symbol{}
+		found := false
+		for _, symbol := range seen {
+			if containsSymbol(heads, symbol) && !containsSymbol(written, symbol) {
+				next, found = symbol, true
+				break
+			}
+		}
+		for i := 0; !found && i < len(heads); i++ {
+			if !containsSymbol(written, heads[i]) {
+				next, found = heads[i], true
+			}
+		}
+
+		written = append(written, next)
+		if !containsSymbol(seen, next) {
+			seen = append(seen, next)
+		}
+		for _, body := range g.Productions[next] {
+			for _, symbol := range body {
+				if !symbol.IsTerminal && !containsSymbol(seen, symbol) {
+					seen = append(seen, symbol)
+				}
+			}
+		}
+	}
+	return written
+}
+
+// Writes a grammar as a bison file. Single byte terminals are character
+// literals, the rest are declared with %token.
+func ExportBison(g *Grammar) string {
+	names := newExportNames(g, nameStyle{reserved: bisonReserved})
+
+	// Terminales que no se pueden escribir como literal de un caracter
+	literals := make(map[Symbol]string)
+	for _, terminal := range g.terminals {
+		if terminal == EpsilonSymbol || isTokenTerminal(terminal) {
+			continue
+		}
+		if literal, ok := bisonCharLiteral(terminal.Value); ok {
+			literals[terminal] = literal
+			continue
+		}
+		tokenName := terminal
+		tokenName.Value = "T_" + terminal.Value
+		names.add(tokenName, false, false)
+		literals[terminal] = names.of(tokenName)
+		names.tokens = append(names.tokens, terminal)
+	}
+
+	var sb strings.Builder
+	for _, token := range names.tokens {
+		if isTokenTerminal(token) {
+			sb.WriteString(fmt.Sprintf("%%token %s\n", names.of(token)))
+		} else {
+			sb.WriteString(fmt.Sprintf("%%token %s %s\n", literals[token], quoteString(token.Value)))
+		}
+	}
+	sb.WriteString(fmt.Sprintf("%%start %s\n\n%%%%\n", names.of(g.GetStartSymbol())))
+
+	for _, head := range orderedHeads(g) {
+		sb.WriteString(fmt.Sprintf("\n%s\n", names.of(head)))
+		for index, body := range g.Productions[head] {
+			if index == 0 {
+				sb.WriteString("    :")
+			} else {
+				sb.WriteString("    |")
+			}
+			for _, symbol := range body {
+				switch {
+				case symbol == EpsilonSymbol:
+					sb.WriteString(" %empty")
+				case !symbol.IsTerminal || isTokenTerminal(symbol):
+					sb.WriteString(" " + names.of(symbol))
+				default:
+					sb.WriteString(" " + literals[symbol])
+				}
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("    ;\n")
+	}
+
+	return sb.String()
+}
+
+// returns: a bison character literal for a value, and false if the value is
+// not a single byte character.
+func bisonCharLiteral(value string) (string, bool) {
+	chars := []rune(value)
+	if len(chars) != 1 || chars[0] > unicode.MaxASCII || chars[0] == 0 {
+		return "", false
+	}
+	switch char := chars[0]; {
+	case char == '\'' || char == '\\':
+		return `'\` + string(char) + `'`, true
+	case char == '\n':
+		return `'\n'`, true
+	case char == '\t':
+		return `'\t'`, true
+	case char < ' ' || char == unicode.MaxASCII:
+		return fmt.Sprintf(`'\x%02x'`, char), true
+	default:
+		return "'" + string(char) + "'", true
+	}
+}
+
+// Writes a grammar as an ANTLR 4 combined grammar. The name written is
+// ANTLRGrammarName(name), which must match the name of the .g4 file. Token
+// terminals are declared in a tokens block.
+func ExportANTLR(g *Grammar, name string) string {
+	names := newExportNames(g, nameStyle{lowerRules: true, upperTokens: true, reserved: antlrReserved})
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("grammar %s;\n", ANTLRGrammarName(name)))
+	if len(names.tokens) > 0 {
+		tokens := make([]string, 0, len(names.tokens))
+		for _, token := range names.tokens {
+			tokens = append(tokens, names.of(token))
+		}
+		sb.WriteString(fmt.Sprintf("\ntokens { %s }\n", strings.Join(tokens, ", ")))
+	}
+
+	for _, head := range orderedHeads(g) {
+		sb.WriteString(fmt.Sprintf("\n%s\n", names.of(head)))
+		for index, body := range g.Productions[head] {
+			if index == 0 {
+				sb.WriteString("    :")
+			} else {
+				sb.WriteString("    |")
+			}
+			for _, symbol := range body {
+				switch {
+				case symbol == EpsilonSymbol:
+					// Una alternativa vacía es ε
+				case !symbol.IsTerminal || isTokenTerminal(symbol):
+					sb.WriteString(" " + names.of(symbol))
+				default:
+					sb.WriteString(" " + antlrLiteral(symbol.Value))
+				}
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("    ;\n")
+	}
+
+	return sb.String()
+}
+
+// returns: an ANTLR literal between single quotes.
+func antlrLiteral(value string) string {
+	var sb strings.Builder
+	sb.WriteString("'")
+	for _, char := range value {
+		switch {
+		case char == '\'' || char == '\\':
+			sb.WriteString(`\` + string(char))
+		case char == '\n':
+			sb.WriteString(`\n`)
+		case char == '\r':
+			sb.WriteString(`\r`)
+		case char == '\t':
+			sb.WriteString(`\t`)
+		case !unicode.IsPrint(char):
+			sb.WriteString(fmt.Sprintf(`\u%04X`, char))
+		default:
+			sb.WriteRune(char)
+		}
+	}
+	sb.WriteString("'")
+	return sb.String()
+}
+
+// Writes a grammar as a tree-sitter grammar.js. Tree-sitter does not allow
+// rules other than the start rule to match the empty string, so the grammar
+// should be simplified first. Token terminals get a rule that throws an
+// error when the parser is generated, until it is replaced by the definition
+// of the token.
+func ExportTreeSitter(g *Grammar, name string) string {
+	names := newExportNames(g, nameStyle{lowerRules: true, reserved: treeSitterReserved})
+
+	var sb strings.Builder
+	sb.WriteString("module.exports = grammar({\n")
+	sb.WriteString(fmt.Sprintf("  name: %s,\n\n", quoteString(strings.ToLower(identifier(Symbol{Value: name, IsTerminal: true})))))
+	sb.WriteString("  rules: {\n")
+
+	for _, head := range orderedHeads(g) {
+		bodies := make([]string, 0, len(g.Productions[head]))
+		for _, body := range g.Productions[head] {
+			symbols := make([]string, 0, len(body))
+			for _, symbol := range body {
+				switch {
+				case symbol == EpsilonSymbol:
+					continue
+				case !symbol.IsTerminal || isTokenTerminal(symbol):
+					symbols = append(symbols, "$."+names.of(symbol))
+				default:
+					symbols = append(symbols, quoteString(symbol.Value))
+				}
+			}
+			bodies = append(bodies, treeSitterCall("seq", symbols, "blank()"))
+		}
+		sb.WriteString(fmt.Sprintf("    %s: $ => %s,\n", names.of(head), treeSitterCall("choice", bodies, "blank()")))
+	}
+
+	// Tree-sitter no tiene un lexer aparte: la regla de cada token lanza un
+	// error al generar el parser, hasta que se reemplace por su definición
+	for _, token := range names.tokens {
+		message := quoteString("falta la definición del token " + token.Value)
+		sb.WriteString(fmt.Sprintf("    %s: $ => {\n      throw new Error(%s);\n    },\n", names.of(token), message))
+	}
+
+	sb.WriteString("  }\n});\n")
+	return sb.String()
+}
+
+// returns: function(arguments...), or the only argument if there is one.
+func treeSitterCall(function string, arguments []string, empty string) string {
+	switch len(arguments) {
+	case 0:
+		return empty
+	case 1:
+		return arguments[0]
+	default:
+		return fmt.Sprintf("%s(%s)", function, strings.Join(arguments, ", "))
+	}
+}
+
+// returns: a string between double quotes, valid in bison and JavaScript.
+func quoteString(value string) string {
+	var sb strings.Builder
+	sb.WriteString(`"`)
+	for _, char := range value {
+		switch {
+		case char == '"' || char == '\\':
+			sb.WriteString(`\` + string(char))
+		case char == '\n':
+			sb.WriteString(`\n`)
+		case char == '\r':
+			sb.WriteString(`\r`)
+		case char == '\t':
+			sb.WriteString(`\t`)
+		case !unicode.IsPrint(char) && char <= 0xFF:
+			sb.WriteString(fmt.Sprintf(`\x%02x`, char))
+		default:
+			sb.WriteRune(char)
+		}
+	}
+	sb.WriteString(`"`)
+	return sb.String()
+}
+
+// Writes a grammar in the A -> b{A} format, so it can be read again with
+// ParseGrammars or line by line with AddProductionFromString. The start
+// symbol is written first, NON terminals are renamed to legal and unique
+// names like A_1 and terminals are escaped when needed.
+//
+// Returns: the grammar file, or an error if a terminal cannot be written
+// because it has more than one character, like the tokens imported from ANTLR.
+func ExportNative(g *Grammar) (string, error) {
+	for _, terminal := range g.terminals {
+		if terminal != EpsilonSymbol && len([]rune(terminal.Value)) != 1 {
+			return "", fmt.Errorf("terminal %q has more than one character, the native format only has single character terminals", terminal.Value)
+		}
+		if escaped := escapeTerminal(terminal); !utf8.ValidString(escaped) || !unicode.IsPrint([]rune(escaped)[0]) {
+			return "", fmt.Errorf("terminal %q cannot be written in the native format", terminal.Value)
+		}
+	}
+
+	names := nativeNames(g)
+	var sb strings.Builder
+	for _, head := range readingOrderHeads(g) {
+		sb.WriteString(names.of(head))
+		sb.WriteString(" -> ")
+		for index, body := range g.Productions[head] {
+			if len(body) == 0 {
+				sb.WriteString(Epsilon)
+			}
+			for _, symbol := range body {
+				if symbol.IsTerminal {
+					sb.WriteString(escapeTerminal(symbol))
+				} else {
+					sb.WriteString("{" + names.of(symbol) + "}")
+				}
+			}
+			if index != len(g.Productions[head])-1 {
+				sb.WriteString("|")
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String(), nil
+}
+
+// Returns: the names of every NON terminal of the grammar in the native
+// format, unique even if two symbols give the same identifier.
+func nativeNames(g *Grammar) *exportNames {
+	names := newExportNames(g, nameStyle{reserved: map[string]bool{}, identifier: nativeIdentifier})
+	for _, nonTerminal := range g.NonTerminals {
+		names.add(nonTerminal, false, false)
+	}
+	// Los que solo aparecen en los cuerpos, en el orden en que se escriben
+	for _, head := range readingOrderHeads(g) {
+		for _, body := range g.Productions[head] {
+			for _, symbol := range body {
+				if !symbol.IsTerminal {
+					names.add(symbol, false, false)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// returns: a legal NON terminal name of the native format for a symbol,
+// without checking if it is unique. Unlike identifier, letters that are not
+// ASCII and primes are kept, except ε.
+func nativeIdentifier(symbol Symbol) string {
+	var sb strings.Builder
+	for _, char := range symbol.Value {
+		if isNonTerminalChar(char) && string(char) != Epsilon {
+			sb.WriteRune(char)
+		} else {
+			sb.WriteString(fmt.Sprintf("_u%04X", char))
+		}
+	}
+	if symbol.Id != 0 {
+		sb.WriteString(fmt.Sprintf("_%d", symbol.Id))
+	}
+	if sb.Len() == 0 {
+		return "r"
+	}
+	return sb.String()
+}
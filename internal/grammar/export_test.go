@@ -0,0 +1,405 @@
+package grammar
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Grammar with names that are not legal identifiers in the export formats.
+func exportTestGrammar(t *testing.T) *Grammar {
+	grammars, err := ParseGrammars("test.txt", []string{
+		"S -> {S}+{A'}|{error}|ε",
+		"A' -> \\\\|é{Término}",
+		"Término -> x",
+		"error -> y",
+	})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+	g := grammars[0]
+
+	// {S_1} -> ID, with ID a token of a lexer as the ones imported from ANTLR
+	S1 := Symbol{IsTerminal: false, Value: "S", Id: 1}
+	g.AddProductionBodies(S1, [][]Symbol{{{IsTerminal: true, Value: "ID"}}})
+	S := Symbol{IsTerminal: false, Value: "S", Id: 0}
+	g.Productions[S] = append(g.Productions[S], []Symbol{S1})
+	g.RecalculateTerminals()
+	return g
+}
+
+func TestExportBison(t *testing.T) {
+	expected := `%token ID
+%token T__u00E9 "é"
+%start S
+
+%%
+
+S
+    : S '+' A_prime
+    | error_
+    | %empty
+    | S_1
+    ;
+
+A_prime
+    : '\\'
+    | T__u00E9 T_u00E9rmino
+    ;
+
+error_
+    : 'y'
+    ;
+
+T_u00E9rmino
+    : 'x'
+    ;
+
+S_1
+    : ID
+    ;
+`
+	if got := ExportBison(exportTestGrammar(t)); got != expected {
+		t.Errorf("Expected %q,\n but got %q", expected, got)
+	}
+}
+
+func TestExportANTLR(t *testing.T) {
+	expected := `grammar my_grammar;
+
+tokens { ID }
+
+s
+    : s '+' a_prime
+    | error
+    |
+    | s_1
+    ;
+
+a_prime
+    : '\\'
+    | 'é' t_u00E9rmino
+    ;
+
+error
+    : 'y'
+    ;
+
+t_u00E9rmino
+    : 'x'
+    ;
+
+s_1
+    : ID
+    ;
+`
+	if got := ExportANTLR(exportTestGrammar(t), "my-grammar"); got != expected {
+		t.Errorf("Expected %q,\n but got %q", expected, got)
+	}
+
+	// El archivo .g4 debe llamarse como la gramática
+	for name, expected := range map[string]string{"Expr": "Expr", "my-grammar": "my_grammar", "2024": "r2024"} {
+		if got := ANTLRGrammarName(name); got != expected {
+			t.Errorf("Expected %q, but got %q", expected, got)
+		}
+	}
+}
+
+func TestExportTreeSitter(t *testing.T) {
+	expected := `module.exports = grammar({
+  name: "my_grammar",
+
+  rules: {
+    s: $ => choice(seq($.s, "+", $.a_prime), $.error, blank(), $.s_1),
+    a_prime: $ => choice("\\", seq("é", $.t_u00E9rmino)),
+    error: $ => "y",
+    t_u00E9rmino: $ => "x",
+    s_1: $ => $.ID,
+    ID: $ => {
+      throw new Error("falta la definición del token ID");
+    },
+  }
+});
+`
+	if got := ExportTreeSitter(exportTestGrammar(t), "My-Grammar"); got != expected {
+		t.Errorf("Expected %q,\n but got %q", expected, got)
+	}
+}
+
+func TestExportIsDeterministic(t *testing.T) {
+	g := parseTestGrammar(t, "E -> {E}+{T}|{T}", "T -> {T}*{F}|{F}", "F -> ({E})|a|{N}a", "N -> n|ε", "U -> u")
+	exports := map[string]func(g *Grammar) string{
+		"bison":       ExportBison,
+		"antlr":       func(g *Grammar) string { return ExportANTLR(g, "test") },
+		"tree-sitter": func(g *Grammar) string { return ExportTreeSitter(g, "test") },
+	}
+
+	for format, export := range exports {
+		expected := export(SimplifyGrammar(g, false))
+		for run := 0; run < 20; run++ {
+			if got := export(SimplifyGrammar(g, false)); got != expected {
+				t.Fatalf("%s: Expected %q,\n but got %q", format, expected, got)
+			}
+		}
+	}
+}
+
+func TestExportNamesAreUnique(t *testing.T) {
+	// {A_1} and {A_1_0} both become A_1
+	grammars, err := ParseGrammars("test.txt", []string{"A -> {A_1}|a", "A_1 -> b"})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+	g := grammars[0]
+	A1 := Symbol{IsTerminal: false, Value: "A", Id: 1}
+	g.AddProductionBodies(A1, [][]Symbol{{{IsTerminal: true, Value: "c"}}})
+
+	names := newExportNames(g, nameStyle{reserved: bisonReserved})
+	seen := make(map[string]Symbol)
+	for symbol, name := range names.names {
+		if other, exist := seen[name]; exist {
+			t.Errorf("Expected unique names, but %s and %s are both %s", symbol.String(), other.String(), name)
+		}
+		seen[name] = symbol
+	}
+}
+
+func TestExportNative(t *testing.T) {
+	g := exportTestGrammar(t)
+	delete(g.Productions, Symbol{IsTerminal: false, Value: "S", Id: 1})
+	g.Productions[Symbol{IsTerminal: false, Value: "S", Id: 0}] = g.Productions[Symbol{IsTerminal: false, Value: "S", Id: 0}][:3]
+	g.AddProductionBodies(Symbol{IsTerminal: false, Value: "(", Id: 2}, [][]Symbol{{{IsTerminal: true, Value: "\t"}, EpsilonSymbol}, {}})
+	g.RecalculateTerminals()
+
+	expected := "S -> {S}+{A'}|{error}|ε\n" +
+		"A' -> \\\\|é{Término}\n" +
+		"error -> y\n" +
+		"Término -> x\n" +
+		"_u0028_2 -> \\tε|ε\n"
+	got, err := ExportNative(g)
+	if err != nil {
+		t.Fatalf("Expected no errors, but got %v", err)
+	}
+	if got != expected {
+		t.Errorf("Expected %q,\n but got %q", expected, got)
+	}
+}
+
+func TestExportNativeTokens(t *testing.T) {
+	// ID is a single terminal, which cannot be written in the native format
+	if _, err := ExportNative(exportTestGrammar(t)); err == nil {
+		t.Errorf("Expected an error for the terminal ID")
+	}
+}
+
+// Grammars used across the test suite, by name.
+func nativeRoundTripGrammars(t *testing.T) map[string]*Grammar {
+	grammars := map[string]*Grammar{
+		"testGrammar":                            testGrammar,
+		"grammarTestCNFConversion":               grammarTestCNFConversion,
+		"expectedTestCNFCNFTerminalSubstitution": expectedTestCNFCNFTerminalSubstitution,
+		"expectedTestCNFSplitLargeProductions":   expectedTestCNFSplitLargeProductions,
+		"grammarTestCNFAddStartSymbol":           grammarTestCNFAddStartSymbol,
+		"expectedTestCNFAddStartSymbol":          expectedTestCNFAddStartSymbol,
+		"grammarTestUselessSymbolElimination":    grammarTestUselessSymbolElimination,
+		"expectedRemoveReachableSymbols":         expectedRemoveReachableSymbols,
+		"expectedRemoveGeneratingSymbols":        expectedRemoveGeneratingSymbols,
+		"expectedTestUselessSymbolElimination":   expectedTestUselessSymbolElimination,
+	}
+
+	readers := map[string]func(string, []string) ([]*Grammar, error){
+		"test.txt": ParseGrammars, "test.bnf": ParseBNF, "test.abnf": ParseABNF, "test.ebnf": ParseW3CEBNF, "test.g4": ParseANTLR,
+	}
+	sources := []struct {
+		file  string
+		lines []string
+	}{
+		{"test.txt", []string{"%start B", "A -> a{A}|ε", "B -> {A}b|c", "---", "S -> he |she "}},
+		{"test.txt", []string{"A -> a|{B}C|{B}C", "B -> b|{C}D", "B -> {M}"}},
+		{"test.txt", []string{"S -> a{S}b|ε"}},
+		{"test.txt", []string{"A -> {B}x|jk|{B}b|jl", "B -> mm|mb|m"}},
+		{"test.txt", []string{"A -> {B}{A}|{C}m|aa", "B -> {C}", "C -> {A}"}},
+		{"test.txt", []string{"A -> {A}t{B}|{B}", "B -> int|l{A}l"}},
+		{"test.txt", []string{"S -> {S}m|{A}", "A -> {A}m|{B}", "B -> {B}m|{C}", "C -> a"}},
+		{"test.txt", []string{"expr_list -> {expr_list},{A'}|{Término}"}},
+		{"test.txt", []string{"expr -> {expr}+{term}|{term}", "term -> {term_2}{term}|x", "term_2 -> y"}},
+		{"test.txt", []string{`A -> \{{A}\}|a\|b|\ε|\s\\|ε|x y`}},
+		{"test.txt", []string{`A -> \t\n\r|\u00A0\u00e9`}},
+		{"test.txt", []string{"A -> {A_1}|a", "A_1 -> b"}},
+		{"test.txt", []string{"%ebnf", "{Args} -> {Expr}(,{Expr})*", "{Expr} -> x|[{Args}]"}},
+		{"test.txt", []string{"%ebnf", "S -> a((b|c)d)?", "T -> \\(a\\)\\*\\+\\?"}},
+		{"test.bnf", []string{`<expr> ::= <term> "+" <expr> | <term>`, `<term> ::= 'x' | "(" <expr> ")" | "-" <term>`, `<opt-sign> ::= "" | "+-"`}},
+		{"test.abnf", []string{`greeting = "hi" / %x48.49`, `greeting =/ sign`, `sign = [ "+" / "-" ]`, `pair = 2bit`, `bit = %x30-31`}},
+		{"test.abnf", []string{"date = 4DIGIT", `       "-" 2digit`, "hex  = %s\"0x\" 1*HEXDIG"}},
+		{"test.ebnf", []string{`List ::= Name ("," Name)*`, `Name ::= [ab]+`, `Sign ::= '+' | #x2D | [^#x20-#x7E]`}},
+		{"test.ebnf", []string{`Comment ::= "/*" [^*/]* '*/'`}},
+		{"test.g4", []string{"grammar List;", "list : '[' (item (',' item)*)? ']' ;", "item : 'x' | list ;"}},
+	}
+	for index, source := range sources {
+		parsed, err := readers[source.file](source.file, source.lines)
+		if err != nil {
+			t.Fatalf("Expected no errors in source %d, but got:\n%v", index, err)
+		}
+		for grammarIndex, g := range parsed {
+			name := fmt.Sprintf("%s %d.%d", source.file, index, grammarIndex)
+			addStageGrammars(grammars, name, g, GNFPipeline())
+		}
+	}
+	return grammars
+}
+
+// Returns: the random grammars of the property tests and their stages. The
+// Greibach normal form of these grammars is too large to build every time.
+func randomRoundTripGrammars() map[string]*Grammar {
+	grammars := make(map[string]*Grammar)
+	for configName, config := range randomGrammarConfigs() {
+		for seed := int64(0); seed < 20; seed++ {
+			addStageGrammars(grammars, fmt.Sprintf("random %s %d", configName, seed), RandomGrammar(seed, config), nil)
+		}
+	}
+	return grammars
+}
+
+// Adds the grammar and every stage of the presets to grammars. Every stage
+// must also be writable, except the empty grammars left when the language is
+// empty.
+func addStageGrammars(grammars map[string]*Grammar, name string, g *Grammar, extra *Pipeline) {
+	grammars[name] = g
+	pipelines := map[string]*Pipeline{PipelineSimplify: SimplifyPipeline(), PipelineCNF: CNFPipeline(), PipelineLL1Prep: LL1PrepPipeline()}
+	if extra != nil {
+		pipelines["extra"] = extra
+	}
+	for pipelineName, pipeline := range pipelines {
+		pipeline.Run(g, false, func(stage string, stageGrammar *Grammar) {
+			if len(stageGrammar.Productions) > 0 {
+				grammars[name+" "+pipelineName+" "+stage] = stageGrammar.Clone()
+			}
+		})
+	}
+}
+
+func TestExportNativeRoundTrip(t *testing.T) {
+	grammars := nativeRoundTripGrammars(t)
+	for name, g := range randomRoundTripGrammars() {
+		grammars[name] = g
+	}
+	for name, g := range grammars {
+		t.Run(name, func(t *testing.T) {
+			written, err := ExportNative(g)
+			if err != nil {
+				t.Fatalf("Expected no errors, but got %v", err)
+			}
+			lines := strings.Split(strings.TrimSuffix(written, "\n"), "\n")
+
+			parsed, err := ParseGrammars("test.txt", lines)
+			if err != nil {
+				t.Fatalf("Expected the written grammar to be valid, but got:\n%v\n%s", err, written)
+			}
+			if len(parsed) != 1 {
+				t.Fatalf("Expected 1 grammar, but got %d", len(parsed))
+			}
+			assertSameNativeGrammar(t, written, parsed[0])
+			assertNativeGrammarRead(t, g, parsed[0])
+
+			added := &Grammar{Productions: make(map[Symbol][][]Symbol)}
+			for _, line := range lines {
+				added.AddProductionFromString(line)
+			}
+			assertSameNativeGrammar(t, written, added)
+		})
+	}
+}
+
+// Checks that the grammar read from the export of g has the same start
+// symbol, productions and NON terminals, with the names of the export.
+func assertNativeGrammarRead(t *testing.T, g *Grammar, parsed *Grammar) {
+	t.Helper()
+	names := nativeNames(g)
+	expected := nativeProductions(g, names.of)
+	found := nativeProductions(parsed, nativeIdentifier)
+	if !reflect.DeepEqual(found, expected) {
+		t.Errorf("Expected the productions %q, but got %q", expected, found)
+	}
+
+	// El símbolo inicial solo se escribe si tiene producciones
+	if _, exist := g.Productions[g.GetStartSymbol()]; exist && nativeIdentifier(parsed.GetStartSymbol()) != names.of(g.GetStartSymbol()) {
+		t.Errorf("Expected the start symbol %q, but got %q", names.of(g.GetStartSymbol()), nativeIdentifier(parsed.GetStartSymbol()))
+	}
+
+	// Los no terminales sin producciones que no se usan no se escriben
+	expectedNonTerminals := make([]string, 0)
+	for head, bodies := range g.Productions {
+		expectedNonTerminals = append(expectedNonTerminals, names.of(head))
+		for _, body := range bodies {
+			for _, symbol := range body {
+				if !symbol.IsTerminal {
+					expectedNonTerminals = append(expectedNonTerminals, names.of(symbol))
+				}
+			}
+		}
+	}
+	expectedNonTerminals = sortedUnique(expectedNonTerminals)
+	foundNonTerminals := make([]string, 0, len(parsed.NonTerminals))
+	for _, nonTerminal := range parsed.NonTerminals {
+		foundNonTerminals = append(foundNonTerminals, nativeIdentifier(nonTerminal))
+	}
+	if foundNonTerminals = sortedUnique(foundNonTerminals); !reflect.DeepEqual(foundNonTerminals, expectedNonTerminals) {
+		t.Errorf("Expected the NON terminals %q, but got %q", expectedNonTerminals, foundNonTerminals)
+	}
+}
+
+// Returns: the bodies of every head with productions, with the NON terminals
+// written with name, ε for the empty bodies and the terminals quoted.
+func nativeProductions(g *Grammar, name func(Symbol) string) map[string][]string {
+	productions := make(map[string][]string)
+	for head, bodies := range g.Productions {
+		written := make([]string, 0, len(bodies))
+		for _, body := range bodies {
+			var sb strings.Builder
+			if len(body) == 0 {
+				sb.WriteString(Epsilon)
+			}
+			for _, symbol := range body {
+				switch {
+				case symbol == EpsilonSymbol:
+					sb.WriteString(Epsilon)
+				case symbol.IsTerminal:
+					sb.WriteString(strconv.Quote(symbol.Value))
+				default:
+					sb.WriteString("{" + name(symbol) + "}")
+				}
+			}
+			written = append(written, sb.String())
+		}
+		productions[name(head)] = written
+	}
+	return productions
+}
+
+// Returns: the strings sorted and without repetitions.
+func sortedUnique(items []string) []string {
+	sort.Strings(items)
+	result := make([]string, 0, len(items))
+	for i, item := range items {
+		if i == 0 || item != items[i-1] {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// Checks that writing a grammar read from written gives back the same text,
+// with the heads in the same order.
+func assertSameNativeGrammar(t *testing.T, written string, g *Grammar) {
+	t.Helper()
+	rewritten, err := ExportNative(g)
+	if err != nil {
+		t.Fatalf("Expected no errors, but got %v", err)
+	}
+	if rewritten != written {
+		t.Errorf("Expected %q,\n but got %q", written, rewritten)
+	}
+}
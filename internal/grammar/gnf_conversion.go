@@ -0,0 +1,146 @@
+package grammar
+
+/*
+Conversión a la forma normal de Greibach: cada cuerpo es un terminal seguido
+de no terminales, A -> a{B}{C}. Como la forma normal de Chomsky, parte de una
+gramática sin producciones ε, sin producciones unarias y sin símbolos inútiles,
+así que va después de las pasadas epsilon, unit y useless:
+
+	pipeline := NewPipeline(EpsilonPass(), UnitPass(), UselessPass(), GreibachPass())
+
+Se hace en tres pasos:
+
+ 1. Se quita la recursión por la izquierda con el algoritmo de Paull, como
+    en RemoveLeftRecursion: para cada Ai se sustituyen los cuerpos
+    Ai -> Aj γ con j < i por los cuerpos de Aj, si Aj llega a Ai por la
+    izquierda, y luego se quita la recursión directa de Ai con un nuevo no
+    terminal Zi, pero sin ε: Ai -> β|βZi, Zi -> α|αZi.
+ 2. Se sustituye el primer símbolo de cada cuerpo que empieza con un no
+    terminal por los cuerpos de ese no terminal, desde An hacia atrás, hasta
+    que todos los cuerpos empiezan con un terminal.
+ 3. Los terminales que no están al inicio de un cuerpo se reemplazan por un
+    nuevo no terminal que solo produce ese terminal.
+
+Si el símbolo inicial era anulable, la cadena vacía vuelve con S' -> ε como
+en la forma normal de Chomsky.
+*/
+
+// Orders the NON terminals, with the start symbol first, and removes the
+// left recursion with the loop of RemoveLeftRecursion: the bodies that start
+// with an earlier NON terminal that can reach the head by the left are
+// substituted, and then the direct left recursion is removed without ε,
+// A -> β|β{A'} and A' -> α|α{A'}. The grammar must not have ε or unit
+// productions.
+//
+// Returns: a new grammar whose bodies start with a terminal, with a NON
+// terminal that does not reach the head by the left, or are the bodies of a
+// new A'.
+func GNFRemoveLeftRecursion(originalGrammar *Grammar) *Grammar {
+	return paullRemoveLeftRecursion(originalGrammar, func(alphas [][]Symbol, betas [][]Symbol, newHead Symbol) ([][]Symbol, [][]Symbol) {
+		// A -> β|β{A'} y A' -> α|α{A'}
+		headBodies := make([][]Symbol, 0, 2*len(betas))
+		for _, body := range betas {
+			headBodies = append(headBodies, body, append(copySymbols(body), newHead))
+		}
+		newBodies := make([][]Symbol, 0, 2*len(alphas))
+		for _, body := range alphas {
+			newBodies = append(newBodies, body, append(copySymbols(body), newHead))
+		}
+		return headBodies, newBodies
+	}, nil)
+}
+
+// Substitutes the first symbol of every body that starts with a NON
+// terminal by the bodies of that NON terminal, until every body starts with
+// a terminal. The grammar must not be left recursive, like the result of
+// GNFRemoveLeftRecursion.
+//
+// Returns: a new grammar whose bodies start with a terminal.
+func GNFSubstituteLeadingNonTerminals(originalGrammar *Grammar) *Grammar {
+	grammar := originalGrammar.Clone()
+
+	done := make(map[Symbol]bool)
+	visiting := make(map[Symbol]bool)
+	var substitute func(head Symbol)
+	substitute = func(head Symbol) {
+		if done[head] || visiting[head] {
+			return
+		}
+		visiting[head] = true
+		for _, body := range grammar.Productions[head] {
+			if len(body) > 0 && !body[0].IsTerminal {
+				substitute(body[0])
+			}
+		}
+
+		bodies := make([][]Symbol, 0, len(grammar.Productions[head]))
+		for _, body := range grammar.Productions[head] {
+			// Con recursión por la izquierda el cuerpo se deja como está
+			if len(body) == 0 || body[0].IsTerminal || !done[body[0]] {
+				bodies = append(bodies, body)
+				continue
+			}
+			// Los cuerpos del primer símbolo ya empiezan con un terminal
+			bodies = append(bodies, substituteLeadingSymbol([][]Symbol{body}, body[0], grammar.Productions[body[0]])...)
+		}
+		grammar.Productions[head] = removeDuplicatesSlices(bodies)
+		visiting[head] = false
+		done[head] = true
+	}
+
+	for _, head := range productionHeads(grammar) {
+		substitute(head)
+	}
+	return grammar
+}
+
+// Replaces every terminal that is not the first symbol of a body by a new
+// NON terminal that only produces that terminal, like {a_1} -> a.
+//
+// Returns: a new grammar whose bodies are a terminal followed by NON
+// terminals, if every body started with a terminal.
+func GNFTerminalSubstitution(originalGrammar *Grammar) *Grammar {
+	grammar := originalGrammar.Clone()
+
+	terminalToNonTerminal := make(map[Symbol]Symbol)
+	for _, head := range productionHeads(originalGrammar) {
+		for _, body := range grammar.Productions[head] {
+			for i := 1; i < len(body); i++ {
+				terminal := body[i]
+				if !terminal.IsTerminal || terminal == EpsilonSymbol {
+					continue
+				}
+				if _, exist := terminalToNonTerminal[terminal]; !exist {
+					// El mismo nombre que usa la forma normal de Chomsky, sin chocar
+					// con un no terminal que ya exista
+					newNonTerminal := Symbol{IsTerminal: false, Value: terminal.Value, Id: terminal.Id + 1}
+					for isNonTerminalOf(grammar, newNonTerminal) {
+						newNonTerminal.Id++
+					}
+					terminalToNonTerminal[terminal] = newNonTerminal
+					grammar.NonTerminals = append(grammar.NonTerminals, newNonTerminal)
+					grammar.Productions[newNonTerminal] = [][]Symbol{{terminal}}
+				}
+				// Los cuerpos son copias, se pueden modificar
+				body[i] = terminalToNonTerminal[terminal]
+			}
+		}
+	}
+	return grammar
+}
+
+// Returns: the bodies with every body that starts with symbol replaced by
+// one body for each of the replacements, followed by the rest of the body.
+func substituteLeadingSymbol(bodies [][]Symbol, symbol Symbol, replacements [][]Symbol) [][]Symbol {
+	result := make([][]Symbol, 0, len(bodies))
+	for _, body := range bodies {
+		if len(body) == 0 || body[0] != symbol {
+			result = append(result, body)
+			continue
+		}
+		for _, replacement := range replacements {
+			result = append(result, append(copySymbols(replacement), body[1:]...))
+		}
+	}
+	return removeDuplicatesSlices(result)
+}
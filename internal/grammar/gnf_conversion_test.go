@@ -0,0 +1,103 @@
+package grammar
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// Devuelve los cuerpos de un no terminal como texto, ordenados
+func sortedBodies(g *Grammar, head Symbol) []string {
+	bodies := make([]string, 0, len(g.Productions[head]))
+	for _, body := range g.Productions[head] {
+		bodies = append(bodies, productionString(body))
+	}
+	sort.Strings(bodies)
+	return bodies
+}
+
+// Convierte la gramática con GNFPipeline y revisa que quede en forma normal
+// de Greibach y con el mismo lenguaje
+func assertGreibach(t *testing.T, g *Grammar, maxLength int) *Grammar {
+	t.Helper()
+	before := grammarSnapshot(g)
+	result := GNFPipeline().RunResult(g, false)
+	if grammarSnapshot(g) != before {
+		t.Errorf("Expected the grammar to be kept, but got %q", grammarSnapshot(g))
+	}
+
+	for _, stage := range result.Stages {
+		if len(stage.Violations) > 0 {
+			t.Errorf("Expected no violations in %s, but got %v", stage.Name, stage.Violations)
+		}
+	}
+	if ok, violations := result.Grammar.IsGNF(); !ok {
+		t.Errorf("Expected Greibach Normal Form, but got %v in:\n%s", violations, result.Grammar.String(false))
+	}
+	if check := VerifyStages(result.Stages, maxLength); !check.Preserved() {
+		t.Errorf("Expected the same language, but %q changed in %s", check.Change.Input, check.Change.Stage)
+	}
+	return result.Grammar
+}
+
+// Ejemplo de Hopcroft y Ullman, con A1 = A, A2 = B y A3 = C
+func TestGreibachHopcroftUllman(t *testing.T) {
+	g := parseTestGrammar(t, "A -> {B}{C}", "B -> {C}{A}|b", "C -> {A}{B}|a")
+
+	// C -> {A}{B} pasa a C -> {C}{A}{C}{B}|b{C}{B}|a, y se quita la recursión con {C_1}
+	ordered := GNFRemoveLeftRecursion(g)
+	expected := []string{"a", "a{C_1}", "b{C_0}{B_0}", "b{C_0}{B_0}{C_1}"}
+	if bodies := sortedBodies(ordered, Symbol{Value: "C"}); !reflect.DeepEqual(bodies, expected) {
+		t.Errorf("Expected %q, but got %q", expected, bodies)
+	}
+	expected = []string{"{A_0}{C_0}{B_0}", "{A_0}{C_0}{B_0}{C_1}"}
+	if bodies := sortedBodies(ordered, Symbol{Value: "C", Id: 1}); !reflect.DeepEqual(bodies, expected) {
+		t.Errorf("Expected %q, but got %q", expected, bodies)
+	}
+	if recursive, violations := ordered.IsLeftRecursive(); recursive {
+		t.Errorf("Expected no left recursion, but got %v", violations)
+	}
+
+	result := assertGreibach(t, g, 6)
+	expected = []string{"a", "a{C_1}", "b{C_0}{B_0}", "b{C_0}{B_0}{C_1}"}
+	if bodies := sortedBodies(result, Symbol{Value: "C"}); !reflect.DeepEqual(bodies, expected) {
+		t.Errorf("Expected %q, but got %q", expected, bodies)
+	}
+}
+
+func TestGreibachExpressions(t *testing.T) {
+	g := parseTestGrammar(t, "E -> {E}+{T}|{T}", "T -> {T}*{F}|{F}", "F -> ({E})|a")
+	result := assertGreibach(t, g, 5)
+
+	// E' -> +T|+TE', y los terminales que no están al inicio pasan a ser no terminales
+	expected := []string{"+{T_0}", "+{T_0}{E_1}"}
+	if bodies := sortedBodies(result, Symbol{Value: "E", Id: 1}); !reflect.DeepEqual(bodies, expected) {
+		t.Errorf("Expected %q, but got %q", expected, bodies)
+	}
+	expected = []string{"({E_0}{)_1}", "a"}
+	if bodies := sortedBodies(result, Symbol{Value: "F"}); !reflect.DeepEqual(bodies, expected) {
+		t.Errorf("Expected %q, but got %q", expected, bodies)
+	}
+}
+
+func TestGreibachIndirectLeftRecursion(t *testing.T) {
+	g := parseTestGrammar(t, "A -> {B}a|c", "B -> {A}b|d")
+	if recursive, _ := g.IsLeftRecursive(); !recursive {
+		t.Fatalf("Expected the grammar to be left recursive")
+	}
+	assertGreibach(t, g, 7)
+}
+
+func TestGreibachKeepsEmptyString(t *testing.T) {
+	g := parseTestGrammar(t, "S -> a{S}b|ε")
+	result := assertGreibach(t, g, 6)
+
+	start := result.GetStartSymbol()
+	if start == (Symbol{Value: "S"}) || !containsSymbolSlice(result.Productions[start], []Symbol{EpsilonSymbol}) {
+		t.Errorf("Expected a new start symbol with ε, but got:\n%s", result.String(false))
+	}
+	expected := []string{"a{S_0}{b_1}", "a{b_1}"}
+	if bodies := sortedBodies(result, Symbol{Value: "S"}); !reflect.DeepEqual(bodies, expected) {
+		t.Errorf("Expected %q, but got %q", expected, bodies)
+	}
+}
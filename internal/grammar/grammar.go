@@ -1,98 +1,105 @@
 package grammar
 
-import (
-	"fmt"
+// Names of the stages of SimplifyGrammar, in the order they are reached.
+const (
+	StageOriginal          = "original"
+	StageLeftFactored      = "left-factored"
+	StageLeftRecursionFree = "left-recursion-free"
+	StageNullablesReplaced = "nullables-replaced"
+	StageEpsilonFree       = "epsilon-free"
+	StageUnitFree          = "unit-free"
+	StageUseful            = "useful"
+	StageCNFStartSymbol    = "cnf-start-symbol"
+	StageCNFTerminals      = "cnf-terminals"
+	StageChomskyNormalForm = "chomsky-normal-form"
 )
 
-// Dada una gramática, elimina todas las producciones epsilon
-func SimplifyGrammar(grammar *Grammar, printSteps bool) *Grammar {
-
-	// Asegurar que el símbolo inicial sea el primer no terminal en la lista nonTerminals
-	if len(grammar.NonTerminals) == 0 {
-		return grammar
-	}
-
-	startSymbol := grammar.NonTerminals[0]
-
-	if printSteps {
-		fmt.Println("\n1️⃣  Gramática ANTES de la simplificación:")
-		fmt.Println(grammar.String(true))
-	}
-
-	fmt.Println("\n2️⃣  FACTORIZACION POR LA IZQUIERDA:")
-	factorizedGrammar := factorizeGrammar(grammar)
-	if printSteps {
-		fmt.Println(factorizedGrammar.String(true))
-	}
-
-	fmt.Println("\nr3️⃣ REMOVER RECURSION POR LA IZQUIERDA:")
-	grammarWithouthRecursion := removeLeftRecursivity(factorizedGrammar)
-	if printSteps {
-		fmt.Println(grammarWithouthRecursion.String(true))
-	}
-
-	fmt.Println("\n4️⃣  ELIMINACIÓN DE EPSILON:")
-
-	// Paso 1: Identificar los símbolos directos anulables
-	directNullables := identifyDirectNullables(grammarWithouthRecursion)
-	if printSteps {
-		fmt.Println("\n🔴  2.1 Símbolos anulables directos encontrados:")
-		fmt.Printf("\t%v\n", *directNullables)
-	}
-
-	// Paso 2: Identificar todos los símbolos anulables (directos e indirectos)
-	allNullables := identifyIndirectNullables(grammarWithouthRecursion, *directNullables)
-	if printSteps {
-		fmt.Println("\n🔴  2.2 Todos los símbolos anulables encontrados:")
-		fmt.Printf("\t%v\n", *allNullables)
-	}
+// Names of the stages of the greibach pass, in the order they are reached.
+const (
+	StageGNFLeftRecursionFree = "gnf-left-recursion-free"
+	StageGNFLeadingTerminals  = "gnf-leading-terminals"
+	StageGreibachNormalForm   = "greibach-normal-form"
+)
 
-	// Paso 3: Reemplazar los símbolos anulables en las producciones
-	grammarWithoutEpsilons := ReplaceNullables(grammarWithouthRecursion, *allNullables)
-	if printSteps {
-		fmt.Println("\n🔴  2.3 Gramática DESPUÉS de reemplazar los anulables:")
-		fmt.Println(grammarWithoutEpsilons.String(true))
-	}
+// Result of simplifying a grammar: a copy of the grammar of every stage, in
+// the order they are reached, and the final grammar.
+type Simplification struct {
+	Stages  []SimplificationStage `json:"stages"`
+	Grammar *Grammar              `json:"grammar"`
+}
 
-	// Paso 4: Eliminar producciones épsilon
-	finalGrammar1 := RemoveEpsilons(grammarWithoutEpsilons)
-	if printSteps {
-		fmt.Println("\n🔴  2.4 Gramática DESPUÉS de eliminar las producciones epsilon:")
-		fmt.Println(finalGrammar1.String(true))
-	}
+// A snapshot of the grammar of a single stage of the simplification. The
+// grammar is a copy, so later stages never modify it.
+type SimplificationStage struct {
+	Name    string         `json:"name"`
+	Pass    string         `json:"pass"`  // Pass that reached the stage, empty for the original grammar.
+	Title   string         `json:"title"` // Description of the stage, as printed by TextObserver.
+	Grammar *Grammar       `json:"grammar"`
+	Metrics GrammarMetrics `json:"metrics"`
+	// Productions that break the form the stage must have, like ε bodies in
+	// the epsilon-free stage.
+	Violations []ProductionViolation `json:"violations,omitempty"`
+}
 
-	// Paso 5: Eliminar producciones unarias
-	fmt.Println("\n5️⃣  ELIMINACIÓN DE PRODUCCIONES UNARIAS:")
-	finalGrammar2 := RemoveUnaryProductions(finalGrammar1, finalGrammar1.NonTerminals)
-	if printSteps {
-		fmt.Println("\n🔴  3.1 Gramática DESPUÉS de eliminar producciones unarias:")
-		fmt.Println(finalGrammar2.String(true))
-	}
+// Size of a grammar, to compare the stages of a simplification.
+type GrammarMetrics struct {
+	NonTerminals    int `json:"nonTerminals"`    // NON terminals with productions.
+	Terminals       int `json:"terminals"`       // Different terminals used in the bodies, without ε.
+	Productions     int `json:"productions"`     // Bodies of all the NON terminals.
+	MaxBodyLength   int `json:"maxBodyLength"`   // Symbols of the longest body, ε bodies have length 0.
+	TotalBodyLength int `json:"totalBodyLength"` // Symbols of all the bodies together.
+}
 
-	// Paso 6: Eliminar símbolos inútiles
-	fmt.Println("\n6️⃣  ELIMINACIÓN DE SIMBOLOS INUTILES:")
-	finalGrammar3 := RemoveUselessSymbols(finalGrammar2, startSymbol)
-	if printSteps {
-		fmt.Println("\n🔴  4.1 Gramática DESPUÉS de eliminar símbolos inútiles:")
-		fmt.Println(finalGrammar3.String(true))
+// Returns: the stage with the given name, or false if the simplification
+// did not reach it.
+func (s *Simplification) Stage(name string) (SimplificationStage, bool) {
+	for _, stage := range s.Stages {
+		if stage.Name == name {
+			return stage, true
+		}
 	}
+	return SimplificationStage{}, false
+}
 
-	// Paso 7: Normalizar paso 1
-	fmt.Println("\n7️⃣  SIMPLIFICACIÓN A FORMA NORMAL DE CHOMSKY:")
-	ncfGrammar1 := CNFTerminalSubstitution(finalGrammar3)
-	if printSteps {
-		fmt.Println("\n🔴  5.1 Gramática DESPUÉS de normalizar el paso 1 de Chomsky:")
-		fmt.Println(ncfGrammar1.String(true))
+// Returns: the size of the grammar.
+func (g *Grammar) Metrics() GrammarMetrics {
+	metrics := GrammarMetrics{NonTerminals: len(g.Productions)}
+	terminals := make(map[Symbol]bool)
+	for _, bodies := range g.Productions {
+		metrics.Productions += len(bodies)
+		for _, body := range bodies {
+			length := 0
+			for _, symbol := range body {
+				if symbol == EpsilonSymbol {
+					continue
+				}
+				length++
+				if symbol.IsTerminal {
+					terminals[symbol] = true
+				}
+			}
+			metrics.TotalBodyLength += length
+			metrics.MaxBodyLength = max(metrics.MaxBodyLength, length)
+		}
 	}
+	metrics.Terminals = len(terminals)
+	return metrics
+}
 
-	// Paso 8: Normalizar paso 2
-	ncfGrammar2 := CNFSplitLargeProductions(ncfGrammar1)
-	if printSteps {
-		fmt.Println("\n🔴  5.2 Gramática DESPUÉS de normalizar el paso 2 de Chomsky:")
-		fmt.Println(ncfGrammar2.String(true))
-	}
+// Dada una gramática, elimina todas las producciones epsilon
+func SimplifyGrammar(grammar *Grammar, printSteps bool) *Grammar {
+	return SimplifyGrammarStages(grammar, printSteps, nil)
+}
 
-	sortGrammar := OrderProductionsByNonTerminals(ncfGrammar2)
+// Simplifies a grammar like SimplifyGrammar, keeping a copy of the grammar
+// of every stage.
+func SimplifyGrammarResult(grammar *Grammar, printSteps bool) *Simplification {
+	return SimplifyPipeline().RunResult(grammar, printSteps)
+}
 
-	return sortGrammar
+// Simplifies a grammar like SimplifyGrammar, calling onStage with the name
+// and the grammar of every stage as soon as it is reached, for example to
+// save each one to disk. onStage may be nil and must not modify the grammar.
+func SimplifyGrammarStages(grammar *Grammar, printSteps bool, onStage func(stage string, g *Grammar)) *Grammar {
+	return SimplifyPipeline().Run(grammar, printSteps, onStage)
 }
@@ -2,6 +2,7 @@ package grammar
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -145,3 +146,215 @@ func TestRemoveDuplicates(t *testing.T) {
 		}
 	}
 }
+
+func TestParseStartDirective(t *testing.T) {
+	name, isDirective := ParseStartDirective("%start Expr")
+	if !isDirective || name != "Expr" {
+		t.Errorf("Expected start directive with name %q, but got %q (%v)", "Expr", name, isDirective)
+	}
+
+	if _, isDirective := ParseStartDirective("S -> a"); isDirective {
+		t.Errorf("Expected %q not to be a start directive", "S -> a")
+	}
+}
+
+func TestGetStartSymbol(t *testing.T) {
+	g := Grammar{
+		Productions: make(map[Symbol][][]Symbol),
+	}
+	g.AddProductionFromString("A -> a{B}")
+	g.AddProductionFromString("B -> b")
+
+	// Without a directive the first NON terminal is the start symbol
+	if start := g.GetStartSymbol(); start.Value != "A" {
+		t.Errorf("Expected start symbol A, but got %s", start.String())
+	}
+
+	g.SetStartSymbol("{B}")
+	if start := g.GetStartSymbol(); start.Value != "B" || start.IsTerminal {
+		t.Errorf("Expected start symbol B, but got %s", start.String())
+	}
+}
+
+func TestSimplifyGrammarHonoursStartSymbol(t *testing.T) {
+	g := Grammar{
+		Productions: make(map[Symbol][][]Symbol),
+	}
+	g.AddProductionFromString("A -> a{A}|b")
+	g.AddProductionFromString("B -> c{A}")
+	g.SetStartSymbol("B")
+
+	simplified := SimplifyGrammar(&g, false)
+	start := simplified.GetStartSymbol()
+
+	if start.Value != "B" {
+		t.Fatalf("Expected start symbol B, but got %s", start.String())
+	}
+	if !CYKParse(simplified, "cab", start) {
+		t.Errorf("Expected %q to be accepted from start symbol B", "cab")
+	}
+	if CYKParse(simplified, "ab", start) {
+		t.Errorf("Expected %q to be rejected from start symbol B", "ab")
+	}
+}
+
+func TestSymbolStringRoundTrip(t *testing.T) {
+	symbols := []Symbol{
+		{IsTerminal: false, Value: "A", Id: 0},
+		{IsTerminal: false, Value: "expr_list", Id: 3},
+		{IsTerminal: false, Value: "A'", Id: 1},
+		{IsTerminal: false, Value: "Término", Id: 0},
+		{IsTerminal: false, Value: "x2_", Id: -1},
+		{IsTerminal: true, Value: "a", Id: 0},
+	}
+
+	for _, symbol := range symbols {
+		parsed, ok := SymbolFromString(symbol.String())
+		if !ok || parsed != symbol {
+			t.Errorf("Expected %q to be parsed back into %v, but got %v", symbol.String(), symbol, parsed)
+		}
+	}
+}
+
+func TestAddProductionFromStringRichNames(t *testing.T) {
+	g := Grammar{
+		Productions: make(map[Symbol][][]Symbol),
+	}
+	g.AddProductionFromString("expr_list -> {expr_list},{A'}|{Término}")
+
+	expectedGrammar := `NonTerminals: [{expr_list_0},{A'_0},{Término_0}]
+Terminals: [,]
+
+{expr_list_0} -> {expr_list_0},{A'_0}|{Término_0}
+`
+	if g.String(true) != expectedGrammar {
+		t.Errorf("Expected %q,\n but got %q", expectedGrammar, g.String(true))
+	}
+
+	grammars, err := ParseGrammars("test.txt", []string{"expr_list -> {expr_list},{A'}|{Término}"})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+	if grammars[0].String(true) != expectedGrammar {
+		t.Errorf("Expected %q,\n but got %q", expectedGrammar, grammars[0].String(true))
+	}
+}
+
+func TestSimplifyGrammarRichNames(t *testing.T) {
+	grammars, err := ParseGrammars("test.txt", []string{
+		"expr -> {expr}+{term}|{term}",
+		"term -> {term_2}{term}|x",
+		"term_2 -> y",
+	})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+
+	simplified := SimplifyGrammar(grammars[0], false)
+	start := simplified.GetStartSymbol()
+
+	for _, input := range []string{"x", "x+x", "yx+yyx"} {
+		if !CYKParse(simplified, input, start) {
+			t.Errorf("Expected %q to be accepted", input)
+		}
+	}
+	for _, input := range []string{"y", "x+", "+x"} {
+		if CYKParse(simplified, input, start) {
+			t.Errorf("Expected %q to be rejected", input)
+		}
+	}
+}
+
+func TestAddProductionFromStringEscapes(t *testing.T) {
+	g := Grammar{
+		Productions: make(map[Symbol][][]Symbol),
+	}
+	g.AddProductionFromString(`A -> \{{A}\}|a\|b|\ε|\s\\|ε`)
+
+	expectedGrammar := "{A_0} -> \\{{A_0}\\}|a\\|b|\\ε|\\s\\\\|ε\n"
+	if g.String(false) != expectedGrammar {
+		t.Errorf("Expected %q,\n but got %q", expectedGrammar, g.String(false))
+	}
+
+	// The escaped ε is a terminal, not the empty string
+	if nullables := identifyDirectNullables(&g); len(*nullables) != 1 {
+		t.Errorf("Expected only the ε body to be nullable, but got %v", *nullables)
+	}
+}
+
+func TestEscapedBodiesRoundTrip(t *testing.T) {
+	g := Grammar{
+		Productions: make(map[Symbol][][]Symbol),
+	}
+	g.AddProductionFromString(`A -> \{{A}\}|a\|b|\ε|\s\\|ε|x y`)
+
+	// Writing every body and reading it again gives back the same bodies
+	written := strings.SplitN(strings.TrimSuffix(g.String(false), "\n"), " -> ", 2)[1]
+	reread := Grammar{
+		Productions: make(map[Symbol][][]Symbol),
+	}
+	reread.AddProductionFromString("A -> " + strings.ReplaceAll(written, "{A_0}", "{A}"))
+
+	A := Symbol{IsTerminal: false, Value: "A", Id: 0}
+	if !compareProductionSlices(g.Productions[A], reread.Productions[A]) {
+		t.Errorf("Expected %v,\n but got %v", g.Productions[A], reread.Productions[A])
+	}
+}
+
+func TestGrammarMetrics(t *testing.T) {
+	grammars, err := ParseGrammars("test.txt", []string{"S -> a{S}b|{A}|ε", "A -> cc{A}|c", "B -> b"})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+
+	expected := GrammarMetrics{NonTerminals: 3, Terminals: 3, Productions: 6, MaxBodyLength: 3, TotalBodyLength: 9}
+	if metrics := grammars[0].Metrics(); metrics != expected {
+		t.Errorf("Expected %+v, but got %+v", expected, metrics)
+	}
+}
+
+func TestSimplificationSnapshots(t *testing.T) {
+	grammars, err := ParseGrammars("test.txt", []string{"A -> {B}x|jk|{B}b|jl", "B -> mm|mb|m|ε"})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+	before := grammars[0].String(true)
+
+	result := SimplifyGrammarResult(grammars[0], false)
+	if grammars[0].String(true) != before {
+		t.Errorf("Expected the input to stay %q, but got %q", before, grammars[0].String(true))
+	}
+
+	snapshots := make(map[string]string)
+	for _, stage := range result.Stages {
+		snapshots[stage.Name] = stage.Grammar.String(true)
+		if stage.Metrics != stage.Grammar.Metrics() {
+			t.Errorf("%s: expected metrics %+v, but got %+v", stage.Name, stage.Grammar.Metrics(), stage.Metrics)
+		}
+		if stage.Title == "" || (stage.Pass == "") != (stage.Name == StageOriginal) {
+			t.Errorf("%s: expected a title and a pass, but got %q and %q", stage.Name, stage.Title, stage.Pass)
+		}
+	}
+
+	// Changing the result does not change the snapshots
+	for head := range result.Grammar.Productions {
+		result.Grammar.Productions[head][0][0] = Symbol{IsTerminal: true, Value: "z"}
+	}
+	for _, stage := range result.Stages {
+		if stage.Grammar.String(true) != snapshots[stage.Name] {
+			t.Errorf("%s: expected the snapshot to stay %q, but got %q", stage.Name, snapshots[stage.Name], stage.Grammar.String(true))
+		}
+	}
+
+	original, found := result.Stage(StageOriginal)
+	if !found || original.Grammar.String(true) != before {
+		t.Errorf("Expected the original stage to be %q, but got %v", before, original)
+	}
+	factored, _ := result.Stage(StageLeftFactored)
+	if factored.Metrics.NonTerminals <= original.Metrics.NonTerminals {
+		t.Errorf("Expected factoring to add NON terminals, but got %+v", factored.Metrics)
+	}
+	if _, found := result.Stage("missing"); found {
+		t.Errorf("Expected no stage named missing")
+	}
+}
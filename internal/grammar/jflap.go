@@ -0,0 +1,251 @@
+package grammar
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+/*
+Lector y escritor de gramáticas de JFLAP (.jff):
+
+	<structure>
+	  <type>grammar</type>
+	  <production><left>S</left><right>aSb</right></production>
+	  <production><left>S</left><right/></production>
+	</structure>
+
+En JFLAP cada caracter es un símbolo: las letras mayúsculas son variables (no
+terminales) y cualquier otro caracter es un terminal. Un lado derecho vacío
+es la cadena vacía (λ en JFLAP). El símbolo inicial es el lado izquierdo de
+la primera producción.
+
+Al escribir, cada no terminal recibe una letra mayúscula: S para el símbolo
+inicial y la misma letra si el nombre ya es una sola mayúscula. Por eso solo
+se pueden escribir gramáticas con hasta 26 no terminales y sin terminales que
+sean letras mayúsculas.
+*/
+
+// Type of the JFLAP files that contain a grammar.
+const JFLAPGrammarType = "grammar"
+
+type jflapProduction struct {
+	Left  string `xml:"left"`
+	Right string `xml:"right"`
+}
+
+// Parses a JFLAP file with a grammar. The lines are joined back to decode
+// the XML, and every error is reported with the line where it was found.
+//
+// Returns: the grammar in a list, to match the other readers, and a
+// ParseErrors if any error was found.
+func ParseJFLAP(file string, lines []string) ([]*Grammar, error) {
+	p := &grammarParser{file: file}
+	p.resetGrammar()
+	decoder := xml.NewDecoder(strings.NewReader(strings.Join(lines, "\n")))
+
+	fileType := ""
+decoding:
+	for {
+		line, column := decoder.InputPos()
+		current, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			p.addXMLError(err, lines)
+			break
+		}
+
+		start, isStart := current.(xml.StartElement)
+		if !isStart {
+			continue
+		}
+		p.line = line
+		at := token{kind: tokenChar, value: "<", column: column}
+
+		switch start.Name.Local {
+		case "type":
+			if err := decoder.DecodeElement(&fileType, &start); err != nil {
+				p.addXMLError(err, lines)
+				break decoding
+			}
+			if fileType != JFLAPGrammarType {
+				p.addError(at, fmt.Sprintf("the JFLAP file is of type %q, not a grammar", fileType),
+					"finite automata are read with nfa.ParseJFLAP")
+			}
+		case "production":
+			var production jflapProduction
+			if err := decoder.DecodeElement(&production, &start); err != nil {
+				p.addXMLError(err, lines)
+				break decoding
+			}
+			p.parseJFLAPProduction(production, at)
+		}
+	}
+
+	if fileType == "" && len(p.errors) == 0 {
+		p.addError(token{column: 1}, "missing <type> element", "JFLAP files start with <structure><type>grammar</type>")
+	}
+	if len(p.errors) > 0 {
+		return nil, p.errors
+	}
+	if g := p.closeGrammar(); g != nil {
+		return []*Grammar{g}, nil
+	}
+	return []*Grammar{}, nil
+}
+
+// Reports an error of the XML decoder, at the start of the line where it
+// was found.
+func (p *grammarParser) addXMLError(err error, lines []string) {
+	message := err.Error()
+	var syntaxError *xml.SyntaxError
+	if errors.As(err, &syntaxError) {
+		p.line, message = syntaxError.Line, syntaxError.Msg
+	}
+
+	at := token{kind: tokenChar, column: 1}
+	if p.line >= 1 && p.line <= len(lines) {
+		for column, char := range []rune(lines[p.line-1]) {
+			if !unicode.IsSpace(char) {
+				at.value, at.column = string(char), column+1
+				break
+			}
+		}
+	}
+	p.addError(at, message, "check that the file is a valid JFLAP file")
+}
+
+// Adds a JFLAP production to the current grammar, checking that its left
+// side is a single variable.
+func (p *grammarParser) parseJFLAPProduction(production jflapProduction, at token) {
+	left := []rune(strings.TrimSpace(production.Left))
+	if len(left) != 1 || !isJFLAPVariable(left[0]) {
+		p.addError(at, fmt.Sprintf("the left side %q is not a single variable", production.Left),
+			"context free grammars have a single uppercase letter on the left side")
+		return
+	}
+
+	head := Symbol{IsTerminal: false, Value: string(left), Id: 0}
+	body := make([]Symbol, 0)
+	for _, char := range production.Right {
+		if isJFLAPVariable(char) {
+			body = append(body, Symbol{IsTerminal: false, Value: string(char), Id: 0})
+		} else {
+			body = append(body, Symbol{IsTerminal: true, Value: string(char), Id: 0})
+		}
+	}
+	if len(body) == 0 {
+		body = append(body, EpsilonSymbol)
+	}
+	p.current.addProductionSymbols(head, [][]Symbol{body})
+}
+
+// Checks if a character is a variable of a JFLAP grammar.
+func isJFLAPVariable(char rune) bool {
+	return char >= 'A' && char <= 'Z'
+}
+
+// Writes a grammar as a JFLAP file. The start symbol is renamed to S and
+// written first, since JFLAP takes the left side of the first production as
+// the start symbol.
+//
+// Returns: the file, or an error if the grammar has more than 26 NON
+// terminals, terminals of more than one character or uppercase terminals.
+func ExportJFLAP(g *Grammar) (string, error) {
+	for _, terminal := range g.terminals {
+		chars := []rune(terminal.Value)
+		if terminal == EpsilonSymbol {
+			continue
+		}
+		if len(chars) != 1 {
+			return "", fmt.Errorf("terminal %q has more than one character, JFLAP only has single character terminals", terminal.Value)
+		}
+		if isJFLAPVariable(chars[0]) {
+			return "", fmt.Errorf("terminal %q cannot be written in JFLAP, where uppercase letters are variables", terminal.Value)
+		}
+	}
+
+	heads := orderedHeads(g)
+	names, err := jflapNames(g, heads)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"no\"?>\n")
+	sb.WriteString("<structure>\n")
+	sb.WriteString(fmt.Sprintf("\t<type>%s</type>\n", JFLAPGrammarType))
+	for _, head := range heads {
+		for _, body := range g.Productions[head] {
+			var right strings.Builder
+			for _, symbol := range body {
+				switch {
+				case symbol == EpsilonSymbol:
+					// La cadena vacía es un lado derecho vacío
+				case symbol.IsTerminal:
+					right.WriteString(symbol.Value)
+				default:
+					right.WriteRune(names[symbol])
+				}
+			}
+			sb.WriteString(fmt.Sprintf("\t<production>\n\t\t<left>%c</left>\n", names[head]))
+			if right.Len() == 0 {
+				sb.WriteString("\t\t<right/>\n")
+			} else {
+				sb.WriteString("\t\t<right>")
+				xml.EscapeText(&sb, []byte(right.String()))
+				sb.WriteString("</right>\n")
+			}
+			sb.WriteString("\t</production>\n")
+		}
+	}
+	sb.WriteString("</structure>\n")
+
+	return sb.String(), nil
+}
+
+// Assigns an uppercase letter to every NON terminal of the grammar: S to the
+// start symbol, its own name to the NON terminals named with a single
+// uppercase letter, and the first free letter to the rest.
+func jflapNames(g *Grammar, heads []Symbol) (map[Symbol]rune, error) {
+	nonTerminals := append([]Symbol{}, heads...)
+	for _, nonTerminal := range g.NonTerminals {
+		if !containsSymbol(nonTerminals, nonTerminal) {
+			nonTerminals = append(nonTerminals, nonTerminal)
+		}
+	}
+	if len(nonTerminals) > 26 {
+		return nil, fmt.Errorf("the grammar has %d nonterminals, but JFLAP only has the 26 uppercase letters", len(nonTerminals))
+	}
+
+	names := make(map[Symbol]rune)
+	used := make(map[rune]bool)
+	assign := func(symbol Symbol, name rune) {
+		names[symbol] = name
+		used[name] = true
+	}
+
+	assign(g.GetStartSymbol(), 'S')
+	for _, nonTerminal := range nonTerminals {
+		chars := []rune(nonTerminal.Value)
+		if _, named := names[nonTerminal]; !named && nonTerminal.Id == 0 && len(chars) == 1 && isJFLAPVariable(chars[0]) && !used[chars[0]] {
+			assign(nonTerminal, chars[0])
+		}
+	}
+	next := 'A'
+	for _, nonTerminal := range nonTerminals {
+		if _, named := names[nonTerminal]; named {
+			continue
+		}
+		for used[next] {
+			next++
+		}
+		assign(nonTerminal, next)
+	}
+	return names, nil
+}
@@ -0,0 +1,166 @@
+package grammar
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseJFLAP(t *testing.T) {
+	lines := []string{
+		`<?xml version="1.0" encoding="UTF-8" standalone="no"?><!--Created with JFLAP 7.1.--><structure>`,
+		"\t<type>grammar</type>",
+		"\t<production><left>S</left><right>aSb</right></production>",
+		"\t<production><left>S</left><right>A</right></production>",
+		"\t<production><left>A</left><right/></production>",
+		"\t<production><left>A</left><right>c&lt;A</right></production>",
+		"</structure>",
+	}
+
+	grammars, err := ParseJFLAP("test.jff", lines)
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+
+	expected := "{S_0} -> a{S_0}b|{A_0}\n{A_0} -> ε|c<{A_0}\n"
+	if got := grammars[0].String(false); got != expected {
+		t.Errorf("Expected %q,\n but got %q", expected, got)
+	}
+
+	simplified := SimplifyGrammar(grammars[0], false)
+	start := simplified.GetStartSymbol()
+	for input, accepted := range map[string]bool{"": true, "ac<b": true, "aac<c<bb": true, "ab": true, "c<b": false} {
+		if got := CYKParse(simplified, input, start); got != accepted {
+			t.Errorf("CYKParse(%q) = %v, expected %v", input, got, accepted)
+		}
+	}
+}
+
+func TestParseJFLAPErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		expected ParseError
+	}{
+		{
+			name:     "Finite automaton",
+			lines:    []string{"<structure>", "  <type>fa</type>", "</structure>"},
+			expected: ParseError{Line: 2, Column: 3, Char: "<", Message: `the JFLAP file is of type "fa", not a grammar`},
+		},
+		{
+			name:     "Unrestricted grammar",
+			lines:    []string{"<structure><type>grammar</type>", "  <production><left>aS</left><right>b</right></production>", "</structure>"},
+			expected: ParseError{Line: 2, Column: 3, Char: "<", Message: `the left side "aS" is not a single variable`},
+		},
+		{
+			name:     "Malformed XML",
+			lines:    []string{"<structure><type>grammar</type>", "  <production><left>S</left>", "</structure>"},
+			expected: ParseError{Line: 3, Column: 1, Char: "<", Message: "element <production> closed by </structure>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseJFLAP("test.jff", tt.lines)
+
+			var parseErrors ParseErrors
+			if !errors.As(err, &parseErrors) || len(parseErrors) != 1 {
+				t.Fatalf("Expected 1 error, but got %v", err)
+			}
+			got := parseErrors[0]
+			if got.File != "test.jff" || got.Line != tt.expected.Line || got.Column != tt.expected.Column || got.Char != tt.expected.Char || got.Message != tt.expected.Message {
+				t.Errorf("Expected error %v, but got %v", tt.expected, got)
+			}
+			if got.Hint == "" {
+				t.Errorf("Expected the error to have a hint")
+			}
+		})
+	}
+}
+
+func TestExportJFLAP(t *testing.T) {
+	grammars, err := ParseGrammars("test.txt", []string{
+		"%start expr",
+		"A -> a",
+		"expr -> {expr}+{term}|{A}|ε",
+		"term -> <{S}>",
+		"S -> {A}",
+	})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+
+	expected := `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<structure>
+	<type>grammar</type>
+	<production>
+		<left>S</left>
+		<right>S+B</right>
+	</production>
+	<production>
+		<left>S</left>
+		<right>A</right>
+	</production>
+	<production>
+		<left>S</left>
+		<right/>
+	</production>
+	<production>
+		<left>A</left>
+		<right>a</right>
+	</production>
+	<production>
+		<left>B</left>
+		<right>&lt;C&gt;</right>
+	</production>
+	<production>
+		<left>C</left>
+		<right>A</right>
+	</production>
+</structure>
+`
+	got, err := ExportJFLAP(grammars[0])
+	if err != nil {
+		t.Fatalf("Expected no errors, but got %v", err)
+	}
+	if got != expected {
+		t.Errorf("Expected %q,\n but got %q", expected, got)
+	}
+
+	// Reading the file again gives the same grammar with the JFLAP names
+	reread, err := ParseJFLAP("test.jff", strings.Split(got, "\n"))
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+	expectedGrammar := "{S_0} -> {S_0}+{B_0}|{A_0}|ε\n{B_0} -> <{C_0}>\n{A_0} -> a\n{C_0} -> {A_0}\n"
+	if got := reread[0].String(false); got != expectedGrammar {
+		t.Errorf("Expected %q,\n but got %q", expectedGrammar, got)
+	}
+}
+
+func TestExportJFLAPErrors(t *testing.T) {
+	manyNonTerminals := make([]string, 0, 27)
+	for i := 0; i < 27; i++ {
+		manyNonTerminals = append(manyNonTerminals, fmt.Sprintf("N%d -> {N%d}", i, i+1))
+	}
+
+	tests := map[string][]string{
+		"uppercase terminal":    {"S -> aB"},
+		"too many nonterminals": manyNonTerminals,
+	}
+	for name, lines := range tests {
+		grammars, err := ParseGrammars("test.txt", lines)
+		if err != nil {
+			t.Fatalf("%s: expected no errors, but got:\n%v", name, err)
+		}
+		if _, err := ExportJFLAP(grammars[0]); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	// Tokens imported from ANTLR have more than one character
+	if _, err := ExportJFLAP(exportTestGrammar(t)); err == nil {
+		t.Errorf("Expected an error for the terminal ID")
+	}
+}
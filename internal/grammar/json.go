@@ -0,0 +1,131 @@
+package grammar
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+/*
+Codificación JSON de las gramáticas y de los resultados, para que otros
+programas puedan consumir la salida sin leer el texto de la consola.
+
+Un símbolo es {"terminal": bool, "value": string, "id": int}. Una gramática es:
+
+	{
+	  "start": {"terminal": false, "value": "S", "id": 0},
+	  "terminals": [{"terminal": true, "value": "a", "id": 0}],
+	  "nonTerminals": [{"terminal": false, "value": "S", "id": 0}],
+	  "productions": [
+	    {"head": {"terminal": false, "value": "S", "id": 0},
+	     "bodies": [[{"terminal": true, "value": "a", "id": 0}], [{"terminal": true, "value": "ε", "id": 0}]]}
+	  ]
+	}
+
+Las producciones siguen el orden de nonTerminals, y los cuerpos el orden de
+la gramática. La cadena vacía es el terminal ε con id 0, y el caracter ε
+escrito como \ε es el terminal ε con id 1. Los resultados de la simplificación
+(Simplification) y de CYK (CYKResult, ParseTree) usan las etiquetas json de
+sus campos.
+*/
+
+type grammarJSON struct {
+	Start        Symbol           `json:"start"`
+	Terminals    []Symbol         `json:"terminals"`
+	NonTerminals []Symbol         `json:"nonTerminals"`
+	Productions  []productionJSON `json:"productions"`
+}
+
+type productionJSON struct {
+	Head   Symbol     `json:"head"`
+	Bodies [][]Symbol `json:"bodies"`
+}
+
+// Encodes the grammar with the documented JSON shape. The terminals are
+// taken from the productions, so they are right even if the cached list is
+// outdated.
+func (g *Grammar) MarshalJSON() ([]byte, error) {
+	encoded := grammarJSON{
+		Start:        g.GetStartSymbol(),
+		Terminals:    []Symbol{},
+		NonTerminals: append([]Symbol{}, g.NonTerminals...),
+		Productions:  []productionJSON{},
+	}
+
+	for _, head := range productionHeads(g) {
+		production := productionJSON{Head: head, Bodies: make([][]Symbol, 0, len(g.Productions[head]))}
+		for _, body := range g.Productions[head] {
+			// A nil body would be encoded as null instead of []
+			production.Bodies = append(production.Bodies, append([]Symbol{}, body...))
+			for _, symbol := range body {
+				if symbol.IsTerminal && symbol != EpsilonSymbol && !containsSymbol(encoded.Terminals, symbol) {
+					encoded.Terminals = append(encoded.Terminals, symbol)
+				}
+			}
+		}
+		encoded.Productions = append(encoded.Productions, production)
+	}
+
+	return json.Marshal(encoded)
+}
+
+// Decodes a grammar with the documented JSON shape, checking that heads are
+// NON terminals, that no head is repeated and that the start symbol is a
+// NON terminal. Like in MarshalJSON, the terminals are taken from the
+// productions and the list of the JSON is ignored.
+func (g *Grammar) UnmarshalJSON(data []byte) error {
+	var decoded grammarJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	result := Grammar{
+		NonTerminals: decoded.NonTerminals,
+		StartSymbol:  decoded.Start,
+		Productions:  make(map[Symbol][][]Symbol),
+	}
+	if decoded.Start.IsTerminal {
+		return fmt.Errorf("invalid grammar: the start symbol %q is a terminal", decoded.Start.Value)
+	}
+	for _, production := range decoded.Productions {
+		head := production.Head
+		if head.IsTerminal {
+			return fmt.Errorf("invalid grammar: the production head %q is a terminal", head.Value)
+		}
+		if _, exist := result.Productions[head]; exist {
+			return fmt.Errorf("invalid grammar: the productions of %s are repeated", head.String())
+		}
+		if !containsSymbol(result.NonTerminals, head) {
+			result.NonTerminals = append(result.NonTerminals, head)
+		}
+		result.Productions[head] = production.Bodies
+	}
+	// Una lista distinta a la de las producciones confundiría a la forma
+	// normal de Chomsky, que sustituye cada terminal de la lista
+	result.RecalculateTerminals()
+
+	*g = result
+	return nil
+}
+
+// Returns the heads of the productions in the order of the NON terminals,
+// followed by the heads that are not in that list, sorted by name.
+func productionHeads(g *Grammar) []Symbol {
+	heads := make([]Symbol, 0, len(g.Productions))
+	for _, nonTerminal := range g.NonTerminals {
+		if _, exist := g.Productions[nonTerminal]; exist && !containsSymbol(heads, nonTerminal) {
+			heads = append(heads, nonTerminal)
+		}
+	}
+
+	missing := make([]Symbol, 0)
+	for head := range g.Productions {
+		if !containsSymbol(heads, head) {
+			missing = append(missing, head)
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool {
+		return missing[i].String() < missing[j].String()
+	})
+	return append(heads, missing...)
+}
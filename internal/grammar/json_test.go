@@ -0,0 +1,190 @@
+package grammar
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGrammarMarshalJSON(t *testing.T) {
+	grammars, err := ParseGrammars("test.txt", []string{"%start B", "A -> a|ε", "B -> {A}\\ε"})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+
+	data, err := json.Marshal(grammars[0])
+	if err != nil {
+		t.Fatalf("Expected no errors, but got %v", err)
+	}
+
+	expected := `{"start":{"terminal":false,"value":"B","id":0},` +
+		`"terminals":[{"terminal":true,"value":"a","id":0},{"terminal":true,"value":"ε","id":1}],` +
+		`"nonTerminals":[{"terminal":false,"value":"A","id":0},{"terminal":false,"value":"B","id":0}],` +
+		`"productions":[` +
+		`{"head":{"terminal":false,"value":"A","id":0},"bodies":[[{"terminal":true,"value":"a","id":0}],[{"terminal":true,"value":"ε","id":0}]]},` +
+		`{"head":{"terminal":false,"value":"B","id":0},"bodies":[[{"terminal":false,"value":"A","id":0},{"terminal":true,"value":"ε","id":1}]]}]}`
+	if string(data) != expected {
+		t.Errorf("Expected %s,\n but got %s", expected, data)
+	}
+}
+
+func TestGrammarJSONRoundTrip(t *testing.T) {
+	for name, g := range nativeRoundTripGrammars(t) {
+		data, err := json.Marshal(g)
+		if err != nil {
+			t.Fatalf("%s: expected no errors, but got %v", name, err)
+		}
+
+		var decoded Grammar
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("%s: expected no errors, but got %v", name, err)
+		}
+		if start := g.GetStartSymbol(); decoded.GetStartSymbol() != start {
+			t.Errorf("%s: expected start symbol %s, but got %v", name, start.String(), decoded.GetStartSymbol())
+		}
+		// The terminals are taken from the productions, not from the cached list
+		if decoded.String(false) != g.String(false) || !reflect.DeepEqual(decoded.NonTerminals, g.NonTerminals) {
+			t.Errorf("%s: expected %q,\n but got %q", name, g.String(true), decoded.String(true))
+		}
+		for head, bodies := range g.Productions {
+			// Compared without sorting them in place, bodies may be shared between heads
+			decodedBodies := decoded.Productions[head]
+			if fmt.Sprint(bodies) != fmt.Sprint(decodedBodies) {
+				t.Errorf("%s: expected bodies %v for %s, but got %v", name, bodies, head.String(), decodedBodies)
+			}
+		}
+	}
+}
+
+func TestGrammarUnmarshalJSONTerminals(t *testing.T) {
+	S := `{"terminal":false,"value":"S","id":0}`
+	a := `{"terminal":true,"value":"a","id":0}`
+	b := `{"terminal":true,"value":"b","id":0}`
+	productions := `"productions":[{"head":` + S + `,"bodies":[[` + a + `,` + b + `]]}]`
+
+	// Una lista vacía o desactualizada no cambia los terminales de las producciones
+	for _, terminals := range []string{`[]`, `[` + b + `]`, `[` + b + `,` + a + `,` + b + `]`} {
+		var g Grammar
+		if err := json.Unmarshal([]byte(`{"start":`+S+`,"terminals":`+terminals+`,`+productions+`}`), &g); err != nil {
+			t.Fatalf("Expected no errors, but got %v", err)
+		}
+		expected := []Symbol{{Value: "a", IsTerminal: true}, {Value: "b", IsTerminal: true}}
+		if !reflect.DeepEqual(g.terminals, expected) {
+			t.Errorf("%s: expected the terminals %v, but got %v", terminals, expected, g.terminals)
+		}
+		if cnf := CNFPipeline().Run(&g, false, nil); !CYKParse(cnf, "ab", cnf.GetStartSymbol()) {
+			t.Errorf("%s: expected ab to be accepted by:\n%s", terminals, cnf.String(false))
+		}
+	}
+}
+
+func TestGrammarUnmarshalJSONErrors(t *testing.T) {
+	S := `{"terminal":false,"value":"S","id":0}`
+	a := `{"terminal":true,"value":"a","id":0}`
+	tests := map[string]string{
+		"terminal start": `{"start":` + a + `,"productions":[]}`,
+		"terminal head":  `{"start":` + S + `,"productions":[{"head":` + a + `,"bodies":[]}]}`,
+		"repeated head":  `{"start":` + S + `,"productions":[{"head":` + S + `,"bodies":[]},{"head":` + S + `,"bodies":[]}]}`,
+		"malformed":      `{"start":`,
+	}
+	for name, data := range tests {
+		var g Grammar
+		if err := json.Unmarshal([]byte(data), &g); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestSimplificationJSON(t *testing.T) {
+	grammars, err := ParseGrammars("test.txt", []string{"S -> {S}a{S}b|ε"})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+	original := grammars[0].String(true)
+
+	result := SimplifyGrammarResult(grammars[0], false)
+
+	names := make([]string, 0, len(result.Stages))
+	for _, stage := range result.Stages {
+		names = append(names, stage.Name)
+	}
+	expectedNames := []string{
+		StageOriginal, StageLeftFactored, StageLeftRecursionFree, StageNullablesReplaced, StageEpsilonFree,
+		StageUnitFree, StageUseful, StageCNFStartSymbol, StageCNFTerminals, StageChomskyNormalForm,
+	}
+	if !reflect.DeepEqual(names, expectedNames) {
+		t.Fatalf("Expected stages %v, but got %v", expectedNames, names)
+	}
+	// The stages are copies, the later ones do not change them
+	if got := result.Stages[0].Grammar.String(true); got != original {
+		t.Errorf("Expected the original stage to be %q, but got %q", original, got)
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("Expected no errors, but got %v", err)
+	}
+	var decoded Simplification
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Expected no errors, but got %v", err)
+	}
+	// Los terminales decodificados son los de las producciones
+	expected := result.Grammar.Clone()
+	expected.RecalculateTerminals()
+	if got := decoded.Grammar.String(true); got != expected.String(true) {
+		t.Errorf("Expected %q,\n but got %q", expected.String(true), got)
+	}
+	if len(decoded.Stages) != len(result.Stages) || decoded.Stages[3].Name != StageNullablesReplaced {
+		t.Errorf("Expected the stages to be decoded, but got %v", decoded.Stages)
+	}
+}
+
+func TestCYKParseResult(t *testing.T) {
+	result := CYKParseResult(testGrammar, "baaba", SCYK)
+	if !result.Accepted || result.Tree == nil {
+		t.Fatalf("Expected baaba to be accepted with a tree, but got %+v", result)
+	}
+	if result.Tree.Symbol != SCYK || result.Tree.Yield() != "baaba" {
+		t.Errorf("Expected a tree from S that derives baaba, but got one from %s that derives %q", result.Tree.Symbol.String(), result.Tree.Yield())
+	}
+	if len(result.Table) != 5 || !containsSymbol(result.Table[4][0], SCYK) {
+		t.Errorf("Expected S in the last cell of the table, but got %v", result.Table)
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("Expected no errors, but got %v", err)
+	}
+	var decoded CYKResult
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Expected no errors, but got %v", err)
+	}
+	if !reflect.DeepEqual(decoded, result) {
+		t.Errorf("Expected %+v,\n but got %+v", result, decoded)
+	}
+
+	rejected := CYKParseResult(testGrammar, "bb", SCYK)
+	if rejected.Accepted || rejected.Tree != nil {
+		t.Errorf("Expected bb to be rejected without a tree, but got %+v", rejected)
+	}
+	if data, _ := json.Marshal(rejected); strings.Contains(string(data), "tree") {
+		t.Errorf("Expected no tree in %s", data)
+	}
+}
+
+func TestCYKParseResultEmptyString(t *testing.T) {
+	g := &Grammar{Productions: make(map[Symbol][][]Symbol)}
+	g.AddProductionFromString("S -> a{S}b|ε")
+	simplified := SimplifyGrammar(g, false)
+	start := simplified.GetStartSymbol()
+
+	result := CYKParseResult(simplified, "", start)
+	if !result.Accepted || result.Tree == nil || result.Tree.Children[0].Symbol != EpsilonSymbol {
+		t.Errorf("Expected the empty string to be derived with ε, but got %+v", result)
+	}
+	if tree := CYKParseResult(simplified, "aabb", start).Tree; tree == nil || tree.Yield() != "aabb" {
+		t.Errorf("Expected a tree that derives aabb, but got %+v", tree)
+	}
+}
@@ -0,0 +1,227 @@
+package grammar
+
+import (
+	"sort"
+	"strings"
+)
+
+/*
+Verificación de que las pasadas conservan el lenguaje. Se enumeran todas las
+cadenas de largo 0 a N sobre los terminales de la gramática original y se
+revisa con Earley si cada etapa las acepta, igual que la gramática original:
+
+	check := VerifyLanguage(g, SimplifyPipeline(), 5)
+	if check.Change != nil {
+		fmt.Println(check.Change.Pass, check.Change.Input)
+	}
+
+La pasada epsilon quita la cadena vacía del lenguaje a propósito, y las
+pasadas chomsky y greibach la devuelven con S' -> ε. Por eso la cadena vacía
+no se compara en las etapas que hay entre ellas.
+*/
+
+// Most strings that VerifyStages checks in every stage. With many terminals
+// the number of strings grows fast, |T|^N, so a longer maxLength is lowered
+// until the strings fit.
+const MaxVerifiedStrings = 100000
+
+// Result of checking that a pipeline keeps the language of a grammar.
+type LanguageCheck struct {
+	MaxLength       int             `json:"maxLength"`                 // Longest string checked.
+	RequestedLength int             `json:"requestedLength,omitempty"` // Longest string asked for, when it was lowered to MaxLength.
+	Strings         int             `json:"strings"`                   // Strings checked in every stage.
+	Stages          []string        `json:"stages"`                    // Stages checked, in order.
+	Change          *LanguageChange `json:"change,omitempty"`
+}
+
+// A string accepted by the original grammar and rejected by a stage, or
+// the other way around.
+type LanguageChange struct {
+	Input    string   `json:"input"`    // The string, with its terminals joined.
+	Symbols  []Symbol `json:"symbols"`  // Terminals of the string.
+	Pass     string   `json:"pass"`     // Pass that reached the stage.
+	Stage    string   `json:"stage"`    // First stage that changed the membership of the string.
+	Previous string   `json:"previous"` // Stage before it, that still agreed with the original grammar.
+	Accepted bool     `json:"accepted"` // True if the original grammar accepts the string.
+}
+
+// Returns: true if every stage kept the language.
+func (c LanguageCheck) Preserved() bool {
+	return c.Change == nil
+}
+
+// Applies the pipeline to the grammar and checks that every stage accepts
+// the same strings of up to maxLength terminals as the original grammar.
+//
+// Returns: the result of the check, with the first string that changed.
+func VerifyLanguage(g *Grammar, pipeline *Pipeline, maxLength int) LanguageCheck {
+	return VerifyStages(pipeline.RunResult(g, false).Stages, maxLength)
+}
+
+// Checks that every stage of a simplification accepts the same strings of
+// up to maxLength terminals as the first stage, the original grammar. The
+// stages are checked in order, so the change found is in the first stage
+// that changed the language, and it is the shortest string that changed
+// there. At most MaxVerifiedStrings strings are checked: if there are more,
+// maxLength is lowered and the one asked for is kept in RequestedLength.
+//
+// Returns: the result of the check, with the first string that changed.
+func VerifyStages(stages []SimplificationStage, maxLength int) LanguageCheck {
+	check := LanguageCheck{MaxLength: maxLength, Stages: make([]string, 0, len(stages))}
+	for _, stage := range stages {
+		check.Stages = append(check.Stages, stage.Name)
+	}
+	if len(stages) == 0 {
+		return check
+	}
+
+	terminals := languageTerminals(stages[0].Grammar)
+	for maxLength > 0 && countStrings(len(terminals), maxLength) > MaxVerifiedStrings {
+		maxLength--
+	}
+	if maxLength != check.MaxLength {
+		check.RequestedLength = check.MaxLength
+		check.MaxLength = maxLength
+	}
+	inputs := func(visit func(input []Symbol) bool) {
+		enumerateStrings(terminals, maxLength, visit)
+	}
+	check.Change, check.Strings = firstLanguageChange(stages, inputs)
+	return check
+}
+
+// Checks every string given by inputs in every stage, in order. The
+// strings are generated again for each stage instead of being kept, only
+// whether the first stage accepts them is kept.
+//
+// Returns: the first string whose membership changed in the first stage
+// that changed one, or nil if every stage agrees with the first one, and the
+// number of strings.
+func firstLanguageChange(stages []SimplificationStage, inputs func(visit func(input []Symbol) bool)) (*LanguageChange, int) {
+	if len(stages) == 0 {
+		return nil, 0
+	}
+
+	original := newEarleyRecognizer(stages[0].Grammar, stages[0].Grammar.GetStartSymbol())
+	accepted := make([]bool, 0)
+	inputs(func(input []Symbol) bool {
+		accepted = append(accepted, original.recognize(input))
+		return true
+	})
+
+	withoutEmpty := false
+	for index, stage := range stages[1:] {
+		// Entre la pasada epsilon y las pasadas chomsky o greibach el lenguaje no tiene ε
+		switch stage.Name {
+		case StageEpsilonFree:
+			withoutEmpty = true
+		case StageCNFStartSymbol, StageGreibachNormalForm:
+			withoutEmpty = false
+		}
+
+		recognizer := newEarleyRecognizer(stage.Grammar, stage.Grammar.GetStartSymbol())
+		var change *LanguageChange
+		i := 0
+		inputs(func(input []Symbol) bool {
+			defer func() { i++ }()
+			if len(input) == 0 && withoutEmpty {
+				return true
+			}
+			if recognizer.recognize(input) == accepted[i] {
+				return true
+			}
+			change = &LanguageChange{
+				Input:    joinSymbols(input),
+				Symbols:  input,
+				Pass:     stage.Pass,
+				Stage:    stage.Name,
+				Previous: stages[index].Name,
+				Accepted: accepted[i],
+			}
+			return false
+		})
+		if change != nil {
+			return change, len(accepted)
+		}
+	}
+	return nil, len(accepted)
+}
+
+// Returns: the terminals used in the bodies of the grammar, without ε and
+// sorted by value.
+func languageTerminals(g *Grammar) []Symbol {
+	values := make(map[string]bool)
+	for _, bodies := range g.Productions {
+		for _, body := range bodies {
+			for _, symbol := range body {
+				if symbol.IsTerminal && symbol != EpsilonSymbol {
+					values[symbol.Value] = true
+				}
+			}
+		}
+	}
+
+	terminals := make([]Symbol, 0, len(values))
+	for value := range values {
+		terminals = append(terminals, Symbol{IsTerminal: true, Value: value})
+	}
+	sort.Slice(terminals, func(i, j int) bool { return terminals[i].Value < terminals[j].Value })
+	return terminals
+}
+
+// Returns: the number of strings of up to maxLength terminals, or
+// MaxVerifiedStrings + 1 if there are more than MaxVerifiedStrings.
+func countStrings(terminals int, maxLength int) int {
+	count, power := 1, 1
+	for length := 1; length <= maxLength; length++ {
+		power *= terminals
+		count += power
+		if power > MaxVerifiedStrings || count > MaxVerifiedStrings {
+			return MaxVerifiedStrings + 1
+		}
+	}
+	return count
+}
+
+// Calls visit with every string of up to maxLength terminals, the shorter
+// ones first, until visit returns false.
+func enumerateStrings(terminals []Symbol, maxLength int, visit func(input []Symbol) bool) {
+	for length := 0; length <= maxLength; length++ {
+		if length > 0 && len(terminals) == 0 {
+			return
+		}
+		// Los índices de los terminales cuentan como un número en base len(terminals)
+		indexes := make([]int, length)
+		input := make([]Symbol, length)
+		for {
+			for i, index := range indexes {
+				input[i] = terminals[index]
+			}
+			if !visit(append([]Symbol{}, input...)) {
+				return
+			}
+
+			position := length - 1
+			for position >= 0 && indexes[position] == len(terminals)-1 {
+				indexes[position] = 0
+				position--
+			}
+			if position < 0 {
+				break
+			}
+			indexes[position]++
+		}
+	}
+}
+
+// Returns: the values of the symbols joined, ε for the empty string.
+func joinSymbols(symbols []Symbol) string {
+	if len(symbols) == 0 {
+		return Epsilon
+	}
+	var sb strings.Builder
+	for _, symbol := range symbols {
+		sb.WriteString(symbol.Value)
+	}
+	return sb.String()
+}
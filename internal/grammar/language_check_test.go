@@ -0,0 +1,116 @@
+package grammar
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEnumerateStrings(t *testing.T) {
+	a := Symbol{IsTerminal: true, Value: "a"}
+	b := Symbol{IsTerminal: true, Value: "b"}
+
+	got := []string{}
+	enumerateStrings([]Symbol{a, b}, 2, func(input []Symbol) bool {
+		got = append(got, joinSymbols(input))
+		return true
+	})
+	expected := []string{"ε", "a", "b", "aa", "ab", "ba", "bb"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, but got %v", expected, got)
+	}
+
+	count := 0
+	enumerateStrings(nil, 3, func(input []Symbol) bool {
+		count++
+		return true
+	})
+	if count != 1 {
+		t.Errorf("Expected only the empty string without terminals, but got %d strings", count)
+	}
+}
+
+func TestVerifyLanguage(t *testing.T) {
+	sources := [][]string{
+		{"S -> a{S}b|ε"},
+		{"A -> {A}t{B}|{B}", "B -> int|l{A}l"},
+		{"S -> {S}m|{A}", "A -> {A}m|{B}", "B -> {B}m|{C}", "C -> a"},
+		{"A -> {B}{A}|{C}m|aa", "B -> {C}", "C -> {A}"},
+		{"E -> {E}+{T}|{T}", "T -> {T}*{F}|{F}", "F -> ({E})|x"},
+		{"S -> {A}a|b", "A -> {S}c|d"},
+		{"expr -> {expr}+{term}|{term}", "term -> {term_2}{term}|x", "term_2 -> y"},
+	}
+	for _, source := range sources {
+		grammars, err := ParseGrammars("test.txt", source)
+		if err != nil {
+			t.Fatalf("Expected no errors, but got:\n%v", err)
+		}
+		for _, pipeline := range []*Pipeline{SimplifyPipeline(), CNFPipeline(), LL1PrepPipeline(), CleanPipeline()} {
+			check := VerifyLanguage(grammars[0], pipeline, 4)
+			if !check.Preserved() {
+				t.Errorf("%v with %v: expected the same language, but %+v changed", source, pipeline.Names(), *check.Change)
+			}
+			if check.Strings == 0 || len(check.Stages) != len(pipeline.RunResult(grammars[0], false).Stages) {
+				t.Errorf("%v with %v: expected every stage to be checked, but got %+v", source, pipeline.Names(), check)
+			}
+		}
+	}
+}
+
+func TestVerifyLanguageLimitsStrings(t *testing.T) {
+	// 20 terminales: 20^4 cadenas de largo 4 ya son más que MaxVerifiedStrings
+	g := parseTestGrammar(t, "S -> a{S}|b{S}|c{S}|d{S}|e{S}|f{S}|g{S}|h{S}|i{S}|j{S}|k{S}|l{S}|m{S}|n{S}|o{S}|p{S}|q{S}|r{S}|s{S}|t", "T -> {T}t|t")
+	check := VerifyLanguage(g, CleanPipeline(), 6)
+	if !check.Preserved() {
+		t.Errorf("Expected the same language, but %+v changed", *check.Change)
+	}
+	if check.MaxLength != 3 || check.RequestedLength != 6 {
+		t.Errorf("Expected strings of up to 3 terminals instead of 6, but got %d instead of %d", check.MaxLength, check.RequestedLength)
+	}
+	if check.Strings != 1+20+400+8000 {
+		t.Errorf("Expected %d strings, but got %d", 1+20+400+8000, check.Strings)
+	}
+
+	if check := VerifyLanguage(g, CleanPipeline(), 2); check.MaxLength != 2 || check.RequestedLength != 0 {
+		t.Errorf("Expected strings of up to 2 terminals, but got %+v", check)
+	}
+}
+
+func TestVerifyLanguageFindsChange(t *testing.T) {
+	grammars, err := ParseGrammars("test.txt", []string{"S -> a{S}b|ε"})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+
+	// Una pasada que pierde la última producción de cada no terminal
+	broken := NewPass("broken", func(g *Grammar) *Grammar {
+		result := g.Clone()
+		for head, bodies := range result.Productions {
+			result.Productions[head] = bodies[:len(bodies)-1]
+		}
+		return result
+	})
+	check := VerifyLanguage(grammars[0], CNFPipeline().Add(broken), 4)
+	if check.Preserved() {
+		t.Fatalf("Expected the broken pass to change the language")
+	}
+
+	change := *check.Change
+	// La gramática en CNF acepta ε con S' -> ε, que es el último cuerpo de S'
+	expected := LanguageChange{Input: "ε", Symbols: []Symbol{}, Pass: "broken", Stage: "broken", Previous: StageChomskyNormalForm, Accepted: true}
+	if !reflect.DeepEqual(change, expected) {
+		t.Errorf("Expected %+v, but got %+v", expected, change)
+	}
+}
+
+func TestVerifyLanguageSkipsEmptyStringWithoutChomsky(t *testing.T) {
+	grammars, err := ParseGrammars("test.txt", []string{"S -> a{S}b|ε"})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+
+	// La pasada epsilon quita ε del lenguaje a propósito
+	check := VerifyLanguage(grammars[0], NewPipeline(EpsilonPass(), UnitPass()), 4)
+	if !check.Preserved() {
+		t.Errorf("Expected the same language without ε, but %+v changed", *check.Change)
+	}
+}
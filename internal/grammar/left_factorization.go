@@ -9,7 +9,9 @@ type leftFactorPrefix struct {
 	count  int
 }
 
-func factorizeGrammar(grammar *Grammar) *Grammar {
+// Factors every production of the grammar by the left.
+func factorizeGrammar(originalGrammar *Grammar) *Grammar {
+	grammar := originalGrammar.Clone()
 
 	for _, nonTerminal := range grammar.NonTerminals {
 		if productions, exist := grammar.Productions[nonTerminal]; exist {
@@ -68,6 +70,7 @@ func findLongestCommonPrefix(bodies [][]Symbol) ([]Symbol, [][]Symbol, [][]Symbo
 
 	// This will map each potential prefix to its occurrence count
 	prefixCountMap := make(map[string]leftFactorPrefix)
+	prefixOrder := make([]string, 0) // Prefixes in the order they were found, so ties are always broken the same way
 
 	// Check each body against all others to find common prefixes
 	for i := 0; i < len(bodies); i++ {
@@ -91,6 +94,7 @@ func findLongestCommonPrefix(bodies [][]Symbol) ([]Symbol, [][]Symbol, [][]Symbo
 					prefixCountMap[prefixKey] = entry
 					// else, add the entry
 				} else {
+					prefixOrder = append(prefixOrder, prefixKey)
 					prefixCountMap[prefixKey] = leftFactorPrefix{
 						prefix: prefix,
 						count:  1,
@@ -104,7 +108,8 @@ func findLongestCommonPrefix(bodies [][]Symbol) ([]Symbol, [][]Symbol, [][]Symbo
 	var longestPrefix []Symbol
 	var maxOccurrences int
 
-	for _, value := range prefixCountMap {
+	for _, prefixKey := range prefixOrder {
+		value := prefixCountMap[prefixKey]
 		if value.count > maxOccurrences {
 			longestPrefix = value.prefix // Convert the string back to symbols
 			maxOccurrences = value.count
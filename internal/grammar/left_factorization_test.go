@@ -128,3 +128,19 @@ func TestFactorizeGrammar(t *testing.T) {
 	// {A_2} -> x|b
 	fmt.Println(a.String(true))
 }
+
+func TestFactorizeGrammarKeepsInput(t *testing.T) {
+	grammar := &Grammar{Productions: make(map[Symbol][][]Symbol)}
+	grammar.AddProductionFromString("A -> {B}x|jk|{B}b|jl")
+	grammar.AddProductionFromString("B -> mm|mb|m")
+	before := grammar.String(true)
+
+	factorized := factorizeGrammar(grammar)
+
+	if grammar.String(true) != before {
+		t.Errorf("Expected the input to stay %q, but got %q", before, grammar.String(true))
+	}
+	if factorized.String(true) == before {
+		t.Errorf("Expected the factorized grammar to change, but got %q", factorized.String(true))
+	}
+}
@@ -2,147 +2,378 @@ package grammar
 
 import (
 	"fmt"
-	"sync"
+	"strings"
 )
 
-func removeLeftRecursivity(originalGrammar *Grammar) *Grammar {
-	// Create a new grammar
-	newGrammar := Grammar{
-		Productions:  make(map[Symbol][][]Symbol),
-		NonTerminals: make([]Symbol, 0),
-		terminals:    make([]Symbol, 0),
+/*
+Eliminación de la recursión por la izquierda con el algoritmo de Paull:
+
+ 1. Se ordenan los no terminales A1, ..., An, con el símbolo inicial primero.
+ 2. Para cada Ai, los cuerpos Ai -> Aj γ con j < i se sustituyen por los
+    cuerpos de Aj, siempre que Aj pueda llegar a Ai por la izquierda. Así la
+    recursión indirecta, como A -> {B}a y B -> {A}b, pasa a ser directa.
+ 3. Se quita la recursión directa de Ai con un nuevo no terminal:
+    Ai -> β{Ai'} y Ai' -> α{Ai'}|ε.
+
+La recursión puede estar escondida detrás de símbolos anulables, como en
+S -> {N}{S}a con N -> ε. Si después de los pasos anteriores la gramática sigue
+siendo recursiva, se repiten sobre una copia sin producciones ε, y si el
+símbolo inicial era anulable se agrega S' -> ε como en la forma normal de
+Chomsky. Se queda el intento con menos no terminales recursivos.
+
+El algoritmo no sirve con ciclos, A =>+ A, porque no hay un cuerpo β con el
+que empezar. Los cuerpos A -> A no cambian el lenguaje y se quitan; los no
+terminales que siguen en un ciclo se dejan igual y el resto se arregla. Se
+reportan los ciclos y los no terminales que quedan recursivos, las pasadas
+epsilon y unit quitan los ciclos.
+*/
+
+// Error of RemoveLeftRecursion when the left recursion of some NON terminals
+// can not be removed because of the cycles of the grammar, NON terminals that
+// derive themselves, A =>+ A.
+type LeftRecursionCycleError struct {
+	// Cada ciclo empieza y termina con el mismo no terminal
+	Cycles [][]Symbol
+	// Los no terminales que siguen siendo recursivos por la izquierda, en el
+	// orden de los no terminales
+	Heads []Symbol
+}
+
+func (e *LeftRecursionCycleError) Error() string {
+	cycles := make([]string, 0, len(e.Cycles))
+	for _, cycle := range e.Cycles {
+		cycles = append(cycles, strings.Join(symbolsToStrings(cycle), " => "))
 	}
-	// Deep copy nonTerminals and Terminals
-	// copy(newGrammar.nonTerminals, originalGrammar.nonTerminals)
-	copy(newGrammar.terminals, originalGrammar.terminals)
+	heads := strings.Join(symbolsToStrings(e.Heads), ", ")
+	return fmt.Sprintf("the left recursion of %s can not be removed because of the cycles: %s", heads, strings.Join(cycles, ", "))
+}
 
-	for _, head := range originalGrammar.NonTerminals {
-		if _, exist := originalGrammar.Productions[head]; !exist {
-			continue
-		}
+// Removes the direct, indirect and hidden left recursion of the grammar with
+// the algorithm of Paull. The NON terminals that are part of a cycle are kept.
+//
+// Returns: a new grammar without left recursion, or a grammar with the left
+// recursion removed outside the cycles and a *LeftRecursionCycleError.
+func RemoveLeftRecursion(originalGrammar *Grammar) (*Grammar, error) {
+	grammar := removeTrivialBodies(originalGrammar)
+	result := paullRemoveLeftRecursion(grammar, epsilonDirectRecursionRemoval, cyclicHeads(grammar))
+	stuck := leftRecursiveHeads(result)
+	if len(stuck) == 0 {
+		return result, nil
+	}
 
-		fmt.Println(newGrammar.String(true))
+	// La recursión puede estar escondida detrás de símbolos anulables, y sin
+	// ellos algunos ciclos, como S -> {S}{N}, desaparecen
+	start := grammar.GetStartSymbol()
+	startNullable := nullableSymbols(grammar)[start]
+	withoutEpsilons := removeTrivialBodies(removeNullableSymbols(grammar))
+	if startNullable {
+		withoutEpsilons = CNFAddStartSymbol(withoutEpsilons, true)
+	}
+	retry := paullRemoveLeftRecursion(withoutEpsilons, epsilonDirectRecursionRemoval, cyclicHeads(withoutEpsilons))
+	if retryStuck := leftRecursiveHeads(retry); len(retryStuck) < len(stuck) {
+		result, stuck = retry, retryStuck
+	}
+	if len(stuck) == 0 {
+		return result, nil
+	}
 
-		AllBodyVariants := findAllBodyVariants(&head, originalGrammar)
+	cycles := grammarCycles(result)
+	if len(cycles) == 0 {
+		return result, fmt.Errorf("the left recursion could not be removed: %s", leftRecursionViolations(result)[0])
+	}
+	return result, &LeftRecursionCycleError{Cycles: cycles, Heads: stuck}
+}
+
+// Removes the direct left recursion of a head, A -> Aα|β. It receives the
+// bodies α, the bodies β and the new NON terminal A'.
+//
+// Returns: the new bodies of A and the bodies of A'.
+type directRecursionRemoval func(alphas [][]Symbol, betas [][]Symbol, newHead Symbol) ([][]Symbol, [][]Symbol)
 
-		// Create the two new productions
-		recursiveBodies := make([][]Symbol, 0)    // List of bodies that has left recursivity
-		nonRecursiveBodies := make([][]Symbol, 0) // List of bodies that DO Not have recursivityh
+// Orders the NON terminals, with the start symbol first, substitutes the
+// leading NON terminals that come before in the order and can reach the head
+// by the left, and removes the direct left recursion of every head with
+// removeDirect. The heads in skip, which are part of a cycle, are kept.
+//
+// Returns: a new grammar, without left recursion if it was not hidden
+// behind nullable symbols or cycles.
+func paullRemoveLeftRecursion(originalGrammar *Grammar, removeDirect directRecursionRemoval, skip map[Symbol]bool) *Grammar {
+	grammar := originalGrammar.Clone()
+	order := moveSymbolToFront(productionHeads(grammar), grammar.GetStartSymbol())
 
-		// Separate recursive bodies from non recursive bodies
-		for _, bodyVariant := range AllBodyVariants {
-			if bodyVariant[0] == head {
-				recursiveBodies = append(recursiveBodies, bodyVariant)
-			} else {
-				nonRecursiveBodies = append(nonRecursiveBodies, bodyVariant)
+	for i, head := range order {
+		bodies, exist := grammar.Productions[head]
+		if !exist || skip[head] {
+			continue
+		}
+		// Sustituir solo los no terminales anteriores que llegan a head, los
+		// demás no causan recursión y la gramática no crece sin necesidad. Los
+		// demás no terminales no cambian mientras se sustituye, así que las
+		// esquinas se calculan una vez
+		corners := leftCornerGraph(grammar)
+		for _, previous := range order[:i] {
+			if leftCornerPath(corners, previous, head) != nil {
+				bodies = substituteLeadingSymbol(bodies, previous, grammar.Productions[previous])
+				bodies = removeEpsilonSymbols(bodies)
 			}
 		}
 
-		// If recursive bodies were not found,
+		recursiveBodies, nonRecursiveBodies := separateLeftRecursiveBodies(head, bodies)
 		if len(recursiveBodies) == 0 {
-			newGrammar.AddProduction(head.Value, AllBodyVariants) // A
+			grammar.Productions[head] = bodies
 			continue
 		}
-		// If nonRecursiveBodies is empty, add epsilon
 		if len(nonRecursiveBodies) == 0 {
-			nonRecursiveBodies = append(nonRecursiveBodies, []Symbol{EpsilonSymbol})
+			// Sin cuerpos que no sean recursivos el no terminal no genera nada,
+			// la pasada useless quita los cuerpos que lo usan
+			delete(grammar.Productions, head)
+			continue
 		}
 
-		fmt.Println()
-
-		// If not, solve the recursion
-
-		// FIX RECURSION
-		// Create the productions
-		production1 := newGrammar.AddProduction(head.Value, make([][]Symbol, 0)) // A
-		production2 := newGrammar.AddProduction(head.Value, make([][]Symbol, 0)) // A'
-
-		// Modify bodies to remove recursion
-		var wg sync.WaitGroup
-		wg.Add(2)
-		go fixRecursiveBodies(&wg, &recursiveBodies, production2)
-		go fixNonRecursiveBodies(&wg, &nonRecursiveBodies, production2)
-		wg.Wait()
-		// Add epsilon at the end of recursive bodies, to ensure the form A' -> αA'|...|ε
-		recursiveBodies = append(recursiveBodies, []Symbol{EpsilonSymbol})
-		// Eliminite Duplicates.
-		recursiveBodies = removeDuplicatesSlices(recursiveBodies)
-		nonRecursiveBodies = removeDuplicatesSlices(nonRecursiveBodies)
-		// Add fixed bodies to grammar.
-		newGrammar.AddProductionBodies(*production1, nonRecursiveBodies)
-		newGrammar.AddProductionBodies(*production2, recursiveBodies)
-
+		// A' va después de A
+		newHead := grammar.freshNonTerminal(head.Value)
+		grammar.NonTerminals = insertSymbolAfter(grammar.NonTerminals, head, newHead)
+		alphas := make([][]Symbol, 0, len(recursiveBodies))
+		for _, body := range recursiveBodies {
+			alphas = append(alphas, copySymbols(body[1:]))
+		}
+		headBodies, newBodies := removeDirect(alphas, nonRecursiveBodies, newHead)
+		grammar.Productions[head] = removeDuplicatesSlices(headBodies)
+		grammar.Productions[newHead] = removeDuplicatesSlices(newBodies)
 	}
 
-	return &newGrammar
+	grammar.RecalculateTerminals()
+	return grammar
 }
 
-func fixRecursiveBodies(wg *sync.WaitGroup, bodies *[][]Symbol, secondProduction *Symbol) {
-	defer wg.Done()
-	for i := range *bodies {
-		(*bodies)[i] = (*bodies)[i][1:]                        // Remove the first element that causes the recursivity, to left with the rest: α
-		(*bodies)[i] = append((*bodies)[i], *secondProduction) // Add A' at the end of the recursive body, so that we end up with a body with form: αA'
+// Removes the direct left recursion with ε, A -> β{A'} and A' -> α{A'}|ε.
+//
+// Returns: the new bodies of A and the bodies of A'.
+func epsilonDirectRecursionRemoval(alphas [][]Symbol, betas [][]Symbol, newHead Symbol) ([][]Symbol, [][]Symbol) {
+	headBodies := make([][]Symbol, 0, len(betas))
+	for _, body := range betas {
+		headBodies = append(headBodies, append(copySymbols(body), newHead))
+	}
+	newBodies := make([][]Symbol, 0, len(alphas)+1)
+	for _, body := range alphas {
+		newBodies = append(newBodies, append(copySymbols(body), newHead))
 	}
+	newBodies = append(newBodies, []Symbol{EpsilonSymbol})
+	return removeEpsilonSymbols(headBodies), newBodies
 }
 
-func fixNonRecursiveBodies(wg *sync.WaitGroup, bodies *[][]Symbol, secondProduction *Symbol) {
-	defer wg.Done()
-	for i := range *bodies {
-		(*bodies)[i] = append((*bodies)[i], *secondProduction) // Add A' at the end of the recursive body, so that we end up with a body with form: βA'
+// Separates the bodies of head that start with head, A -> Aα, from the rest.
+//
+// Returns: the left recursive bodies and the other bodies, both in their
+// original order.
+func separateLeftRecursiveBodies(head Symbol, bodies [][]Symbol) ([][]Symbol, [][]Symbol) {
+	recursiveBodies := make([][]Symbol, 0)    // List of bodies that has left recursivity
+	nonRecursiveBodies := make([][]Symbol, 0) // List of bodies that DO Not have recursivityh
+	for _, body := range bodies {
+		if len(body) > 0 && body[0] == head {
+			recursiveBodies = append(recursiveBodies, body)
+		} else {
+			nonRecursiveBodies = append(nonRecursiveBodies, body)
+		}
 	}
+	return recursiveBodies, nonRecursiveBodies
+}
 
+// Returns: the NON terminals that every head derives alone, A => B, because
+// the other symbols of the body are nullable. The cycles of the grammar,
+// A =>+ A, are the cycles of this graph.
+func cycleGraph(g *Grammar) map[Symbol][]Symbol {
+	nullables := nullableSymbols(g)
+	successors := make(map[Symbol][]Symbol)
+	for head, bodies := range g.Productions {
+		for _, body := range bodies {
+			for _, next := range cycleSuccessors(body, nullables) {
+				if !containsSymbol(successors[head], next) {
+					successors[head] = append(successors[head], next)
+				}
+			}
+		}
+	}
+	return successors
 }
 
-// findAllBodyVariants finds all body variants for a given head symbol,
-// including direct and indirect productions.
-func findAllBodyVariants(head *Symbol, grammar *Grammar) [][]Symbol {
-	var result [][]Symbol
-	visited := make(map[Symbol]bool)
-	visited[*head] = true
+// Returns: the NON terminals that are part of a cycle, A =>+ A.
+func cyclicHeads(g *Grammar) map[Symbol]bool {
+	successors := cycleGraph(g)
+	cyclic := make(map[Symbol]bool)
+	for head, nexts := range successors {
+		for _, next := range nexts {
+			if leftCornerPath(successors, next, head) != nil {
+				cyclic[head] = true
+				break
+			}
+		}
+	}
+	return cyclic
+}
 
-	var explore func(searchSymbol *Symbol) [][]Symbol
+// Searches the cycles of the grammar, A =>+ A, through bodies whose other
+// symbols are nullable.
+//
+// Returns: one of the shortest cycles of each group of NON terminals that
+// derive each other, in the order of the NON terminals.
+func grammarCycles(g *Grammar) [][]Symbol {
+	successors := cycleGraph(g)
+	cycles := make([][]Symbol, 0)
+	found := make(map[Symbol]bool)
+	for _, head := range productionHeads(g) {
+		if found[head] {
+			continue
+		}
+		var shortest []Symbol
+		for _, next := range successors[head] {
+			path := leftCornerPath(successors, next, head)
+			if path != nil && (shortest == nil || len(path) < len(shortest)) {
+				shortest = path
+			}
+		}
+		if shortest == nil {
+			continue
+		}
+		cycle := append([]Symbol{head}, shortest...)
+		for _, symbol := range cycle {
+			found[symbol] = true
+		}
+		cycles = append(cycles, cycle)
+	}
+	return cycles
+}
 
-	// Recursive function to expand bodies
-	explore = func(searchSymbol *Symbol) [][]Symbol {
-		var variants [][]Symbol
+// Returns: the NON terminals with a left recursive body, in the order of the
+// NON terminals.
+func leftRecursiveHeads(g *Grammar) []Symbol {
+	heads := make([]Symbol, 0)
+	for _, violation := range leftRecursionViolations(g) {
+		if !containsSymbol(heads, violation.Head) {
+			heads = append(heads, violation.Head)
+		}
+	}
+	return heads
+}
 
-		if searchSymbol.IsTerminal {
-			return nil
+// Removes the bodies A -> A, which derive nothing new. A head with only
+// those bodies generates nothing, so its productions are removed.
+//
+// Returns: a new grammar without bodies equal to their head.
+func removeTrivialBodies(originalGrammar *Grammar) *Grammar {
+	grammar := originalGrammar.Clone()
+	for _, head := range productionHeads(grammar) {
+		bodies := make([][]Symbol, 0, len(grammar.Productions[head]))
+		for _, body := range grammar.Productions[head] {
+			clean := removeEpsilonSymbols([][]Symbol{body})[0]
+			if len(clean) != 1 || clean[0] != head {
+				bodies = append(bodies, body)
+			}
 		}
-		if _, isVisited := visited[*searchSymbol]; isVisited {
-			return nil
+		if len(bodies) == 0 {
+			delete(grammar.Productions, head)
+			continue
 		}
+		grammar.Productions[head] = bodies
+	}
+	grammar.RecalculateTerminals()
+	return grammar
+}
 
-		visited[*searchSymbol] = true
+// Removes every nullable symbol from the bodies in every possible way, like
+// the epsilon pass.
+//
+// Returns: a new grammar without ε productions, whose NON terminals derive
+// the same strings as before except ε.
+func removeNullableSymbols(originalGrammar *Grammar) *Grammar {
+	grammar := originalGrammar.Clone()
+	nullables := nullableSymbols(grammar)
 
-		for _, production := range grammar.Productions[*searchSymbol] {
-			variants = append(variants, production)
-			temp := explore(&production[0])
-			if temp != nil {
-				rest := production[1:]
-				for _, r := range temp {
-					variants = append(variants, append(r, rest...))
+	for head, bodies := range grammar.Productions {
+		newBodies := make([][]Symbol, 0, len(bodies))
+		for _, body := range bodies {
+			variants := [][]Symbol{{}}
+			for _, symbol := range body {
+				if symbol == EpsilonSymbol {
+					continue
+				}
+				next := make([][]Symbol, 0, 2*len(variants))
+				for _, variant := range variants {
+					next = append(next, append(copySymbols(variant), symbol))
+					// Cada símbolo anulable puede no estar
+					if nullables[symbol] {
+						next = append(next, variant)
+					}
+				}
+				variants = next
+			}
+			for _, variant := range variants {
+				if len(variant) > 0 {
+					newBodies = append(newBodies, variant)
 				}
 			}
 		}
 
-		return variants
+		if len(newBodies) == 0 {
+			delete(grammar.Productions, head)
+			continue
+		}
+		grammar.Productions[head] = removeDuplicatesSlices(newBodies)
 	}
 
-	// Expand all bodies for the head symbol
-	for _, body := range grammar.Productions[*head] {
-		first := body[0]
-		variants := explore(&first)
-		variants = append(variants, []Symbol{body[0]})
-		variants = removeDuplicatesSlices(variants)
-		rest := body[1:]
+	grammar.RecalculateTerminals()
+	return grammar
+}
 
-		for _, variant := range variants {
-			// fmt.Println(variant)
-			newBodyVariant := append(variant, rest...)
-			result = append(result, newBodyVariant)
+// Returns: the bodies without ε symbols, and a body with only ε for those
+// that end up empty.
+func removeEpsilonSymbols(bodies [][]Symbol) [][]Symbol {
+	result := make([][]Symbol, 0, len(bodies))
+	for _, body := range bodies {
+		clean := make([]Symbol, 0, len(body))
+		for _, symbol := range body {
+			if symbol != EpsilonSymbol {
+				clean = append(clean, symbol)
+			}
 		}
+		if len(clean) == 0 {
+			clean = append(clean, EpsilonSymbol)
+		}
+		result = append(result, clean)
 	}
+	return result
+}
 
+// Returns: the left corners of every NON terminal of the grammar.
+func leftCornerGraph(g *Grammar) map[Symbol][]Symbol {
+	nullables := nullableSymbols(g)
+	corners := make(map[Symbol][]Symbol)
+	for head, bodies := range g.Productions {
+		for _, body := range bodies {
+			for _, corner := range leftCorners(body, nullables) {
+				if !containsSymbol(corners[head], corner) {
+					corners[head] = append(corners[head], corner)
+				}
+			}
+		}
+	}
+	return corners
+}
+
+// Returns: a copy of symbols with item right after after, or at the end if
+// after is not in symbols.
+func insertSymbolAfter(symbols []Symbol, after Symbol, item Symbol) []Symbol {
+	result := make([]Symbol, 0, len(symbols)+1)
+	inserted := false
+	for _, symbol := range symbols {
+		result = append(result, symbol)
+		if symbol == after && !inserted {
+			result = append(result, item)
+			inserted = true
+		}
+	}
+	if !inserted {
+		result = append(result, item)
+	}
 	return result
 }
@@ -1,82 +1,176 @@
 package grammar
 
 import (
-	"fmt"
+	"errors"
+	"reflect"
+	"strings"
 	"testing"
 )
 
-// Helper function to create a Symbol easily
-func createSymbol(value string, isTerminal bool, id int) Symbol {
-	return Symbol{
-		IsTerminal: isTerminal,
-		Value:      value,
-		Id:         id,
+// Quita la recursión por la izquierda y revisa que no quede y que el
+// lenguaje sea el mismo
+func assertLeftRecursionRemoved(t *testing.T, g *Grammar, maxLength int) *Grammar {
+	t.Helper()
+	result, err := RemoveLeftRecursion(g)
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+	if recursive, violations := result.IsLeftRecursive(); recursive {
+		t.Errorf("Expected no left recursion, but got %v in:\n%s", violations, result.String(false))
+	}
+	if check := VerifyLanguage(g, LL1PrepPipeline(), maxLength); !check.Preserved() {
+		t.Errorf("Expected the same language, but %q changed in %s", check.Change.Input, check.Change.Stage)
+	}
+	return result
+}
+
+func TestRemoveLeftRecursionDirect(t *testing.T) {
+	g := parseTestGrammar(t, "A -> {A}t{B}|{B}", "B -> i|l{A}l")
+	result := assertLeftRecursionRemoved(t, g, 6)
+
+	// A -> {B}{A_1} y A_1 -> t{B}{A_1}|ε
+	expected := []string{"{B_0}{A_1}"}
+	if bodies := sortedBodies(result, Symbol{Value: "A"}); !reflect.DeepEqual(bodies, expected) {
+		t.Errorf("Expected %q, but got %q", expected, bodies)
+	}
+	expected = []string{"t{B_0}{A_1}", "ε"}
+	if bodies := sortedBodies(result, Symbol{Value: "A", Id: 1}); !reflect.DeepEqual(bodies, expected) {
+		t.Errorf("Expected %q, but got %q", expected, bodies)
+	}
+	expected = []string{"i", "l{A_0}l"}
+	if bodies := sortedBodies(result, Symbol{Value: "B"}); !reflect.DeepEqual(bodies, expected) {
+		t.Errorf("Expected %q, but got %q", expected, bodies)
 	}
 }
 
-func TestFindAllBodyVariants(t *testing.T) {
-	// Step 1: Define the symbols
-	A := createSymbol("A", false, 0)
+func TestRemoveLeftRecursionIndirect(t *testing.T) {
+	g := parseTestGrammar(t, "A -> {B}a|c", "B -> {A}b|d")
+	result := assertLeftRecursionRemoved(t, g, 7)
 
-	// Step 2: Define the grammar
-	grammar := &Grammar{
-		Productions: make(map[Symbol][][]Symbol),
+	// B -> {A}b pasa a B -> {B}ab|cb, y se quita la recursión directa
+	expected := []string{"cb{B_1}", "d{B_1}"}
+	if bodies := sortedBodies(result, Symbol{Value: "B"}); !reflect.DeepEqual(bodies, expected) {
+		t.Errorf("Expected %q, but got %q", expected, bodies)
+	}
+	expected = []string{"ab{B_1}", "ε"}
+	if bodies := sortedBodies(result, Symbol{Value: "B", Id: 1}); !reflect.DeepEqual(bodies, expected) {
+		t.Errorf("Expected %q, but got %q", expected, bodies)
+	}
+	expected = []string{"c", "{B_0}a"}
+	if bodies := sortedBodies(result, Symbol{Value: "A"}); !reflect.DeepEqual(bodies, expected) {
+		t.Errorf("Expected %q, but got %q", expected, bodies)
 	}
-	grammar.AddProductionFromString("A -> {B}m")
-	grammar.AddProductionFromString("B -> {C}|{D}o")
-	grammar.AddProductionFromString("C -> {B}k")
-	grammar.AddProductionFromString("D -> q")
+}
 
-	// fmt.Println(grammar.String(false))
+func TestRemoveLeftRecursionKeepsOtherProductions(t *testing.T) {
+	g := parseTestGrammar(t, "S -> {S}m|{A}", "A -> {A}m|{B}", "B -> {B}n|{C}", "C -> a")
+	result := assertLeftRecursionRemoved(t, g, 6)
 
-	bodyVariants := findAllBodyVariants(&A, grammar)
-	for _, variant := range bodyVariants {
-		fmt.Println(variant)
+	// Ningún no terminal llega a uno anterior, así que no se sustituye nada
+	expected := []string{"{A_0}{S_1}"}
+	if bodies := sortedBodies(result, Symbol{Value: "S"}); !reflect.DeepEqual(bodies, expected) {
+		t.Errorf("Expected %q, but got %q", expected, bodies)
+	}
+	expected = []string{"a"}
+	if bodies := sortedBodies(result, Symbol{Value: "C"}); !reflect.DeepEqual(bodies, expected) {
+		t.Errorf("Expected %q, but got %q", expected, bodies)
 	}
 }
 
-func TestFindAllBodyVariants2(t *testing.T) {
-	// Step 1: Define the symbols
-	A := createSymbol("A", false, 0)
+func TestRemoveLeftRecursionHidden(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+	}{
+		{name: "nullable prefix", lines: []string{"S -> {N}{S}a|b", "N -> n|ε"}},
+		{name: "nullable start", lines: []string{"S -> {N}{S}a|ε", "N -> n|ε"}},
+		{name: "indirect", lines: []string{"A -> {N}{B}a|c", "B -> {A}b|{N}d", "N -> n|ε"}},
+		{name: "nullable cycle", lines: []string{"S -> {S}{N}|a", "N -> n|ε"}},
+	}
 
-	// Step 2: Define the grammar
-	grammar := &Grammar{
-		Productions: make(map[Symbol][][]Symbol),
+	for _, test := range tests {
+		g := parseTestGrammar(t, test.lines...)
+		if recursive, _ := g.IsLeftRecursive(); !recursive {
+			t.Fatalf("%s: Expected the grammar to be left recursive", test.name)
+		}
+		assertLeftRecursionRemoved(t, g, 6)
 	}
-	grammar.AddProductionFromString("A -> {B}{A}|{C}m|aa")
-	grammar.AddProductionFromString("B -> {C}")
-	grammar.AddProductionFromString("C -> {A}")
+}
 
-	// fmt.Println(grammar.String(false))
+func TestRemoveLeftRecursionTrivialBodies(t *testing.T) {
+	g := parseTestGrammar(t, "S -> {S}a|{B}", "B -> {B}|b")
+	result := assertLeftRecursionRemoved(t, g, 5)
 
-	bodyVariants := findAllBodyVariants(&A, grammar)
-	for _, variant := range bodyVariants {
-		fmt.Println(variant)
+	// B -> {B} no genera nada nuevo y se quita antes de Paull
+	expected := []string{"b"}
+	if bodies := sortedBodies(result, Symbol{Value: "B"}); !reflect.DeepEqual(bodies, expected) {
+		t.Errorf("Expected %q, but got %q", expected, bodies)
+	}
+	expected = []string{"{B_0}{S_1}"}
+	if bodies := sortedBodies(result, Symbol{Value: "S"}); !reflect.DeepEqual(bodies, expected) {
+		t.Errorf("Expected %q, but got %q", expected, bodies)
 	}
 }
 
-func TestRemoveLeftRecursivity(t *testing.T) {
-	// Step 2: Define the grammar
-	grammar := &Grammar{
-		Productions: make(map[Symbol][][]Symbol),
+func TestRemoveLeftRecursionCycles(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		expected string
+		found    []string
+	}{
+		{
+			name:     "unit cycle",
+			lines:    []string{"A -> {B}|a", "B -> {A}|b"},
+			expected: "the left recursion of {A_0}, {B_0} can not be removed because of the cycles: {A_0} => {B_0} => {A_0}",
+			found: []string{
+				"{A_0} -> {B_0}: is part of the cycle {A_0} => {B_0} => {A_0}, remove the ε and unit productions first",
+				"{B_0} -> {A_0}: is part of the cycle {B_0} => {A_0} => {B_0}, remove the ε and unit productions first",
+			},
+		},
+		{
+			// S no es parte del ciclo, así que su recursión se quita
+			name:     "cycle below",
+			lines:    []string{"S -> {S}c|{A}", "A -> {B}|a", "B -> {A}|b"},
+			expected: "the left recursion of {A_0}, {B_0} can not be removed because of the cycles: {A_0} => {B_0} => {A_0}",
+			found: []string{
+				"{A_0} -> {B_0}: is part of the cycle {A_0} => {B_0} => {A_0}, remove the ε and unit productions first",
+				"{B_0} -> {A_0}: is part of the cycle {B_0} => {A_0} => {B_0}, remove the ε and unit productions first",
+			},
+		},
 	}
-	grammar.AddProductionFromString("A -> {A}t{B}|{B}")
-	grammar.AddProductionFromString("B -> int|l{A}l")
 
-	newGrammar := removeLeftRecursivity(grammar)
-	fmt.Println(newGrammar.String(false))
-}
+	for _, test := range tests {
+		g := parseTestGrammar(t, test.lines...)
+		result, err := RemoveLeftRecursion(g)
+		var cycleError *LeftRecursionCycleError
+		if !errors.As(err, &cycleError) {
+			t.Fatalf("%s: Expected a LeftRecursionCycleError, but got %v", test.name, err)
+		}
+		if err.Error() != test.expected {
+			t.Errorf("%s: Expected %q, but got %q", test.name, test.expected, err.Error())
+		}
+		if heads := leftRecursiveHeads(result); !reflect.DeepEqual(heads, cycleError.Heads) {
+			t.Errorf("%s: Expected only %v to be left recursive, but got %v", test.name, cycleError.Heads, heads)
+		}
 
-func TestRemoveLeftRecursivity2(t *testing.T) {
-	// Step 2: Define the grammar
-	grammar := &Grammar{
-		Productions: make(map[Symbol][][]Symbol),
+		// La etapa reporta los ciclos que quedan
+		stage, _ := LL1PrepPipeline().RunResult(g, false).Stage(StageLeftRecursionFree)
+		if found := violationStrings(stage.Violations); !reflect.DeepEqual(found, test.found) {
+			t.Errorf("%s: Expected %q, but got %q", test.name, test.found, found)
+		}
 	}
-	grammar.AddProductionFromString("S -> {S}m|{A}")
-	grammar.AddProductionFromString("A -> {A}m|{B}")
-	grammar.AddProductionFromString("B -> {B}m|{C}")
-	grammar.AddProductionFromString("C -> a")
+}
+
+func TestRemoveLeftRecursionWithoutBase(t *testing.T) {
+	g := parseTestGrammar(t, "S -> a|{A}b", "A -> {A}a")
+	result := assertLeftRecursionRemoved(t, g, 5)
 
-	newGrammar := removeLeftRecursivity(grammar)
-	fmt.Println(newGrammar.String(true))
+	// A no genera nada, así que no se le agrega A -> ε
+	if _, exist := result.Productions[Symbol{Value: "A"}]; exist {
+		t.Errorf("Expected A without productions, but got:\n%s", result.String(false))
+	}
+	if strings.Contains(result.String(false), "ε") {
+		t.Errorf("Expected no ε, but got:\n%s", result.String(false))
+	}
 }
@@ -0,0 +1,65 @@
+package grammar
+
+import "unicode/utf8"
+
+type tokenKind int
+
+const (
+	tokenChar    tokenKind = iota // Any character without special meaning.
+	tokenSpace                    // A blank space.
+	tokenArrow                    // "->"
+	tokenPipe                     // "|"
+	tokenLBrace                   // "{"
+	tokenRBrace                   // "}"
+	tokenEpsilon                  // "ε"
+	tokenEscape                   // "\" followed by a character or a \uXXXX code, its value is what follows the "\".
+	tokenEOL                      // End of the line.
+)
+
+// token is a lexical unit of a single line of a grammar file.
+type token struct {
+	kind   tokenKind
+	value  string
+	column int // Column (in characters, starting at 1) where the token begins.
+}
+
+// Splits a line of a grammar file into tokens. The lexer never fails, every
+// character that has no special meaning becomes a tokenChar and it is up to
+// the parser to decide if it is valid where it appears.
+func tokenizeLine(line string) []token {
+	tokens := make([]token, 0, utf8.RuneCountInString(line)+1)
+	runes := []rune(line)
+
+	for i := 0; i < len(runes); i++ {
+		column := i + 1
+		switch r := runes[i]; {
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '>':
+			tokens = append(tokens, token{kind: tokenArrow, value: "->", column: column})
+			i++
+		case r == '|':
+			tokens = append(tokens, token{kind: tokenPipe, value: "|", column: column})
+		case r == '{':
+			tokens = append(tokens, token{kind: tokenLBrace, value: "{", column: column})
+		case r == '}':
+			tokens = append(tokens, token{kind: tokenRBrace, value: "}", column: column})
+		case r == EscapeSymbol:
+			// A trailing backslash is reported by the parser as an escape without value
+			value := ""
+			if i+1 < len(runes) {
+				_, length, _ := unescapeSequence(runes[i+1:])
+				value = string(runes[i+1 : i+1+length])
+				i += length
+			}
+			tokens = append(tokens, token{kind: tokenEscape, value: value, column: column})
+		case r == ' ' || r == '\t':
+			tokens = append(tokens, token{kind: tokenSpace, value: string(r), column: column})
+		case string(r) == Epsilon:
+			tokens = append(tokens, token{kind: tokenEpsilon, value: Epsilon, column: column})
+		default:
+			tokens = append(tokens, token{kind: tokenChar, value: string(r), column: column})
+		}
+	}
+
+	tokens = append(tokens, token{kind: tokenEOL, value: "", column: len(runes) + 1})
+	return tokens
+}
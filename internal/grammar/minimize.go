@@ -0,0 +1,331 @@
+package grammar
+
+import "errors"
+
+/*
+Minimizador de gramáticas con delta debugging. Cuando una pasada cambia el
+lenguaje de una gramática grande, Minimize busca una gramática y una cadena
+testigo más pequeñas con las que el problema se sigue viendo:
+
+	check := VerifyLanguage(g, SimplifyPipeline(), 5)
+	reproduction, err := Minimize(g, check.Change.Symbols, ChangesMembership(SimplifyPipeline()))
+	content, err := reproduction.Native()
+
+El predicado dice si el problema se sigue viendo con una gramática y una
+testigo. Se borran, mientras el predicado se cumpla y en este orden: no
+terminales completos (con los cuerpos que los usan), cuerpos, símbolos de los
+cuerpos y símbolos de la testigo. Cada borrado usa el algoritmo ddmin de
+Zeller: primero prueba a quitar mitades, luego cuartos, y así hasta quitar
+elementos de uno en uno. Las rondas se repiten hasta que ninguna borra nada,
+así que al final no se puede quitar ningún elemento por sí solo.
+*/
+
+// Name of the section written by Reproduction.Native.
+const MinimalSectionName = "minimal"
+
+// Tells whether a grammar and a witness still show a problem. It must not
+// modify the grammar or the witness.
+type MinimizePredicate func(g *Grammar, witness []Symbol) bool
+
+// A grammar and a witness reduced by Minimize.
+type Reproduction struct {
+	Grammar *Grammar
+	Witness []Symbol
+	Tests   int // Times the predicate was called.
+}
+
+// Returns: a predicate for Minimize that holds when some stage of the
+// pipeline changes whether the grammar accepts the witness, like
+// VerifyLanguage reports.
+func ChangesMembership(pipeline *Pipeline) MinimizePredicate {
+	return func(g *Grammar, witness []Symbol) bool {
+		change, _ := firstLanguageChange(pipeline.RunResult(g, false).Stages, func(visit func(input []Symbol) bool) {
+			visit(witness)
+		})
+		return change != nil
+	}
+}
+
+// Shrinks a grammar and a witness by deleting NON terminals, bodies, body
+// symbols and witness symbols while the predicate holds. The start symbol
+// is never deleted, and a body without symbols becomes ε. A predicate that
+// panics counts as not holding. The grammar and the witness are not
+// modified.
+//
+// Returns: the reduced grammar and witness, or an error if the predicate
+// does not hold for the given ones.
+func Minimize(g *Grammar, witness []Symbol, holds MinimizePredicate) (*Reproduction, error) {
+	result := &Reproduction{Grammar: cleanMinimized(g.Clone()), Witness: copySymbols(witness)}
+	if result.Witness == nil {
+		result.Witness = []Symbol{}
+	}
+
+	test := func(candidate *Grammar, candidateWitness []Symbol) (held bool) {
+		result.Tests++
+		defer func() {
+			if recover() != nil {
+				held = false
+			}
+		}()
+		return holds(candidate, candidateWitness)
+	}
+	if !test(result.Grammar.Clone(), copySymbols(result.Witness)) {
+		return nil, errors.New("the predicate does not hold for the given grammar and witness")
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, reduce := range []func(*Reproduction, func(*Grammar, []Symbol) bool) bool{
+			deleteNonTerminals, deleteBodies, deleteBodySymbols, deleteWitnessSymbols,
+		} {
+			changed = reduce(result, test) || changed
+		}
+	}
+	return result, nil
+}
+
+// Writes the reproduction as a section of the A -> b{A} format named
+// "minimal", with the witness as an accept: or reject: example, as the
+// grammar itself accepts or rejects it. Checking the file with the --batch
+// mode of the program shows the problem, when it is a change of membership.
+//
+// Returns: the file, or an error if the grammar cannot be written in the
+// native format.
+func (r *Reproduction) Native() (string, error) {
+	content, err := ExportNative(r.Grammar)
+	if err != nil {
+		return "", err
+	}
+
+	prefix := RejectPrefix
+	if EarleyRecognize(r.Grammar, r.Witness, r.Grammar.GetStartSymbol()) {
+		prefix = AcceptPrefix
+	}
+	return SectionHeader + " " + MinimalSectionName + "\n" + content + prefix + " " + joinSymbols(r.Witness) + "\n", nil
+}
+
+// Deletes whole NON terminals, except the start symbol, with the bodies of
+// other NON terminals that use them.
+//
+// Returns: true if something was deleted.
+func deleteNonTerminals(r *Reproduction, test func(*Grammar, []Symbol) bool) bool {
+	heads := make([]Symbol, 0, len(r.Grammar.Productions))
+	for _, head := range orderedHeads(r.Grammar) {
+		if head != r.Grammar.GetStartSymbol() {
+			heads = append(heads, head)
+		}
+	}
+
+	build := func(kept []int) *Grammar {
+		deleted := make(map[Symbol]bool)
+		for _, head := range heads {
+			deleted[head] = true
+		}
+		for _, index := range kept {
+			delete(deleted, heads[index])
+		}
+
+		candidate := r.Grammar.Clone()
+		for head, bodies := range candidate.Productions {
+			if deleted[head] {
+				delete(candidate.Productions, head)
+				continue
+			}
+			keptBodies := make([][]Symbol, 0, len(bodies))
+			for _, body := range bodies {
+				uses := false
+				for _, symbol := range body {
+					uses = uses || deleted[symbol]
+				}
+				if !uses {
+					keptBodies = append(keptBodies, body)
+				}
+			}
+			candidate.Productions[head] = keptBodies
+		}
+		return cleanMinimized(candidate)
+	}
+	return reduceGrammar(r, len(heads), build, test)
+}
+
+// Deletes bodies of the productions.
+//
+// Returns: true if something was deleted.
+func deleteBodies(r *Reproduction, test func(*Grammar, []Symbol) bool) bool {
+	type bodyIndex struct {
+		head Symbol
+		body int
+	}
+	bodies := make([]bodyIndex, 0)
+	for _, head := range orderedHeads(r.Grammar) {
+		for index := range r.Grammar.Productions[head] {
+			bodies = append(bodies, bodyIndex{head, index})
+		}
+	}
+
+	build := func(kept []int) *Grammar {
+		candidate := r.Grammar.Clone()
+		for head := range candidate.Productions {
+			candidate.Productions[head] = [][]Symbol{}
+		}
+		for _, index := range kept {
+			head := bodies[index].head
+			candidate.Productions[head] = append(candidate.Productions[head], copySymbols(r.Grammar.Productions[head][bodies[index].body]))
+		}
+		return cleanMinimized(candidate)
+	}
+	return reduceGrammar(r, len(bodies), build, test)
+}
+
+// Deletes symbols of the bodies. A body left without symbols becomes ε.
+//
+// Returns: true if something was deleted.
+func deleteBodySymbols(r *Reproduction, test func(*Grammar, []Symbol) bool) bool {
+	type symbolIndex struct {
+		head           Symbol
+		body, position int
+	}
+	symbols := make([]symbolIndex, 0)
+	for _, head := range orderedHeads(r.Grammar) {
+		for index, body := range r.Grammar.Productions[head] {
+			for position, symbol := range body {
+				if symbol != EpsilonSymbol {
+					symbols = append(symbols, symbolIndex{head, index, position})
+				}
+			}
+		}
+	}
+
+	build := func(kept []int) *Grammar {
+		keep := make(map[symbolIndex]bool, len(kept))
+		for _, index := range kept {
+			keep[symbols[index]] = true
+		}
+
+		candidate := r.Grammar.Clone()
+		for head, bodies := range candidate.Productions {
+			for index, body := range bodies {
+				keptSymbols := make([]Symbol, 0, len(body))
+				for position, symbol := range body {
+					if symbol == EpsilonSymbol || keep[symbolIndex{head, index, position}] {
+						keptSymbols = append(keptSymbols, symbol)
+					}
+				}
+				bodies[index] = keptSymbols
+			}
+		}
+		return cleanMinimized(candidate)
+	}
+	return reduceGrammar(r, len(symbols), build, test)
+}
+
+// Deletes symbols of the witness.
+//
+// Returns: true if something was deleted.
+func deleteWitnessSymbols(r *Reproduction, test func(*Grammar, []Symbol) bool) bool {
+	build := func(kept []int) []Symbol {
+		witness := make([]Symbol, 0, len(kept))
+		for _, index := range kept {
+			witness = append(witness, r.Witness[index])
+		}
+		return witness
+	}
+
+	kept, reduced := deltaDebug(len(r.Witness), func(kept []int) bool {
+		return test(r.Grammar.Clone(), build(kept))
+	})
+	if reduced {
+		r.Witness = build(kept)
+	}
+	return reduced
+}
+
+// Keeps the grammar built from the fewest elements for which the predicate
+// still holds.
+//
+// Returns: true if some element was deleted.
+func reduceGrammar(r *Reproduction, count int, build func(kept []int) *Grammar, test func(*Grammar, []Symbol) bool) bool {
+	kept, reduced := deltaDebug(count, func(kept []int) bool {
+		return test(build(kept), copySymbols(r.Witness))
+	})
+	if reduced {
+		r.Grammar = build(kept)
+	}
+	return reduced
+}
+
+// Runs the ddmin algorithm over the elements 0 to count-1: deletes chunks
+// of elements while test holds for the remaining ones, making the chunks
+// smaller until they have a single element.
+//
+// Returns: the indexes of the elements kept, in order, and true if some
+// element was deleted.
+func deltaDebug(count int, test func(kept []int) bool) ([]int, bool) {
+	kept := make([]int, count)
+	for i := range kept {
+		kept[i] = i
+	}
+
+	chunks := 2
+	for len(kept) > 0 {
+		chunkSize := (len(kept) + chunks - 1) / chunks
+		reduced := false
+		for start := 0; start < len(kept); start += chunkSize {
+			end := min(start+chunkSize, len(kept))
+			complement := append(append([]int{}, kept[:start]...), kept[end:]...)
+			if test(complement) {
+				kept = complement
+				chunks = max(chunks-1, 2)
+				reduced = true
+				break
+			}
+		}
+		if !reduced {
+			if chunkSize == 1 {
+				break
+			}
+			chunks = min(chunks*2, len(kept))
+		}
+	}
+	return kept, len(kept) < count
+}
+
+// Fixes the cached lists of a grammar after deleting parts of it: the NON
+// terminals are the ones still used, in the same order, a body without
+// symbols is ε and a NON terminal without bodies has no productions.
+//
+// Returns: the same grammar.
+func cleanMinimized(g *Grammar) *Grammar {
+	used := map[Symbol]bool{g.GetStartSymbol(): true}
+	for head, bodies := range g.Productions {
+		if len(bodies) == 0 {
+			delete(g.Productions, head)
+			continue
+		}
+		used[head] = true
+		for index, body := range bodies {
+			if len(body) == 0 {
+				bodies[index] = []Symbol{EpsilonSymbol}
+			}
+			for _, symbol := range body {
+				if !symbol.IsTerminal {
+					used[symbol] = true
+				}
+			}
+		}
+		g.Productions[head] = removeDuplicatesSlices(bodies)
+	}
+
+	// El símbolo inicial se fija para que no cambie si se borra el primer no terminal
+	g.StartSymbol = g.GetStartSymbol()
+	nonTerminals := make([]Symbol, 0, len(used))
+	for _, nonTerminal := range g.NonTerminals {
+		if used[nonTerminal] {
+			nonTerminals = append(nonTerminals, nonTerminal)
+			delete(used, nonTerminal)
+		}
+	}
+	g.NonTerminals = nonTerminals
+	g.RecalculateTerminals()
+	return g
+}
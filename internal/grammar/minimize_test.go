@@ -0,0 +1,153 @@
+package grammar
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDeltaDebug(t *testing.T) {
+	tests := 0
+	kept, reduced := deltaDebug(10, func(kept []int) bool {
+		tests++
+		return containsInt(kept, 3) && containsInt(kept, 7)
+	})
+	if !reduced || !reflect.DeepEqual(kept, []int{3, 7}) {
+		t.Errorf("Expected [3 7], but got %v", kept)
+	}
+	if tests > 30 {
+		t.Errorf("Expected at most 30 tests, but got %d", tests)
+	}
+
+	if kept, reduced := deltaDebug(3, func(kept []int) bool { return len(kept) == 3 }); reduced || len(kept) != 3 {
+		t.Errorf("Expected nothing to be deleted, but got %v", kept)
+	}
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func TestMinimize(t *testing.T) {
+	grammars, err := ParseGrammars("test.txt", []string{"S -> a{S}|{S}b|{A}|c", "A -> d{A}|ε"})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+	g := grammars[0]
+	before := g.String(true)
+	witness := []Symbol{{IsTerminal: true, Value: "a"}, {IsTerminal: true, Value: "c"}, {IsTerminal: true, Value: "b"}, {IsTerminal: true, Value: "b"}}
+
+	// El problema: la gramática acepta una testigo con una b
+	accepts := func(g *Grammar, witness []Symbol) bool {
+		return strings.Contains(joinSymbols(witness), "b") && EarleyRecognize(g, witness, g.GetStartSymbol())
+	}
+	reproduction, err := Minimize(g, witness, accepts)
+	if err != nil {
+		t.Fatalf("Expected no errors, but got %v", err)
+	}
+
+	// No se puede borrar un solo elemento más: sin {S} no se acepta cb y sin c
+	// no se acepta ninguna cadena
+	expected := "{S_0} -> {S_0}b|c\n"
+	if reproduction.Grammar.String(false) != expected || joinSymbols(reproduction.Witness) != "cb" {
+		t.Errorf("Expected %q with the witness cb, but got %q with %q", expected, reproduction.Grammar.String(false), joinSymbols(reproduction.Witness))
+	}
+	if g.String(true) != before || len(witness) != 4 {
+		t.Errorf("Expected the grammar and the witness to stay the same")
+	}
+
+	content, err := reproduction.Native()
+	if err != nil {
+		t.Fatalf("Expected no errors, but got %v", err)
+	}
+	if expected := "### grammar minimal\nS -> {S}b|c\naccept: cb\n"; content != expected {
+		t.Errorf("Expected %q, but got %q", expected, content)
+	}
+}
+
+func TestMinimizeErrors(t *testing.T) {
+	grammars, err := ParseGrammars("test.txt", []string{"S -> a{S}|b"})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+
+	never := func(g *Grammar, witness []Symbol) bool { return false }
+	if _, err := Minimize(grammars[0], nil, never); err == nil {
+		t.Errorf("Expected an error when the predicate does not hold")
+	}
+
+	// Las gramáticas con las que el predicado falla no se conservan
+	panics := func(g *Grammar, witness []Symbol) bool {
+		if len(g.Productions[g.GetStartSymbol()]) < 2 {
+			panic("start symbol with a single body")
+		}
+		return true
+	}
+	reproduction, err := Minimize(grammars[0], nil, panics)
+	if err != nil {
+		t.Fatalf("Expected no errors, but got %v", err)
+	}
+	if got := len(reproduction.Grammar.Productions[reproduction.Grammar.GetStartSymbol()]); got != 2 {
+		t.Errorf("Expected the two bodies of S to be kept, but got %d", got)
+	}
+}
+
+func TestMinimizeLanguageChange(t *testing.T) {
+	grammars, err := ParseGrammars("test.txt", []string{
+		"E -> {E}+{T}|{T}",
+		"T -> {T}*{F}|{F}",
+		"F -> ({E})|x|y{F}",
+	})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+
+	// Una pasada que pierde los cuerpos que empiezan con y
+	broken := NewPass("broken", func(g *Grammar) *Grammar {
+		result := g.Clone()
+		for head, bodies := range result.Productions {
+			kept := make([][]Symbol, 0, len(bodies))
+			for _, body := range bodies {
+				if body[0].Value != "y" {
+					kept = append(kept, body)
+				}
+			}
+			result.Productions[head] = kept
+		}
+		return result
+	})
+	pipeline := NewPipeline(LeftRecursionPass(), broken)
+
+	check := VerifyLanguage(grammars[0], pipeline, 3)
+	if check.Preserved() {
+		t.Fatalf("Expected the broken pass to change the language")
+	}
+	reproduction, err := Minimize(grammars[0], check.Change.Symbols, ChangesMembership(pipeline))
+	if err != nil {
+		t.Fatalf("Expected no errors, but got %v", err)
+	}
+	content, err := reproduction.Native()
+	if err != nil {
+		t.Fatalf("Expected no errors, but got %v", err)
+	}
+
+	// La reproducción se puede leer de nuevo y sigue mostrando el problema
+	sections, err := ParseGrammarSections("minimal.txt", strings.Split(strings.TrimSuffix(content, "\n"), "\n"))
+	if err != nil {
+		t.Fatalf("Expected no errors reading %q, but got:\n%v", content, err)
+	}
+	if sections[0].Name != MinimalSectionName || len(sections[0].Examples) != 1 {
+		t.Fatalf("Expected a section named minimal with one example, but got %+v", sections[0])
+	}
+	if !ChangesMembership(pipeline)(sections[0].Grammar, reproduction.Witness) {
+		t.Errorf("Expected %q to still change the language", content)
+	}
+	if expected := "### grammar minimal\nE -> {T}\nT -> {F}\nF -> x|y{F}\naccept: yx\n"; content != expected {
+		t.Errorf("Expected %q, but got %q", expected, content)
+	}
+}
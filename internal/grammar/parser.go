@@ -0,0 +1,503 @@
+package grammar
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Line that separates two grammars inside the same file.
+const GrammarSeparator = "---"
+
+// Header of a named grammar section, written as "### grammar NAME". Like the
+// separator, it closes the previous grammar.
+const SectionHeader = "### grammar"
+
+// Prefixes of the example strings of a grammar: "accept: STRING" for strings
+// of the language and "reject: STRING" for strings outside of it.
+const (
+	AcceptPrefix = "accept:"
+	RejectPrefix = "reject:"
+)
+
+// A grammar of a file, with the name of its section and its examples.
+type GrammarSection struct {
+	Name     string    // Name given by the section header, empty if it had none.
+	Line     int       // Line where the section starts, its header if it has one.
+	Grammar  *Grammar  // The grammar of the section.
+	Examples []Example // Strings that the grammar must accept or reject.
+}
+
+// An example string of a grammar. An empty input, written as nothing or as
+// ε after the prefix, is the empty string.
+type Example struct {
+	Input  string `json:"input"`
+	Accept bool   `json:"accept"` // True for "accept:" examples, false for "reject:" ones.
+	Line   int    `json:"line"`
+}
+
+// ParseError describes a single problem found while parsing a grammar file.
+type ParseError struct {
+	File    string // Name of the file being parsed.
+	Line    int    // Line of the error, starting at 1.
+	Column  int    // Column of the error in characters, starting at 1.
+	Char    string // Offending character, empty when the error is at the end of the line.
+	Message string // What went wrong.
+	Hint    string // How to fix it.
+}
+
+func (e ParseError) Error() string {
+	found := "end of line"
+	if e.Char != "" {
+		found = fmt.Sprintf("'%s'", e.Char)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s, found %s", e.File, e.Line, e.Column, e.Message, found)
+}
+
+// ParseErrors is the list of every error found in a grammar file.
+type ParseErrors []ParseError
+
+func (errs ParseErrors) Error() string {
+	var sb strings.Builder
+	for index, err := range errs {
+		sb.WriteString(err.Error())
+		if err.Hint != "" {
+			sb.WriteString("\n\thint: ")
+			sb.WriteString(err.Hint)
+		}
+		if index != len(errs)-1 {
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+// grammarParser keeps the state needed to parse a grammar file line by line.
+type grammarParser struct {
+	file   string
+	line   int
+	errors ParseErrors
+
+	current     *Grammar  // Grammar being read.
+	ebnf        bool      // True if the EBNF operators are enabled for the current grammar.
+	startLine   int       // Line of the %start directive of the current grammar.
+	startColumn int       // Column of the start symbol name in the %start directive.
+	name        string    // Name of the section of the current grammar.
+	sectionLine int       // Line where the current section starts.
+	examples    []Example // Examples of the current grammar.
+}
+
+// Parses the lines of a grammar file. Grammars are separated by a "---" line,
+// empty lines and lines starting with "#" are ignored, "%start NAME" sets the
+// start symbol of the current grammar and "%ebnf" enables the EBNF operators
+// inside its bodies.
+//
+// The parser does not stop at the first error, it keeps going to report
+// every error in the file.
+//
+// Returns: the list of grammars, and a ParseErrors if any error was found.
+func ParseGrammars(file string, lines []string) ([]*Grammar, error) {
+	sections, err := ParseGrammarSections(file, lines)
+	grammars := make([]*Grammar, 0, len(sections))
+	for _, section := range sections {
+		grammars = append(grammars, section.Grammar)
+	}
+	return grammars, err
+}
+
+// Parses the lines of a grammar file like ParseGrammars, also reading the
+// named sections and their examples:
+//
+//	### grammar expr
+//	E -> {E}+x|x
+//	accept: x+x
+//	reject: x+
+//
+// A "### grammar NAME" line starts a new grammar, like "---", and the
+// "accept:" and "reject:" lines add examples to the current grammar.
+//
+// Returns: the list of sections, and a ParseErrors if any error was found.
+func ParseGrammarSections(file string, lines []string) ([]*GrammarSection, error) {
+	p := &grammarParser{file: file}
+	sections := make([]*GrammarSection, 0)
+	names := make(map[string]bool)
+	p.resetGrammar()
+
+	closeSection := func() {
+		if section := p.closeSection(); section != nil {
+			sections = append(sections, section)
+		}
+	}
+
+	for index, line := range lines {
+		p.line = index + 1
+
+		switch {
+		case line == GrammarSeparator:
+			closeSection()
+		case strings.HasPrefix(line, SectionHeader+" ") || strings.TrimSpace(line) == SectionHeader:
+			closeSection()
+			p.parseSectionHeader(line, names)
+		case strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "%"):
+			p.parseDirective(line)
+		case strings.HasPrefix(line, AcceptPrefix):
+			p.examples = append(p.examples, Example{Input: exampleInput(line[len(AcceptPrefix):]), Accept: true, Line: p.line})
+		case strings.HasPrefix(line, RejectPrefix):
+			p.examples = append(p.examples, Example{Input: exampleInput(line[len(RejectPrefix):]), Accept: false, Line: p.line})
+		default:
+			if head, bodies, ok := p.parseProduction(line); ok {
+				p.current.addProductionSymbols(head, bodies)
+			}
+		}
+	}
+	closeSection()
+
+	if len(p.errors) > 0 {
+		return sections, p.errors
+	}
+	return sections, nil
+}
+
+// Parses a "### grammar NAME" header, checking that the name is not empty
+// and that no other section has the same name.
+func (p *grammarParser) parseSectionHeader(line string, names map[string]bool) {
+	name := strings.TrimSpace(line[len(SectionHeader):])
+	if name == "" {
+		p.addError(token{column: len([]rune(line)) + 1}, "missing grammar name", "write the header as ### grammar NAME")
+		return
+	}
+
+	column := len(SectionHeader) + strings.Index(line[len(SectionHeader):], name)
+	at := token{kind: tokenChar, value: name, column: len([]rune(line[:column])) + 1}
+	if names[name] {
+		p.addError(at, fmt.Sprintf("grammar %q is already defined", name), "give every section of the file a different name")
+		return
+	}
+	names[name] = true
+	p.name = name
+	p.sectionLine = p.line
+}
+
+// Closes the current grammar like closeGrammar, together with its section.
+//
+// Returns: the section, or nil if the grammar had no productions.
+func (p *grammarParser) closeSection() *GrammarSection {
+	section := &GrammarSection{Name: p.name, Line: p.sectionLine, Examples: p.examples}
+	section.Grammar = p.closeGrammar()
+	if section.Grammar != nil {
+		return section
+	}
+
+	if len(section.Examples) > 0 {
+		first := section.Examples[0]
+		prefix := RejectPrefix
+		if first.Accept {
+			prefix = AcceptPrefix
+		}
+		line := p.line
+		p.line = first.Line
+		p.addError(token{kind: tokenChar, value: prefix, column: 1}, "examples without a grammar",
+			"write the productions of the grammar in the same section as its examples")
+		p.line = line
+	}
+	return nil
+}
+
+// Returns: the input of an example, without the spaces around it. The
+// empty string may also be written as ε.
+func exampleInput(value string) string {
+	value = strings.TrimSpace(value)
+	if value == Epsilon {
+		return ""
+	}
+	return value
+}
+
+// Starts a new empty grammar.
+func (p *grammarParser) resetGrammar() {
+	p.current = &Grammar{Productions: make(map[Symbol][][]Symbol)}
+	p.ebnf = false
+	p.name = ""
+	p.examples = nil
+	p.sectionLine = p.line + 1
+}
+
+// Closes the current grammar, checking that its start symbol exists.
+//
+// Returns: the grammar, or nil if it had no productions.
+func (p *grammarParser) closeGrammar() *Grammar {
+	g := p.current
+	p.resetGrammar()
+
+	if g.StartSymbol.Value != "" {
+		if _, exist := g.Productions[g.StartSymbol]; !exist {
+			line := p.line
+			p.line = p.startLine
+			p.addError(token{kind: tokenChar, value: g.StartSymbol.Value, column: p.startColumn},
+				fmt.Sprintf("start symbol %q has no productions", g.StartSymbol.Value),
+				"declare at least one production for the start symbol")
+			p.line = line
+		}
+	}
+	if len(g.Productions) == 0 {
+		return nil
+	}
+	return g
+}
+
+func (p *grammarParser) addError(at token, message string, hint string) {
+	p.errors = append(p.errors, ParseError{
+		File:    p.file,
+		Line:    p.line,
+		Column:  at.column,
+		Char:    at.value,
+		Message: message,
+		Hint:    hint,
+	})
+}
+
+// Parses a "%start NAME" or "%ebnf" directive, applying it to the current grammar.
+func (p *grammarParser) parseDirective(line string) {
+	tokens := tokenizeLine(line)
+
+	if strings.TrimSpace(line) == EBNFDirective {
+		p.ebnf = true
+		return
+	}
+
+	name, isDirective := ParseStartDirective(line)
+	if !isDirective {
+		if strings.TrimSpace(line) == StartDirective {
+			p.addError(tokens[len(tokens)-1], "missing start symbol name", "write the directive as %start NAME")
+		} else {
+			p.addError(tokens[0], "unknown directive", "the supported directives are %start NAME and %ebnf")
+		}
+		return
+	}
+
+	// Skip the directive keyword and the spaces after it
+	i := len([]rune(StartDirective))
+	for tokens[i].kind == tokenSpace {
+		i++
+	}
+	column := tokens[i].column
+
+	nameTokens := tokens[i : len(tokens)-1]
+	// Allow the start symbol to be written between braces
+	if len(nameTokens) >= 2 && nameTokens[0].kind == tokenLBrace && nameTokens[len(nameTokens)-1].kind == tokenRBrace {
+		nameTokens = nameTokens[1 : len(nameTokens)-1]
+	}
+	if !p.checkNonTerminalName(nameTokens) {
+		return
+	}
+
+	p.current.SetStartSymbol(name)
+	p.startLine, p.startColumn = p.line, column
+}
+
+// Parses a production with the shape HEAD -> body|body. The head may also
+// be written between braces, like {HEAD} -> body.
+//
+// Returns: the head, the bodies and true if the production had no errors.
+func (p *grammarParser) parseProduction(line string) (Symbol, [][]Symbol, bool) {
+	tokens := tokenizeLine(line)
+	errorCount := len(p.errors)
+	i := 0
+
+	// Skip leading spaces
+	for tokens[i].kind == tokenSpace {
+		i++
+	}
+
+	// HEAD
+	var head Symbol
+	if tokens[i].kind == tokenLBrace {
+		var ok bool
+		if head, i, ok = p.parseNonTerminal(tokens, i); !ok {
+			return Symbol{}, nil, false
+		}
+	} else {
+		headStart := i
+		for tokens[i].kind == tokenChar || tokens[i].kind == tokenEpsilon {
+			i++
+		}
+		headTokens := tokens[headStart:i]
+		if len(headTokens) == 0 {
+			if tokens[i].kind == tokenArrow {
+				p.addError(tokens[i], "missing production head", "write the nonterminal being defined before '->'")
+			} else {
+				p.addError(tokens[i], "unexpected character at the start of a production", "productions have the form HEAD -> body|body")
+			}
+			return Symbol{}, nil, false
+		}
+		p.checkNonTerminalName(headTokens)
+		head = Symbol{Value: tokensToString(headTokens), IsTerminal: false, Id: 0}
+	}
+
+	// ->
+	for tokens[i].kind == tokenSpace {
+		i++
+	}
+	if tokens[i].kind != tokenArrow {
+		p.addError(tokens[i], "missing '->' after the production head", "productions have the form HEAD -> body|body")
+		return Symbol{}, nil, false
+	}
+	i++
+	// A single space after the arrow is part of the syntax, the rest belong to the body
+	if tokens[i].kind == tokenSpace {
+		i++
+	}
+
+	// BODIES
+	if p.ebnf {
+		if len(p.errors) != errorCount {
+			return Symbol{}, nil, false
+		}
+		bodies, ok := p.parseEBNFBodies(tokens, i, head)
+		return head, bodies, ok
+	}
+
+	bodies := make([][]Symbol, 0)
+	for {
+		body, next := p.parseBody(tokens, i)
+		bodies = append(bodies, body)
+		i = next
+		if tokens[i].kind == tokenEOL {
+			break
+		}
+		i++ // Skip "|"
+	}
+
+	return head, bodies, len(p.errors) == errorCount
+}
+
+// Parses a single alternative, starting at tokens[i].
+//
+// Returns: the body symbols and the index of the "|" or end of line that ends it.
+func (p *grammarParser) parseBody(tokens []token, i int) ([]Symbol, int) {
+	body := make([]Symbol, 0)
+	errorCount := len(p.errors)
+
+	for {
+		current := tokens[i]
+		switch current.kind {
+		case tokenPipe, tokenEOL:
+			// An alternative left empty by other errors was already reported
+			if len(body) == 0 && len(p.errors) == errorCount {
+				p.addError(current, "empty alternative", "use ε to write the empty string")
+			}
+			return body, i
+
+		case tokenEpsilon:
+			body = append(body, EpsilonSymbol)
+			i++
+
+		case tokenLBrace:
+			if nonTerminal, next, ok := p.parseNonTerminal(tokens, i); ok {
+				body = append(body, nonTerminal)
+				i = next
+			} else if next > i {
+				i = next
+			} else {
+				i++
+			}
+
+		case tokenRBrace:
+			p.addError(current, "unexpected '}'", "nonterminals are written between braces, like {A}")
+			i++
+
+		case tokenEscape:
+			if terminal, ok := p.parseEscape(current); ok {
+				body = append(body, terminal)
+			}
+			i++
+
+		default:
+			// Every other token is made of terminal characters
+			for _, char := range current.value {
+				body = append(body, Symbol{Value: string(char), IsTerminal: true, Id: 0})
+			}
+			i++
+		}
+	}
+}
+
+// Parses a nonterminal written between braces, starting at the "{" in tokens[i].
+//
+// Returns: the nonterminal, the index after it, and true if it had no errors.
+func (p *grammarParser) parseNonTerminal(tokens []token, i int) (Symbol, int, bool) {
+	nameStart := i + 1
+	end := nameStart
+	for tokens[end].kind != tokenRBrace && tokens[end].kind != tokenPipe &&
+		tokens[end].kind != tokenEOL && tokens[end].kind != tokenLBrace {
+		end++
+	}
+	if tokens[end].kind != tokenRBrace {
+		p.addError(tokens[i], "unclosed '{'", "close the nonterminal name with '}'")
+		return Symbol{}, end, false
+	}
+
+	nameTokens := tokens[nameStart:end]
+	if len(nameTokens) == 0 {
+		p.addError(tokens[end], "empty nonterminal name", "write the name of the nonterminal between the braces, like {A}")
+		return Symbol{}, end + 1, false
+	}
+	if !p.checkNonTerminalName(nameTokens) {
+		return Symbol{}, end + 1, false
+	}
+
+	return Symbol{Value: tokensToString(nameTokens), IsTerminal: false, Id: 0}, end + 1, true
+}
+
+// Parses an escape sequence token like \{.
+//
+// Returns: the escaped terminal and true if the escape sequence is valid.
+func (p *grammarParser) parseEscape(current token) (Symbol, bool) {
+	char := []rune(current.value)
+	if len(char) == 0 {
+		p.addError(token{kind: tokenEscape, value: string(EscapeSymbol), column: current.column},
+			"unfinished escape sequence", "use \\\\ to write a backslash")
+		return Symbol{}, false
+	}
+	if terminal, _, exist := unescapeSequence(char); exist {
+		return terminal, true
+	}
+	p.addError(token{kind: tokenEscape, value: string(EscapeSymbol) + current.value, column: current.column},
+		"unknown escape sequence", `valid escape sequences are \{ \} \| \ε \s \t \n \r \\, \uXXXX for any character and, for the EBNF operators, \( \) \? \* \+`)
+	return Symbol{}, false
+}
+
+// Checks that a nonterminal name is made only of valid characters, reporting
+// the first invalid one.
+func (p *grammarParser) checkNonTerminalName(name []token) bool {
+	for _, t := range name {
+		if t.kind == tokenEpsilon {
+			p.addError(t, "ε in nonterminal name", "ε is reserved for the empty string and cannot be part of a name")
+			return false
+		}
+		for _, char := range t.value {
+			if !isNonTerminalChar(char) {
+				p.addError(t, "invalid character in nonterminal name", "nonterminal names may only use letters, digits, '_' and primes, like {expr_list} or {A'}")
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Checks if a character can be part of a nonterminal name: any Unicode
+// letter or digit, underscores and primes.
+func isNonTerminalChar(char rune) bool {
+	return unicode.IsLetter(char) || unicode.IsDigit(char) ||
+		char == '_' || char == '\'' || char == '′'
+}
+
+func tokensToString(tokens []token) string {
+	var sb strings.Builder
+	for _, t := range tokens {
+		sb.WriteString(t.value)
+	}
+	return sb.String()
+}
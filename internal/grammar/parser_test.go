@@ -0,0 +1,219 @@
+package grammar
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseGrammars(t *testing.T) {
+	lines := []string{
+		"# Gramatica 1",
+		"%start B",
+		"A -> a{A}|ε",
+		"B -> {A}b|c",
+		"---",
+		"S -> he |she ",
+	}
+
+	grammars, err := ParseGrammars("test.txt", lines)
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+	if len(grammars) != 2 {
+		t.Fatalf("Expected 2 grammars, but got %d", len(grammars))
+	}
+
+	expectedGrammar := `NonTerminals: [{A_0},{B_0}]
+Terminals: [a,ε,b,c]
+
+{A_0} -> a{A_0}|ε
+{B_0} -> {A_0}b|c
+`
+	if grammars[0].String(true) != expectedGrammar {
+		t.Errorf("Expected %q,\n but got %q", expectedGrammar, grammars[0].String(true))
+	}
+	if start := grammars[0].GetStartSymbol(); start.Value != "B" {
+		t.Errorf("Expected start symbol B, but got %s", start.String())
+	}
+
+	// Spaces inside the body are terminals, printed as \s
+	expectedGrammar = "{S_0} -> he\\s|she\\s\n"
+	if grammars[1].String(false) != expectedGrammar {
+		t.Errorf("Expected %q,\n but got %q", expectedGrammar, grammars[1].String(false))
+	}
+}
+
+func TestParseGrammarsMatchesAddProductionFromString(t *testing.T) {
+	g := Grammar{
+		Productions: make(map[Symbol][][]Symbol),
+	}
+	g.AddProductionFromString("A -> a|{B}C|{B}C")
+	g.AddProductionFromString("B -> b|{C}D")
+
+	grammars, err := ParseGrammars("test.txt", []string{"A -> a|{B}C|{B}C", "B -> b|{C}D"})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+	if grammars[0].String(true) != g.String(true) {
+		t.Errorf("Expected %q,\n but got %q", g.String(true), grammars[0].String(true))
+	}
+}
+
+func TestParseGrammarsErrors(t *testing.T) {
+	lines := []string{
+		"S -> a{A|b",
+		"S a",
+		"A.b -> x||y",
+		"B -> }c|{a B}|",
+		" -> q",
+		"%start Z",
+	}
+
+	expected := []ParseError{
+		{Line: 1, Column: 7, Char: "{", Message: "unclosed '{'"},
+		{Line: 2, Column: 3, Char: "a", Message: "missing '->' after the production head"},
+		{Line: 3, Column: 2, Char: ".", Message: "invalid character in nonterminal name"},
+		{Line: 3, Column: 10, Char: "|", Message: "empty alternative"},
+		{Line: 4, Column: 6, Char: "}", Message: "unexpected '}'"},
+		{Line: 4, Column: 11, Char: " ", Message: "invalid character in nonterminal name"},
+		{Line: 4, Column: 15, Char: "", Message: "empty alternative"},
+		{Line: 5, Column: 2, Char: "->", Message: "missing production head"},
+		{Line: 6, Column: 8, Char: "Z", Message: "start symbol \"Z\" has no productions"},
+	}
+
+	_, err := ParseGrammars("test.txt", lines)
+
+	var parseErrors ParseErrors
+	if !errors.As(err, &parseErrors) {
+		t.Fatalf("Expected ParseErrors, but got %v", err)
+	}
+	if len(parseErrors) != len(expected) {
+		t.Fatalf("Expected %d errors, but got %d:\n%v", len(expected), len(parseErrors), err)
+	}
+	for i, e := range expected {
+		got := parseErrors[i]
+		if got.File != "test.txt" || got.Line != e.Line || got.Column != e.Column || got.Char != e.Char || got.Message != e.Message {
+			t.Errorf("Expected error %d to be %v, but got %v", i, e, got)
+		}
+		if got.Hint == "" {
+			t.Errorf("Expected error %d to have a hint", i)
+		}
+	}
+}
+
+func TestParseGrammarsEscapes(t *testing.T) {
+	grammars, err := ParseGrammars("test.txt", []string{`S -> \{{S}\}|a\|b|\ε|\s\\|ε|\t\n\r|\u00A0\u00e9`})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+
+	S := Symbol{IsTerminal: false, Value: "S", Id: 0}
+	expected := [][]Symbol{
+		{{IsTerminal: true, Value: "{"}, S, {IsTerminal: true, Value: "}"}},
+		{{IsTerminal: true, Value: "a"}, {IsTerminal: true, Value: "|"}, {IsTerminal: true, Value: "b"}},
+		{LiteralEpsilonSymbol},
+		{{IsTerminal: true, Value: " "}, {IsTerminal: true, Value: "\\"}},
+		{EpsilonSymbol},
+		{{IsTerminal: true, Value: "\t"}, {IsTerminal: true, Value: "\n"}, {IsTerminal: true, Value: "\r"}},
+		{{IsTerminal: true, Value: "\u00A0"}, {IsTerminal: true, Value: "é"}},
+	}
+	if !compareProductionSlices(grammars[0].Productions[S], expected) {
+		t.Errorf("Expected bodies %v, but got %v", expected, grammars[0].Productions[S])
+	}
+}
+
+func TestParseGrammarsEscapeErrors(t *testing.T) {
+	_, err := ParseGrammars("test.txt", []string{`S -> a\q|b\`})
+
+	var parseErrors ParseErrors
+	if !errors.As(err, &parseErrors) || len(parseErrors) != 2 {
+		t.Fatalf("Expected 2 errors, but got %v", err)
+	}
+	if parseErrors[0].Column != 7 || parseErrors[0].Char != `\q` || parseErrors[0].Message != "unknown escape sequence" {
+		t.Errorf("Unexpected error %v", parseErrors[0])
+	}
+	if parseErrors[1].Column != 11 || parseErrors[1].Message != "unfinished escape sequence" {
+		t.Errorf("Unexpected error %v", parseErrors[1])
+	}
+}
+
+func TestParseGrammarSections(t *testing.T) {
+	lines := []string{
+		"### grammar anbn",
+		"S -> a{S}b|ε",
+		"accept:",
+		"accept: aabb",
+		"reject:  aab ",
+		"---",
+		"A -> a",
+		"reject: ε",
+		"### grammar expr",
+		"accept: x+x",
+		"E -> {E}+x|x",
+	}
+
+	sections, err := ParseGrammarSections("test.txt", lines)
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+
+	expected := []GrammarSection{
+		{Name: "anbn", Line: 1, Examples: []Example{{Input: "", Accept: true, Line: 3}, {Input: "aabb", Accept: true, Line: 4}, {Input: "aab", Accept: false, Line: 5}}},
+		{Name: "", Line: 7, Examples: []Example{{Input: "", Accept: false, Line: 8}}},
+		{Name: "expr", Line: 9, Examples: []Example{{Input: "x+x", Accept: true, Line: 10}}},
+	}
+	grammars := []string{"{S_0} -> a{S_0}b|ε\n", "{A_0} -> a\n", "{E_0} -> {E_0}+x|x\n"}
+	if len(sections) != len(expected) {
+		t.Fatalf("Expected %d sections, but got %d", len(expected), len(sections))
+	}
+	for i, section := range sections {
+		if section.Name != expected[i].Name || section.Line != expected[i].Line || !reflect.DeepEqual(section.Examples, expected[i].Examples) {
+			t.Errorf("Expected section %d to be %+v, but got %+v", i, expected[i], *section)
+		}
+		if section.Grammar.String(false) != grammars[i] {
+			t.Errorf("Expected grammar %d to be %q, but got %q", i, grammars[i], section.Grammar.String(false))
+		}
+	}
+
+	// ParseGrammars reads the same file, without the sections
+	parsed, err := ParseGrammars("test.txt", lines)
+	if err != nil || len(parsed) != len(sections) {
+		t.Fatalf("Expected %d grammars, but got %d and %v", len(sections), len(parsed), err)
+	}
+}
+
+func TestParseGrammarSectionsErrors(t *testing.T) {
+	lines := []string{
+		"### grammar",
+		"S -> a",
+		"### grammar a",
+		"S -> a",
+		"### grammar a",
+		"S -> b",
+		"---",
+		"reject: b",
+	}
+
+	expected := []ParseError{
+		{Line: 1, Column: 12, Char: "", Message: "missing grammar name"},
+		{Line: 5, Column: 13, Char: "a", Message: "grammar \"a\" is already defined"},
+		{Line: 8, Column: 1, Char: "reject:", Message: "examples without a grammar"},
+	}
+
+	_, err := ParseGrammarSections("test.txt", lines)
+
+	var parseErrors ParseErrors
+	if !errors.As(err, &parseErrors) {
+		t.Fatalf("Expected ParseErrors, but got %v", err)
+	}
+	if len(parseErrors) != len(expected) {
+		t.Fatalf("Expected %d errors, but got %d:\n%v", len(expected), len(parseErrors), err)
+	}
+	for i, e := range expected {
+		got := parseErrors[i]
+		if got.Line != e.Line || got.Column != e.Column || got.Char != e.Char || got.Message != e.Message || got.Hint == "" {
+			t.Errorf("Expected error %d to be %v, but got %v", i, e, got)
+		}
+	}
+}
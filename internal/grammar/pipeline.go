@@ -0,0 +1,459 @@
+package grammar
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+/*
+Pipeline de pasadas de simplificación. Cada pasada (Pass) recibe una gramática
+y devuelve la gramática transformada, y un Pipeline las aplica en orden:
+
+	pipeline := NewPipeline(EpsilonPass(), UnitPass()).Add(UselessPass())
+	result := pipeline.Run(g, false, nil)
+
+Las pasadas incluidas tienen nombre, y ParsePipeline arma un pipeline a partir
+del nombre de uno de los pipelines predefinidos o de una lista de pasadas
+separadas por comas:
+
+	simplify  left-factor, left-recursion, epsilon, unit, useless, chomsky
+	cnf       epsilon, unit, useless, chomsky
+	gnf       epsilon, unit, useless, greibach
+	ll1-prep  left-factor, left-recursion
+	clean     unit, useless
+
+La pasada epsilon recuerda si el símbolo inicial era anulable, para que las
+pasadas chomsky y greibach conserven la cadena vacía con S' -> ε. Las pasadas no imprimen
+nada, avisan de cada paso al Observer con el que se corre el pipeline.
+*/
+
+// Names of the passes included in the package.
+const (
+	PassLeftFactor    = "left-factor"
+	PassLeftRecursion = "left-recursion"
+	PassEpsilon       = "epsilon"
+	PassUnit          = "unit"
+	PassUseless       = "useless"
+	PassChomsky       = "chomsky"
+	PassGreibach      = "greibach"
+)
+
+// Names of the predefined pipelines.
+const (
+	PipelineSimplify = "simplify"
+	PipelineCNF      = "cnf"
+	PipelineGNF      = "gnf"
+	PipelineLL1Prep  = "ll1-prep"
+	PipelineClean    = "clean"
+)
+
+// A step of a Pipeline, that transforms a grammar.
+type Pass interface {
+	// Name used to select or skip the pass, like "epsilon".
+	Name() string
+	// Transforms the grammar, reporting the grammar of every stage it
+	// reaches with ctx.Stage.
+	Apply(g *Grammar, ctx *PassContext) *Grammar
+}
+
+// State shared by the passes of a single run of a pipeline.
+type PassContext struct {
+	pass          string // Name of the pass being applied.
+	startNullable bool   // True if the epsilon pass found that the start symbol was nullable.
+	observer      Observer
+}
+
+// Sends an event of the current pass to the observer of the pipeline.
+func (ctx *PassContext) Notify(event Event) {
+	event.Pass = ctx.pass
+	ctx.observer.notify(event)
+}
+
+// Reports the grammar of a stage of the pass, described by title. The
+// grammar must not be modified after reporting it. If the stage must have
+// a property, like the epsilon-free stage, it is checked and the productions
+// that break it are reported with an EventViolations.
+func (ctx *PassContext) Stage(name string, title string, g *Grammar) {
+	ctx.Notify(Event{Kind: EventGrammar, Title: title, Stage: name, Grammar: g})
+	if title, violations := stageViolations(name, g); len(violations) > 0 {
+		ctx.Notify(Event{Kind: EventViolations, Title: title, Stage: name, Violations: violations})
+	}
+}
+
+// A list of passes applied one after the other.
+type Pipeline struct {
+	passes []Pass
+}
+
+// Creates a pipeline with the given passes.
+func NewPipeline(passes ...Pass) *Pipeline {
+	return &Pipeline{passes: append([]Pass{}, passes...)}
+}
+
+// Returns: a new pipeline with the passes of p followed by the given ones.
+func (p *Pipeline) Add(passes ...Pass) *Pipeline {
+	return NewPipeline(append(append([]Pass{}, p.passes...), passes...)...)
+}
+
+// Returns: a new pipeline with the passes of p, except the ones with the
+// given names.
+func (p *Pipeline) Without(names ...string) *Pipeline {
+	result := NewPipeline()
+	for _, pass := range p.passes {
+		skip := false
+		for _, name := range names {
+			skip = skip || pass.Name() == name
+		}
+		if !skip {
+			result.passes = append(result.passes, pass)
+		}
+	}
+	return result
+}
+
+// Returns: the passes of the pipeline, in order.
+func (p *Pipeline) Passes() []Pass {
+	return append([]Pass{}, p.passes...)
+}
+
+// Returns: the names of the passes of the pipeline, in order.
+func (p *Pipeline) Names() []string {
+	names := make([]string, 0, len(p.passes))
+	for _, pass := range p.passes {
+		names = append(names, pass.Name())
+	}
+	return names
+}
+
+// Returns: true if the pipeline has a pass with the given name.
+func (p *Pipeline) Has(name string) bool {
+	for _, pass := range p.passes {
+		if pass.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns: the pipeline itself if it has the chomsky pass, or a new pipeline
+// with its passes followed by the ones of CNFPipeline. All the passes run
+// with the same context, so the chomsky pass knows if the first epsilon pass
+// found the start symbol nullable and keeps ε.
+func (p *Pipeline) WithChomsky() *Pipeline {
+	if p.Has(PassChomsky) {
+		return p
+	}
+	return p.Add(CNFPipeline().Passes()...)
+}
+
+// Applies every pass to the grammar, calling onStage with the name and the
+// grammar of every stage as soon as it is reached, starting with the
+// original grammar. onStage may be nil and must not modify the grammar. If
+// printSteps is true the steps are written to the standard output.
+func (p *Pipeline) Run(g *Grammar, printSteps bool, onStage func(stage string, g *Grammar)) *Grammar {
+	return p.RunWith(g, MultiObserver(printObserver(printSteps), stageObserver(onStage)))
+}
+
+// Applies every pass to the grammar, sending every step to the observer,
+// which may be nil. The first event is the original grammar, outside of any
+// pass, and then every pass starts with an EventPassStarted.
+func (p *Pipeline) RunWith(g *Grammar, observer Observer) *Grammar {
+	ctx := &PassContext{observer: observer}
+	ctx.Stage(StageOriginal, "Gramática ANTES de la simplificación", g)
+
+	// Sin no terminales no hay nada que transformar, pero el resultado
+	// tampoco debe ser la gramática recibida
+	if len(g.NonTerminals) == 0 {
+		return g.Clone()
+	}
+
+	for _, pass := range p.passes {
+		ctx.pass = pass.Name()
+		title := pass.Name()
+		if titled, ok := pass.(interface{ Title() string }); ok {
+			title = titled.Title()
+		}
+		ctx.Notify(Event{Kind: EventPassStarted, Title: title})
+		g = pass.Apply(g, ctx)
+	}
+	return g
+}
+
+// Applies every pass to the grammar like Run, keeping a copy of the grammar
+// of every stage.
+func (p *Pipeline) RunResult(g *Grammar, printSteps bool) *Simplification {
+	return p.RunResultWith(g, printObserver(printSteps))
+}
+
+// Applies every pass to the grammar like RunWith, keeping a copy of the
+// grammar of every stage.
+func (p *Pipeline) RunResultWith(g *Grammar, observer Observer) *Simplification {
+	result := &Simplification{Stages: make([]SimplificationStage, 0)}
+	result.Grammar = p.RunWith(g, MultiObserver(func(event Event) {
+		if event.Kind == EventViolations && len(result.Stages) > 0 {
+			// Las violaciones son de la etapa que se acaba de reportar
+			last := &result.Stages[len(result.Stages)-1]
+			last.Violations = append(last.Violations, event.Violations...)
+			return
+		}
+		if event.Kind != EventGrammar || event.Stage == "" {
+			return
+		}
+		// Se guarda una copia para que los observadores no compartan memoria
+		// con la gramática que se devuelve
+		result.Stages = append(result.Stages, SimplificationStage{
+			Name:    event.Stage,
+			Pass:    event.Pass,
+			Title:   event.Title,
+			Grammar: event.Grammar.Clone(),
+			Metrics: event.Grammar.Metrics(),
+		})
+	}, observer))
+	return result
+}
+
+// Returns: an observer that writes the events to the standard output, or
+// nil if printSteps is false.
+func printObserver(printSteps bool) Observer {
+	if !printSteps {
+		return nil
+	}
+	return TextObserver(os.Stdout)
+}
+
+// Returns: an observer that calls onStage with the grammar of every stage,
+// or nil if onStage is nil.
+func stageObserver(onStage func(stage string, g *Grammar)) Observer {
+	if onStage == nil {
+		return nil
+	}
+	return func(event Event) {
+		if event.Kind == EventGrammar && event.Stage != "" {
+			onStage(event.Stage, event.Grammar)
+		}
+	}
+}
+
+// Returns: the pipeline used by SimplifyGrammar, that leaves the grammar in
+// Chomsky Normal Form after factoring it and removing left recursion.
+func SimplifyPipeline() *Pipeline {
+	return NewPipeline(LeftFactorPass(), LeftRecursionPass(), EpsilonPass(), UnitPass(), UselessPass(), ChomskyPass())
+}
+
+// Returns: a pipeline that only converts the grammar to Chomsky Normal Form.
+func CNFPipeline() *Pipeline {
+	return NewPipeline(EpsilonPass(), UnitPass(), UselessPass(), ChomskyPass())
+}
+
+// Returns: a pipeline that only converts the grammar to Greibach Normal Form.
+func GNFPipeline() *Pipeline {
+	return NewPipeline(EpsilonPass(), UnitPass(), UselessPass(), GreibachPass())
+}
+
+// Returns: a pipeline that prepares the grammar for an LL(1) parser,
+// factoring it and removing left recursion.
+func LL1PrepPipeline() *Pipeline {
+	return NewPipeline(LeftFactorPass(), LeftRecursionPass())
+}
+
+// Returns: a pipeline that removes unit productions and useless symbols.
+func CleanPipeline() *Pipeline {
+	return NewPipeline(UnitPass(), UselessPass())
+}
+
+var pipelines = map[string]func() *Pipeline{
+	PipelineSimplify: SimplifyPipeline,
+	PipelineCNF:      CNFPipeline,
+	PipelineGNF:      GNFPipeline,
+	PipelineLL1Prep:  LL1PrepPipeline,
+	PipelineClean:    CleanPipeline,
+}
+
+var passes = map[string]func() Pass{
+	PassLeftFactor:    LeftFactorPass,
+	PassLeftRecursion: LeftRecursionPass,
+	PassEpsilon:       EpsilonPass,
+	PassUnit:          UnitPass,
+	PassUseless:       UselessPass,
+	PassChomsky:       ChomskyPass,
+	PassGreibach:      GreibachPass,
+}
+
+// Creates a pipeline from the name of a predefined pipeline, like "cnf", or
+// from a list of pass names separated by commas, like "epsilon,unit".
+//
+// Returns: the pipeline, or an error naming the valid names if a name is unknown.
+func ParsePipeline(spec string) (*Pipeline, error) {
+	if newPipeline, exist := pipelines[strings.TrimSpace(spec)]; exist {
+		return newPipeline(), nil
+	}
+
+	result := NewPipeline()
+	for _, name := range strings.Split(spec, ",") {
+		pass, err := PassByName(name)
+		if err != nil {
+			return nil, fmt.Errorf("%w, or use one of the pipelines %s", err, strings.Join(sortedKeys(pipelines), ", "))
+		}
+		result.passes = append(result.passes, pass)
+	}
+	return result, nil
+}
+
+// Returns: the pass with the given name, or an error naming the valid passes.
+func PassByName(name string) (Pass, error) {
+	if newPass, exist := passes[strings.TrimSpace(name)]; exist {
+		return newPass(), nil
+	}
+	return nil, fmt.Errorf("unknown pass %q, the passes are %s", strings.TrimSpace(name), strings.Join(sortedKeys(passes), ", "))
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// A pass made of a function.
+type funcPass struct {
+	name  string
+	title string // Title of the EventPassStarted of the pass.
+	apply func(g *Grammar, ctx *PassContext) *Grammar
+}
+
+func (p funcPass) Name() string {
+	return p.name
+}
+
+func (p funcPass) Title() string {
+	return p.title
+}
+
+func (p funcPass) Apply(g *Grammar, ctx *PassContext) *Grammar {
+	return p.apply(g, ctx)
+}
+
+// Creates a custom pass from a function. The grammar it returns is reported
+// as a stage with the name of the pass.
+func NewPass(name string, apply func(g *Grammar) *Grammar) Pass {
+	return funcPass{name: name, title: name, apply: func(g *Grammar, ctx *PassContext) *Grammar {
+		result := apply(g)
+		ctx.Stage(name, "Gramática DESPUÉS de "+name, result)
+		return result
+	}}
+}
+
+// Returns: the pass that factors the grammar by the left.
+func LeftFactorPass() Pass {
+	return funcPass{name: PassLeftFactor, title: "FACTORIZACIÓN POR LA IZQUIERDA", apply: func(g *Grammar, ctx *PassContext) *Grammar {
+		factorizedGrammar := factorizeGrammar(g)
+		ctx.Stage(StageLeftFactored, "Gramática DESPUÉS de factorizar por la izquierda", factorizedGrammar)
+		return factorizedGrammar
+	}}
+}
+
+// Returns: the pass that removes left recursion.
+func LeftRecursionPass() Pass {
+	return funcPass{name: PassLeftRecursion, title: "REMOVER RECURSIÓN POR LA IZQUIERDA", apply: func(g *Grammar, ctx *PassContext) *Grammar {
+		// Los no terminales de un ciclo quedan igual y la etapa los reporta
+		// como violaciones, con el ciclo de cada producción
+		grammarWithouthRecursion, _ := RemoveLeftRecursion(g)
+		ctx.Stage(StageLeftRecursionFree, "Gramática DESPUÉS de remover la recursión por la izquierda", grammarWithouthRecursion)
+		return grammarWithouthRecursion
+	}}
+}
+
+// Returns: the pass that removes the ε productions. The empty string is
+// removed from the language, the chomsky pass adds it back.
+func EpsilonPass() Pass {
+	return funcPass{name: PassEpsilon, title: "ELIMINACIÓN DE EPSILON", apply: func(g *Grammar, ctx *PassContext) *Grammar {
+		// Paso 1: Identificar los símbolos directos anulables
+		directNullables := identifyDirectNullables(g)
+		ctx.Notify(Event{Kind: EventNullables, Title: "Símbolos anulables directos encontrados", Symbols: *directNullables})
+
+		// Paso 2: Identificar todos los símbolos anulables (directos e indirectos)
+		allNullables := identifyIndirectNullables(g, *directNullables)
+		ctx.Notify(Event{Kind: EventNullables, Title: "Todos los símbolos anulables encontrados", Symbols: *allNullables})
+
+		// El lenguaje contiene ε si el símbolo inicial es anulable
+		ctx.startNullable = ctx.startNullable || containsSymbol(*allNullables, g.GetStartSymbol())
+
+		// Paso 3: Reemplazar los símbolos anulables en las producciones
+		grammarWithoutEpsilons := ReplaceNullables(g, *allNullables)
+		ctx.Stage(StageNullablesReplaced, "Gramática DESPUÉS de reemplazar los anulables", grammarWithoutEpsilons)
+
+		// Paso 4: Eliminar producciones épsilon
+		finalGrammar := RemoveEpsilons(grammarWithoutEpsilons)
+		ctx.Stage(StageEpsilonFree, "Gramática DESPUÉS de eliminar las producciones epsilon", finalGrammar)
+		return finalGrammar
+	}}
+}
+
+// Returns: the pass that removes unit productions.
+func UnitPass() Pass {
+	return funcPass{name: PassUnit, title: "ELIMINACIÓN DE PRODUCCIONES UNARIAS", apply: func(g *Grammar, ctx *PassContext) *Grammar {
+		if ctx.observer != nil {
+			ctx.Notify(Event{Kind: EventUnitPairs, Title: "Pares unarios encontrados", UnitPairs: FindUnaryPairs(InitializeUnaryPairs(g))})
+		}
+		finalGrammar := RemoveUnaryProductions(g, g.NonTerminals)
+		ctx.Stage(StageUnitFree, "Gramática DESPUÉS de eliminar producciones unarias", finalGrammar)
+		return finalGrammar
+	}}
+}
+
+// Returns: the pass that removes the symbols that are not generating or
+// not reachable from the start symbol.
+func UselessPass() Pass {
+	return funcPass{name: PassUseless, title: "ELIMINACIÓN DE SÍMBOLOS INÚTILES", apply: func(g *Grammar, ctx *PassContext) *Grammar {
+		finalGrammar := RemoveUselessSymbols(g, g.GetStartSymbol())
+		ctx.Stage(StageUseful, "Gramática DESPUÉS de eliminar símbolos inútiles", finalGrammar)
+		return finalGrammar
+	}}
+}
+
+// Returns: the pass that converts a grammar without ε and unit productions
+// to Chomsky Normal Form.
+func ChomskyPass() Pass {
+	return funcPass{name: PassChomsky, title: "SIMPLIFICACIÓN A FORMA NORMAL DE CHOMSKY", apply: func(g *Grammar, ctx *PassContext) *Grammar {
+		ncfGrammar0 := CNFAddStartSymbol(g, ctx.startNullable)
+		ctx.Stage(StageCNFStartSymbol, "Gramática DESPUÉS de agregar el nuevo símbolo inicial", ncfGrammar0)
+
+		ncfGrammar1 := CNFTerminalSubstitution(ncfGrammar0)
+		ctx.Stage(StageCNFTerminals, "Gramática DESPUÉS de normalizar el paso 1 de Chomsky", ncfGrammar1)
+
+		ncfGrammar2 := CNFSplitLargeProductions(ncfGrammar1)
+
+		// Mantener el símbolo inicial como el primer no terminal de la gramática resultante
+		ncfGrammar2.NonTerminals = moveSymbolToFront(ncfGrammar2.NonTerminals, ncfGrammar2.GetStartSymbol())
+
+		sortGrammar := OrderProductionsByNonTerminals(ncfGrammar2)
+		ctx.Stage(StageChomskyNormalForm, "Gramática DESPUÉS de normalizar el paso 2 de Chomsky", sortGrammar)
+		return sortGrammar
+	}}
+}
+
+// Returns: the pass that converts a grammar without ε and unit productions
+// to Greibach Normal Form. The substitutions may make the grammar grow
+// exponentially, so it is only practical for small grammars.
+func GreibachPass() Pass {
+	return funcPass{name: PassGreibach, title: "SIMPLIFICACIÓN A FORMA NORMAL DE GREIBACH", apply: func(g *Grammar, ctx *PassContext) *Grammar {
+		gnfGrammar0 := GNFRemoveLeftRecursion(g)
+		ctx.Stage(StageGNFLeftRecursionFree, "Gramática DESPUÉS de ordenar los no terminales y remover la recursión por la izquierda", gnfGrammar0)
+
+		gnfGrammar1 := GNFSubstituteLeadingNonTerminals(gnfGrammar0)
+		ctx.Stage(StageGNFLeadingTerminals, "Gramática DESPUÉS de sustituir los no terminales al inicio de los cuerpos", gnfGrammar1)
+
+		gnfGrammar2 := GNFTerminalSubstitution(gnfGrammar1)
+		// La cadena vacía vuelve con S' -> ε, como en la forma normal de Chomsky
+		if ctx.startNullable {
+			gnfGrammar2 = CNFAddStartSymbol(gnfGrammar2, true)
+		}
+		gnfGrammar2.NonTerminals = moveSymbolToFront(gnfGrammar2.NonTerminals, gnfGrammar2.GetStartSymbol())
+		ctx.Stage(StageGreibachNormalForm, "Gramática DESPUÉS de reemplazar los terminales que no están al inicio", gnfGrammar2)
+		return gnfGrammar2
+	}}
+}
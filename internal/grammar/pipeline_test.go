@@ -0,0 +1,176 @@
+package grammar
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePipeline(t *testing.T) {
+	tests := map[string][]string{
+		"simplify":        {"left-factor", "left-recursion", "epsilon", "unit", "useless", "chomsky"},
+		"cnf":             {"epsilon", "unit", "useless", "chomsky"},
+		"ll1-prep":        {"left-factor", "left-recursion"},
+		"clean":           {"unit", "useless"},
+		"epsilon, unit":   {"epsilon", "unit"},
+		"useless,useless": {"useless", "useless"},
+	}
+	for spec, expected := range tests {
+		pipeline, err := ParsePipeline(spec)
+		if err != nil {
+			t.Fatalf("%s: expected no errors, but got %v", spec, err)
+		}
+		if !reflect.DeepEqual(pipeline.Names(), expected) {
+			t.Errorf("%s: expected passes %v, but got %v", spec, expected, pipeline.Names())
+		}
+	}
+
+	for _, spec := range []string{"", "epsilon,", "cnf,unit", "eps"} {
+		if _, err := ParsePipeline(spec); err == nil {
+			t.Errorf("%q: expected an error", spec)
+		}
+	}
+}
+
+func TestPipelineBuilder(t *testing.T) {
+	pipeline := NewPipeline(EpsilonPass())
+	added := pipeline.Add(UnitPass(), UselessPass())
+	skipped := added.Without(PassUnit, PassChomsky)
+
+	if !reflect.DeepEqual(pipeline.Names(), []string{"epsilon"}) {
+		t.Errorf("Expected Add to leave the pipeline unchanged, but got %v", pipeline.Names())
+	}
+	if !reflect.DeepEqual(added.Names(), []string{"epsilon", "unit", "useless"}) {
+		t.Errorf("Expected epsilon, unit and useless, but got %v", added.Names())
+	}
+	if !reflect.DeepEqual(skipped.Names(), []string{"epsilon", "useless"}) {
+		t.Errorf("Expected epsilon and useless, but got %v", skipped.Names())
+	}
+	if !skipped.Has(PassUseless) || skipped.Has(PassUnit) {
+		t.Errorf("Expected Has to find useless but not unit")
+	}
+}
+
+func TestPipelineStages(t *testing.T) {
+	grammars, err := ParseGrammars("test.txt", []string{"S -> a{S}b|{A}", "A -> ε|c"})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+
+	stages := []string{}
+	SimplifyPipeline().Run(grammars[0], false, func(stage string, g *Grammar) {
+		stages = append(stages, stage)
+	})
+	expected := []string{
+		StageOriginal, StageLeftFactored, StageLeftRecursionFree, StageNullablesReplaced, StageEpsilonFree,
+		StageUnitFree, StageUseful, StageCNFStartSymbol, StageCNFTerminals, StageChomskyNormalForm,
+	}
+	if !reflect.DeepEqual(stages, expected) {
+		t.Errorf("Expected stages %v, but got %v", expected, stages)
+	}
+
+	// A custom pass reports a stage with its name
+	reversed := NewPass("reversed", func(g *Grammar) *Grammar {
+		result := &Grammar{Productions: make(map[Symbol][][]Symbol)}
+		for _, head := range g.NonTerminals {
+			for _, body := range g.Productions[head] {
+				reversedBody := make([]Symbol, len(body))
+				for i, symbol := range body {
+					reversedBody[len(body)-1-i] = symbol
+				}
+				result.addProductionSymbols(head, [][]Symbol{reversedBody})
+			}
+		}
+		return result
+	})
+	result := NewPipeline(reversed).RunResult(grammars[0], false)
+	if len(result.Stages) != 2 || result.Stages[1].Name != "reversed" {
+		t.Fatalf("Expected the original and reversed stages, but got %v", result.Stages)
+	}
+	expectedGrammar := "{S_0} -> b{S_0}a|{A_0}\n{A_0} -> ε|c\n"
+	if result.Grammar.String(false) != expectedGrammar {
+		t.Errorf("Expected %q, but got %q", expectedGrammar, result.Grammar.String(false))
+	}
+}
+
+func TestCNFPipelineKeepsLanguage(t *testing.T) {
+	sections, err := ParseGrammarSections("test.txt", []string{
+		"S -> a{S}b|{A}",
+		"A -> ε|c",
+		"accept:",
+		"accept: acb",
+		"accept: aabb",
+		"reject: ab c",
+		"reject: acbb",
+	})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+
+	// The chomsky pass keeps ε when the epsilon pass found the start symbol nullable
+	cnf := CNFPipeline().Run(sections[0].Grammar, false, nil)
+	for _, result := range CheckExamples(cnf, sections[0].Examples) {
+		if !result.Passed {
+			t.Errorf("Expected %q to be accepted: %v, but got %v", result.Input, result.Accept, result.Accepted)
+		}
+	}
+}
+
+// The stages must not depend on the order of the maps, so that saved stages
+// and exported grammars are the same in every run
+func TestPipelinesAreDeterministic(t *testing.T) {
+	g := parseTestGrammar(t, "E -> {E}+{T}|{T}|{U}", "T -> {T}*{F}|{F}", "F -> ({E})|a|{N}a|{N}b", "N -> n|ε", "U -> {U}u", "V -> v")
+
+	for _, pipeline := range []*Pipeline{SimplifyPipeline(), GNFPipeline()} {
+		expected := pipeline.RunResult(g, false)
+		for run := 0; run < 20; run++ {
+			result := pipeline.RunResult(g, false)
+			for i, stage := range result.Stages {
+				if grammarSnapshot(stage.Grammar) != grammarSnapshot(expected.Stages[i].Grammar) {
+					t.Fatalf("Expected %s to be\n%q,\n but got %q", stage.Name, grammarSnapshot(expected.Stages[i].Grammar), grammarSnapshot(stage.Grammar))
+				}
+			}
+		}
+	}
+}
+
+func TestPipelineWithoutNonTerminals(t *testing.T) {
+	g := &Grammar{Productions: make(map[Symbol][][]Symbol)}
+
+	result := SimplifyPipeline().RunResult(g, false)
+	if len(result.Stages) != 1 || result.Stages[0].Name != StageOriginal {
+		t.Fatalf("Expected only the original stage, but got %v", result.Stages)
+	}
+	if result.Grammar == g {
+		t.Errorf("Expected a copy of the grammar, but got the same grammar")
+	}
+}
+
+func TestWithChomskyKeepsEmptyString(t *testing.T) {
+	sections, err := ParseGrammarSections("test.txt", []string{
+		"S -> a{S}b|ε",
+		"accept:",
+		"accept: ab",
+		"accept: aaabbb",
+		"reject: aab",
+		"reject: ba",
+	})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+
+	cnfWithoutChomsky := CNFPipeline().Without(PassChomsky)
+	for _, pipeline := range []*Pipeline{NewPipeline(EpsilonPass()), NewPipeline(EpsilonPass(), UnitPass(), UselessPass()), cnfWithoutChomsky} {
+		// La pasada epsilon de pipeline es la que sabe que S genera ε
+		cnf := pipeline.WithChomsky().Run(sections[0].Grammar, false, nil)
+		for _, result := range CheckExamples(cnf, sections[0].Examples) {
+			if !result.Passed {
+				t.Errorf("%v: Expected %q to be accepted: %v, but got %v", pipeline.Names(), result.Input, result.Accept, result.Accepted)
+			}
+		}
+	}
+
+	simplify := SimplifyPipeline()
+	if simplify.WithChomsky() != simplify {
+		t.Errorf("Expected a pipeline with the chomsky pass to be kept, but got %v", simplify.WithChomsky().Names())
+	}
+}
@@ -0,0 +1,59 @@
+package grammar
+
+import (
+	"fmt"
+)
+
+/*
+Propiedades que deben cumplir las pasadas con cualquier gramática. Sirven
+para probar las pasadas con las gramáticas de RandomGrammar:
+
+	for seed := int64(0); seed < 1000; seed++ {
+		g := RandomGrammar(seed, DefaultRandomGrammarConfig())
+		for _, violation := range CheckPassProperties(g, SimplifyPipeline(), 4) {
+			fmt.Println(seed, violation)
+		}
+	}
+
+Se revisa el resultado de cada pasada del pipeline con los validadores que
+el pipeline aplica a cada etapa (la pasada epsilon no deja ε, la pasada
+useless solo deja símbolos generadores y alcanzables, la pasada chomsky deja
+la gramática en forma normal de Chomsky, ...), y que ninguna etapa cambie el
+lenguaje de las cadenas de hasta N terminales.
+*/
+
+// A property that a pass did not keep.
+type PropertyViolation struct {
+	Property string `json:"property"`
+	Pass     string `json:"pass"`
+	Detail   string `json:"detail"`
+}
+
+func (v PropertyViolation) String() string {
+	return fmt.Sprintf("%s after %s: %s", v.Property, v.Pass, v.Detail)
+}
+
+// Applies the pipeline to the grammar and checks the result of every pass,
+// comparing the languages of the stages with strings of up to maxLength
+// terminals. A property is only checked if the pipeline has its pass.
+//
+// Returns: the properties that were not kept, empty if all of them were.
+func CheckPassProperties(g *Grammar, pipeline *Pipeline, maxLength int) []PropertyViolation {
+	violations := make([]PropertyViolation, 0)
+	simplification := pipeline.RunResult(g, false)
+
+	for _, stage := range simplification.Stages {
+		for _, violation := range stage.Violations {
+			violations = append(violations, PropertyViolation{Property: violation.Property, Pass: stage.Pass, Detail: violation.String()})
+		}
+	}
+
+	if change := VerifyStages(simplification.Stages, maxLength).Change; change != nil {
+		detail := fmt.Sprintf("%q is accepted by %s but not by %s", change.Input, change.Previous, change.Stage)
+		if !change.Accepted {
+			detail = fmt.Sprintf("%q is accepted by %s but not by %s", change.Input, change.Stage, change.Previous)
+		}
+		violations = append(violations, PropertyViolation{Property: PropertyLanguageKept, Pass: change.Pass, Detail: detail})
+	}
+	return violations
+}
@@ -0,0 +1,179 @@
+package grammar
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Configuraciones de las pruebas con gramáticas aleatorias
+func randomGrammarConfigs() map[string]RandomGrammarConfig {
+	configs := map[string]RandomGrammarConfig{"default": DefaultRandomGrammarConfig()}
+
+	large := DefaultRandomGrammarConfig()
+	large.NonTerminals = 5
+	large.MaxBodyLength = 4
+	large.EpsilonRatio = 0.25
+	large.UnaryRatio = 0.25
+	large.UselessSymbols = 2
+	configs["large"] = large
+
+	unary := DefaultRandomGrammarConfig()
+	unary.NonTerminals = 4
+	unary.EpsilonRatio = 0.1
+	unary.UnaryRatio = 0.5
+	unary.LeftRecursionRatio = 0
+	configs["unary"] = unary
+	return configs
+}
+
+func TestRandomGrammar(t *testing.T) {
+	config := DefaultRandomGrammarConfig()
+	if RandomGrammar(7, config).String(true) != RandomGrammar(7, config).String(true) {
+		t.Errorf("Expected the same grammar for the same seed")
+	}
+
+	config.NonTerminals = 4
+	config.Terminals = 3
+	config.EpsilonRatio = 0
+	config.UnaryRatio = 0
+	config.LeftRecursionRatio = 1
+	config.UselessSymbols = 2
+	for seed := int64(0); seed < 50; seed++ {
+		g := RandomGrammar(seed, config)
+		if g.GetStartSymbol().Value != "S" || len(g.Productions) != 6 {
+			t.Fatalf("Expected S and 5 more NON terminals, but got %q", g.String(true))
+		}
+		for _, head := range orderedHeads(g) {
+			for _, body := range g.Productions[head] {
+				if len(body) > config.MaxBodyLength || containsSymbol(body, EpsilonSymbol) {
+					t.Fatalf("Expected bodies without ε of up to %d symbols, but got %q", config.MaxBodyLength, g.String(true))
+				}
+				// Todos los cuerpos son recursivos, salvo los que usan a los símbolos inútiles
+				if head.Value[0] != 'U' && body[0] != head && !containsSymbol(body, Symbol{Value: "U"}) {
+					t.Fatalf("Expected every body of %s to be left recursive, but got %q", head.String(), g.String(true))
+				}
+			}
+		}
+		if violations := uselessViolations(g); len(violations) < 2 {
+			t.Fatalf("Expected the useless symbols to be found, but got %v", violations)
+		}
+	}
+}
+
+func TestPassPropertiesRandomGrammars(t *testing.T) {
+	seeds := int64(1000)
+	if testing.Short() {
+		seeds = 200
+	}
+
+	pipelines := map[string]*Pipeline{
+		PipelineCNF:      CNFPipeline(),
+		PipelineSimplify: SimplifyPipeline(),
+		PipelineLL1Prep:  LL1PrepPipeline(),
+		"left-factor":    NewPipeline(LeftFactorPass()).Add(CNFPipeline().Passes()...),
+	}
+	for name, config := range randomGrammarConfigs() {
+		for pipelineName, pipeline := range pipelines {
+			for seed := int64(0); seed < seeds; seed++ {
+				g := RandomGrammar(seed, config)
+				violations := CheckPassProperties(g, pipeline, 4)
+				// Con ciclos solo quedan recursivos los no terminales que reporta el error
+				stuck := stuckLeftRecursionHeads(t, g, pipeline)
+				for _, violation := range violations {
+					if violation.Property != PropertyLeftRecursionFree || !violationOfHeads(violation, stuck) {
+						t.Fatalf("%s grammar with seed %d and the %s pipeline:\n%s\n%v", name, seed, pipelineName, g.String(false), violations)
+					}
+				}
+			}
+		}
+	}
+}
+
+// Returns: the NON terminals whose left recursion RemoveLeftRecursion can not
+// remove because of a cycle, for the grammar that reaches the left-recursion
+// pass of the pipeline.
+func stuckLeftRecursionHeads(t *testing.T, g *Grammar, pipeline *Pipeline) []Symbol {
+	t.Helper()
+	if !pipeline.Has(PassLeftRecursion) {
+		return nil
+	}
+	stages := pipeline.RunResult(g, false).Stages
+	for i, stage := range stages {
+		if stage.Name != StageLeftRecursionFree {
+			continue
+		}
+		_, err := RemoveLeftRecursion(stages[i-1].Grammar)
+		var cycleError *LeftRecursionCycleError
+		if err != nil && !errors.As(err, &cycleError) {
+			t.Fatalf("Expected no errors other than the cycles, but got:\n%v", err)
+		}
+		if cycleError != nil {
+			return cycleError.Heads
+		}
+	}
+	return nil
+}
+
+// Returns: true if the violation is of a production of one of the heads.
+func violationOfHeads(violation PropertyViolation, heads []Symbol) bool {
+	for _, head := range heads {
+		if strings.HasPrefix(violation.Detail, head.String()+" -> ") {
+			return true
+		}
+	}
+	return false
+}
+
+// La forma normal de Greibach crece exponencialmente con las sustituciones,
+// así que se prueba con gramáticas más pequeñas
+func TestPassPropertiesGreibach(t *testing.T) {
+	seeds := int64(1000)
+	if testing.Short() {
+		seeds = 200
+	}
+
+	config := RandomGrammarConfig{NonTerminals: 3, Terminals: 2, MaxBodies: 2, MaxBodyLength: 3, EpsilonRatio: 0.1, UnaryRatio: 0.15, LeftRecursionRatio: 0.2, UselessSymbols: 1}
+	for seed := int64(0); seed < seeds; seed++ {
+		g := RandomGrammar(seed, config)
+		if violations := CheckPassProperties(g, GNFPipeline(), 4); len(violations) > 0 {
+			t.Fatalf("Grammar with seed %d and the %s pipeline:\n%s\n%v", seed, PipelineGNF, g.String(false), violations)
+		}
+	}
+}
+
+func TestPassPropertiesFindViolations(t *testing.T) {
+	grammars, err := ParseGrammars("test.txt", []string{"S -> a{S}b|{A}|{U}", "A -> ε|c", "U -> {U}a"})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+
+	// Pasadas con los mismos nombres de etapas que no hacen nada
+	skip := func(pass string, stages ...string) Pass {
+		return funcPass{name: pass, apply: func(g *Grammar, ctx *PassContext) *Grammar {
+			for _, stage := range stages {
+				ctx.Stage(stage, stage, g)
+			}
+			return g
+		}}
+	}
+	pipeline := NewPipeline(
+		skip(PassEpsilon, StageNullablesReplaced, StageEpsilonFree),
+		skip(PassUseless, StageUseful),
+		skip(PassChomsky, StageCNFStartSymbol, StageCNFTerminals, StageChomskyNormalForm),
+	)
+
+	found := make(map[string]string)
+	for _, violation := range CheckPassProperties(grammars[0], pipeline, 3) {
+		found[violation.Property] = violation.Pass
+	}
+	expected := map[string]string{PropertyEpsilonFree: PassEpsilon, PropertyUseful: PassUseless, PropertyChomsky: PassChomsky}
+	for property, pass := range expected {
+		if found[property] != pass {
+			t.Errorf("Expected %s to be broken by %s, but got %v", property, pass, found)
+		}
+	}
+	if _, exist := found[PropertyLanguageKept]; exist {
+		t.Errorf("Expected the language to be kept, but got %v", found)
+	}
+}
@@ -0,0 +1,131 @@
+package grammar
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+/*
+Generador de gramáticas libres de contexto aleatorias, para probar las
+pasadas con muchas gramáticas distintas. La misma semilla y la misma
+configuración dan siempre la misma gramática:
+
+	g := RandomGrammar(42, DefaultRandomGrammarConfig())
+
+Los no terminales se llaman S, A, B, ... y los terminales a, b, c, ... Cada
+cuerpo es ε, unario o recursivo por la izquierda con la probabilidad dada
+por la configuración, y si no es una secuencia de terminales y no
+terminales. Los símbolos inútiles se agregan al final: la mitad no son
+generadores (U -> a{U}) y se usan desde la gramática, y la otra mitad son
+generadores pero ningún cuerpo los usa.
+*/
+
+// Parameters of RandomGrammar.
+type RandomGrammarConfig struct {
+	NonTerminals       int     // NON terminals, including the start symbol S and without the useless ones.
+	Terminals          int     // Different terminals, from a.
+	MaxBodies          int     // Most bodies of a NON terminal, it has at least one.
+	MaxBodyLength      int     // Most symbols of a body, it has at least one.
+	EpsilonRatio       float64 // Probability of an ε body.
+	UnaryRatio         float64 // Probability of a body with a single NON terminal.
+	LeftRecursionRatio float64 // Probability of a body that starts with its own head.
+	UselessSymbols     int     // Extra NON terminals that are not generating or not reachable.
+}
+
+// Returns: a configuration for small grammars, with a bit of everything.
+func DefaultRandomGrammarConfig() RandomGrammarConfig {
+	return RandomGrammarConfig{
+		NonTerminals:       3,
+		Terminals:          2,
+		MaxBodies:          3,
+		MaxBodyLength:      3,
+		EpsilonRatio:       0.15,
+		UnaryRatio:         0.15,
+		LeftRecursionRatio: 0.1,
+		UselessSymbols:     1,
+	}
+}
+
+// Generates a random grammar from a seed.
+//
+// Returns: the grammar, with S as the start symbol.
+func RandomGrammar(seed int64, config RandomGrammarConfig) *Grammar {
+	random := rand.New(rand.NewSource(seed))
+	g := &Grammar{Productions: make(map[Symbol][][]Symbol)}
+
+	nonTerminals := make([]Symbol, max(config.NonTerminals, 1))
+	for i := range nonTerminals {
+		nonTerminals[i] = Symbol{IsTerminal: false, Value: randomNonTerminalName(i)}
+	}
+	terminals := make([]Symbol, max(config.Terminals, 1))
+	for i := range terminals {
+		terminals[i] = Symbol{IsTerminal: true, Value: string(rune('a' + i%26))}
+	}
+
+	randomSymbol := func() Symbol {
+		if random.Intn(2) == 0 {
+			return terminals[random.Intn(len(terminals))]
+		}
+		return nonTerminals[random.Intn(len(nonTerminals))]
+	}
+	randomBody := func(length int) []Symbol {
+		body := make([]Symbol, 0, length)
+		for len(body) < length {
+			body = append(body, randomSymbol())
+		}
+		return body
+	}
+
+	for _, head := range nonTerminals {
+		bodies := make([][]Symbol, 0, config.MaxBodies)
+		for count := 1 + random.Intn(max(config.MaxBodies, 1)); len(bodies) < count; {
+			length := 1 + random.Intn(max(config.MaxBodyLength, 1))
+			switch chance := random.Float64(); {
+			case chance < config.EpsilonRatio:
+				bodies = append(bodies, []Symbol{EpsilonSymbol})
+			case chance < config.EpsilonRatio+config.UnaryRatio:
+				bodies = append(bodies, []Symbol{nonTerminals[random.Intn(len(nonTerminals))]})
+			case chance < config.EpsilonRatio+config.UnaryRatio+config.LeftRecursionRatio:
+				bodies = append(bodies, append([]Symbol{head}, randomBody(max(length-1, 1))...))
+			default:
+				bodies = append(bodies, randomBody(length))
+			}
+		}
+		g.addProductionSymbols(head, bodies)
+	}
+
+	for i := 0; i < config.UselessSymbols; i++ {
+		useless := Symbol{IsTerminal: false, Value: "U"}
+		if i > 0 {
+			useless.Value += fmt.Sprint(i)
+		}
+		terminal := terminals[random.Intn(len(terminals))]
+		if i%2 == 0 {
+			// No generador: nunca termina, y otro no terminal lo usa
+			g.addProductionSymbols(useless, [][]Symbol{{terminal, useless}})
+			user := nonTerminals[random.Intn(len(nonTerminals))]
+			g.addProductionSymbols(user, [][]Symbol{{useless, terminal}})
+		} else {
+			// No alcanzable: genera una cadena, pero ningún cuerpo lo usa
+			g.addProductionSymbols(useless, [][]Symbol{{terminal}})
+		}
+	}
+
+	g.StartSymbol = nonTerminals[0]
+	return g
+}
+
+// Returns: the name of the i-th NON terminal of a random grammar: S, A, B,
+// ... and then A1, B1, ...
+func randomNonTerminalName(i int) string {
+	if i == 0 {
+		return "S"
+	}
+	// Sin S ni U, que son el símbolo inicial y los símbolos inútiles
+	letters := "ABCDEFGHIJKLMNOPQRTVWXYZ"
+	name := string(letters[(i-1)%len(letters)])
+	if round := (i - 1) / len(letters); round > 0 {
+		name += fmt.Sprint(round)
+	}
+	return name
+}
@@ -0,0 +1,122 @@
+package grammar
+
+import (
+	"strconv"
+	"strings"
+
+	ast "github.com/DanielRasho/Computation-Theory/internal/abstract_syntax_tree"
+)
+
+// Limit that keeps value ranges and character classes from creating huge grammars.
+const maxValueRange = 256
+
+// sourceChar is a character of a rule with its position in the file. Rules
+// of some notations span several lines, so every character keeps its own line.
+type sourceChar struct {
+	char   rune
+	line   int
+	column int
+}
+
+// ruleReader holds what the readers of notations with EBNF-like operators
+// share: the rule being parsed, and the operands of the AST built for it,
+// which is translated into productions with an ebnfDesugarer.
+type ruleReader struct {
+	*grammarParser
+	rule    []sourceChar // Rule being parsed, without comments.
+	i       int          // Position in rule.
+	symbols []Symbol     // Operands of the rule being parsed, by index.
+}
+
+// Adds the productions of a rule, creating the helper NON terminals needed by its operators.
+func (p *ruleReader) addRule(head Symbol, node ast.Node) {
+	// La cabeza se registra antes que los no terminales auxiliares para que
+	// estos reciban un Id distinto
+	if _, exist := p.current.Productions[head]; !exist {
+		p.current.addProductionSymbols(head, nil)
+	}
+	d := &ebnfDesugarer{grammar: p.current, head: head, symbols: p.symbols}
+	p.current.addProductionSymbols(head, d.alternatives(node))
+}
+
+// Registers a symbol as an operand of the rule.
+func (p *ruleReader) operand(symbol Symbol) ast.Node {
+	p.symbols = append(p.symbols, symbol)
+	return ast.NewCharacterNode(strconv.Itoa(len(p.symbols) - 1))
+}
+
+// Joins nodes with the concatenation operator, an empty list is ε.
+func (p *ruleReader) concatenate(nodes []ast.Node) ast.Node {
+	if len(nodes) == 0 {
+		return p.operand(EpsilonSymbol)
+	}
+	node := nodes[0]
+	for _, next := range nodes[1:] {
+		node = ast.NewOperatorNode("·", []ast.Node{node, next})
+	}
+	return node
+}
+
+// returns: an alternative between a terminal for each character.
+func (p *ruleReader) alternateChars(chars []rune) ast.Node {
+	var node ast.Node
+	for _, char := range chars {
+		operand := p.operand(Symbol{Value: string(char), IsTerminal: true, Id: 0})
+		if node == nil {
+			node = operand
+		} else {
+			node = ast.NewOperatorNode("|", []ast.Node{node, operand})
+		}
+	}
+	return node
+}
+
+func (p *ruleReader) peek() rune {
+	if p.i >= len(p.rule) {
+		return 0
+	}
+	return p.rule[p.i].char
+}
+
+func (p *ruleReader) skipSpaces() {
+	for p.i < len(p.rule) && (p.peek() == ' ' || p.peek() == '\t') {
+		p.i++
+	}
+}
+
+func (p *ruleReader) errorAtCurrent(message string, hint string) {
+	if p.i >= len(p.rule) {
+		last := p.rule[len(p.rule)-1]
+		p.errorAt(sourceChar{line: last.line, column: last.column + 1}, message, hint)
+		return
+	}
+	p.errorAt(p.rule[p.i], message, hint)
+}
+
+// Reports an error at a character, which may be in a line before the current one.
+func (p *ruleReader) errorAt(at sourceChar, message string, hint string) {
+	value := ""
+	if at.char != 0 {
+		value = string(at.char)
+	}
+	line := p.line
+	p.line = at.line
+	p.addError(token{kind: tokenChar, value: value, column: at.column}, message, hint)
+	p.line = line
+}
+
+func toSourceChars(line string, lineNumber int) []sourceChar {
+	chars := make([]sourceChar, 0, len(line))
+	for index, char := range []rune(line) {
+		chars = append(chars, sourceChar{char: char, line: lineNumber, column: index + 1})
+	}
+	return chars
+}
+
+func sourceString(chars []sourceChar) string {
+	var sb strings.Builder
+	for _, c := range chars {
+		sb.WriteRune(c.char)
+	}
+	return sb.String()
+}
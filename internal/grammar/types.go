@@ -3,7 +3,9 @@ package grammar
 import (
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
+	"unicode"
 )
 
 const Epsilon = "ε"
@@ -14,12 +16,103 @@ var EpsilonSymbol Symbol = Symbol{
 	Id:         0,
 }
 
+// The literal character ε written as \ε. It uses a different Id so it is
+// never confused with the empty string.
+var LiteralEpsilonSymbol Symbol = Symbol{
+	IsTerminal: true,
+	Value:      "ε",
+	Id:         1,
+}
+
+const EscapeSymbol = '\\'
+
+// Terminals that can only be written with an escape sequence, by the
+// character that follows the backslash.
+var escapeSequences = map[rune]Symbol{
+	'{':  {IsTerminal: true, Value: "{", Id: 0},
+	'}':  {IsTerminal: true, Value: "}", Id: 0},
+	'|':  {IsTerminal: true, Value: "|", Id: 0},
+	'ε':  LiteralEpsilonSymbol,
+	's':  {IsTerminal: true, Value: " ", Id: 0},
+	't':  {IsTerminal: true, Value: "\t", Id: 0},
+	'n':  {IsTerminal: true, Value: "\n", Id: 0},
+	'r':  {IsTerminal: true, Value: "\r", Id: 0},
+	'\\': {IsTerminal: true, Value: "\\", Id: 0},
+}
+
+// Prefix of the escape sequence \uXXXX, which writes any character of the
+// Basic Multilingual Plane by its hexadecimal code.
+const unicodeEscape = 'u'
+
+// Terminals that are EBNF operators inside a %ebnf grammar. The escape
+// sequences are accepted in every grammar, but they are only needed when
+// the EBNF operators are enabled.
+var operatorEscapes = map[rune]Symbol{
+	'(': {IsTerminal: true, Value: "(", Id: 0},
+	')': {IsTerminal: true, Value: ")", Id: 0},
+	'?': {IsTerminal: true, Value: "?", Id: 0},
+	'*': {IsTerminal: true, Value: "*", Id: 0},
+	'+': {IsTerminal: true, Value: "+", Id: 0},
+}
+
+// Returns the terminal represented by an escape sequence "\char".
+func unescapeTerminal(char rune) (Symbol, bool) {
+	if symbol, exist := escapeSequences[char]; exist {
+		return symbol, true
+	}
+	symbol, exist := operatorEscapes[char]
+	return symbol, exist
+}
+
+// Returns the terminal represented by the escape sequence that starts at
+// chars[0], just after the backslash: a single character like \{ or a
+// character code like \u00E9.
+//
+// Returns: the terminal, the number of characters read and true if the
+// escape sequence is valid.
+func unescapeSequence(chars []rune) (Symbol, int, bool) {
+	if len(chars) == 0 {
+		return Symbol{}, 0, false
+	}
+	if chars[0] == unicodeEscape && len(chars) >= 5 {
+		if code, err := strconv.ParseUint(string(chars[1:5]), 16, 32); err == nil {
+			return Symbol{IsTerminal: true, Value: string(rune(code)), Id: 0}, 5, true
+		}
+	}
+	symbol, exist := unescapeTerminal(chars[0])
+	return symbol, 1, exist
+}
+
+// returns: the terminal as it must be written in a grammar file, escaping
+// the characters that have a special meaning.
+func escapeTerminal(symbol Symbol) string {
+	switch {
+	case symbol == LiteralEpsilonSymbol:
+		return string(EscapeSymbol) + Epsilon
+	case symbol.Value == Epsilon:
+		return Epsilon
+	}
+	for char, escaped := range escapeSequences {
+		if escaped.Value == symbol.Value {
+			return string(EscapeSymbol) + string(char)
+		}
+	}
+	// Characters that cannot be seen, like control characters, by their code
+	if char := []rune(symbol.Value); len(char) == 1 && !unicode.IsPrint(char[0]) && char[0] <= 0xFFFF {
+		return fmt.Sprintf("%c%c%04X", EscapeSymbol, unicodeEscape, char[0])
+	}
+	return symbol.Value
+}
+
 type Symbol struct {
-	IsTerminal bool
-	Value      string
-	Id         int
+	IsTerminal bool   `json:"terminal"`
+	Value      string `json:"value"`
+	Id         int    `json:"id"`
 }
 
+// returns: the terminal value, or {Value_Id} for NON terminals. Since the Id
+// always comes after the last "_", names with underscores like {expr_list}
+// can be recovered with SymbolFromString.
 func (s *Symbol) String() string {
 	if s.IsTerminal {
 		return s.Value
@@ -27,12 +120,69 @@ func (s *Symbol) String() string {
 	return fmt.Sprintf("{%s_%d}", s.Value, s.Id)
 }
 
+// Parses the output of Symbol.String() back into a Symbol.
+//
+// Returns: the symbol and true if the string was a valid NON terminal or terminal.
+func SymbolFromString(value string) (Symbol, bool) {
+	if !strings.HasPrefix(value, "{") || !strings.HasSuffix(value, "}") || len(value) < 2 {
+		return Symbol{IsTerminal: true, Value: value, Id: 0}, value != ""
+	}
+
+	name := value[1 : len(value)-1]
+	division := strings.LastIndex(name, "_")
+	if division <= 0 {
+		return Symbol{}, false
+	}
+	id, err := strconv.Atoi(name[division+1:])
+	if err != nil {
+		return Symbol{}, false
+	}
+
+	return Symbol{IsTerminal: false, Value: name[:division], Id: id}, true
+}
+
+const StartDirective = "%start"
+
+// Directive that enables the EBNF operators ( ) ? * + inside the bodies of a grammar.
+const EBNFDirective = "%ebnf"
+
 type Grammar struct {
 	terminals    []Symbol              // List of all cached terminals in the grammar.
 	NonTerminals []Symbol              // List of all cached NON terminals in the grammar.
+	StartSymbol  Symbol                // Start symbol, when empty the first NON terminal is used.
 	Productions  map[Symbol][][]Symbol // The actual productions.
 }
 
+// returns: the start symbol of the grammar. If it was never set, the
+// first registered NON terminal is used.
+func (g *Grammar) GetStartSymbol() Symbol {
+	if g.StartSymbol.Value != "" {
+		return g.StartSymbol
+	}
+	if len(g.NonTerminals) > 0 {
+		return g.NonTerminals[0]
+	}
+	return Symbol{}
+}
+
+// Sets the start symbol of the grammar from the name of a NON terminal.
+// Both "S" and "{S}" are accepted.
+func (g *Grammar) SetStartSymbol(name string) {
+	name = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(name), "{"), "}")
+	g.StartSymbol = Symbol{Value: name, IsTerminal: false, Id: 0}
+}
+
+// Parses a start directive with the shape "%start S".
+//
+// Returns: the name of the start symbol and true if line is a start directive.
+func ParseStartDirective(line string) (string, bool) {
+	if !strings.HasPrefix(line, StartDirective+" ") {
+		return "", false
+	}
+	name := strings.TrimSpace(line[len(StartDirective):])
+	return name, name != ""
+}
+
 // returns: a readable representation of the grammar.
 func (g *Grammar) String(verbose bool) string {
 	var sb strings.Builder
@@ -48,7 +198,11 @@ func (g *Grammar) String(verbose bool) string {
 			sb.WriteString(" -> ")
 			for index, body := range bodies {
 				for _, symbol := range body {
-					sb.WriteString(symbol.String())
+					if symbol.IsTerminal {
+						sb.WriteString(escapeTerminal(symbol))
+					} else {
+						sb.WriteString(symbol.String())
+					}
 				}
 				if index != len(bodies)-1 {
 					sb.WriteString("|")
@@ -83,36 +237,41 @@ func (g *Grammar) AddProductionFromString(production string) {
 
 	head := Symbol{Value: production[:division1], IsTerminal: false, Id: 0}
 	body := production[division2+1:]
-	bodyItems := strings.Split(body, "|")
+	bodyItems := splitAlternatives(body)
+
+	bodies := make([][]Symbol, 0, len(bodyItems))
+	for _, v := range bodyItems {
+		body, _, _ := splitStringIntoSymbols(v)
+		bodies = append(bodies, body)
+	}
+
+	g.addProductionSymbols(head, bodies)
+}
 
+// Adds already parsed bodies to a head, registering every NON terminal and
+// terminal found and removing repeated body values.
+func (g *Grammar) addProductionSymbols(head Symbol, bodies [][]Symbol) {
 	// If production is not registered create it
 	if _, exist := g.Productions[head]; !exist {
 		// Add new NON terminal
 		g.NonTerminals = append(g.NonTerminals, head)
-		bodySymbols := make([][]Symbol, 0)
-		// Add bodies
-		for _, v := range bodyItems {
-			body, nonTerminal, terminal := splitStringIntoSymbols(v)
-			g.NonTerminals = append(g.NonTerminals, nonTerminal...)
-			g.terminals = append(g.terminals, terminal...)
-			bodySymbols = append(bodySymbols, body)
-		}
-		// Remove duplicate bodies.
-		bodySymbols = removeDuplicatesSlices(bodySymbols)
-		g.Productions[head] = bodySymbols
-	} else {
-		// Else append the body new items with the old ones
-		existentBodyItems := g.Productions[head]
-		for _, v := range bodyItems {
-			body, nonTerminal, terminal := splitStringIntoSymbols(v)
-			g.NonTerminals = append(g.NonTerminals, nonTerminal...)
-			g.terminals = append(g.terminals, terminal...)
-			existentBodyItems = append(existentBodyItems, body)
-		}
-		// Remove duplicate bodies.
-		existentBodyItems = removeDuplicatesSlices(existentBodyItems)
-		g.Productions[head] = existentBodyItems
 	}
+
+	// Append the body new items with the old ones
+	existentBodyItems := g.Productions[head]
+	for _, body := range bodies {
+		for _, symbol := range body {
+			if symbol.IsTerminal {
+				g.terminals = append(g.terminals, symbol)
+			} else {
+				g.NonTerminals = append(g.NonTerminals, symbol)
+			}
+		}
+		existentBodyItems = append(existentBodyItems, body)
+	}
+	// Remove duplicate bodies.
+	g.Productions[head] = removeDuplicatesSlices(existentBodyItems)
+
 	g.NonTerminals = removeDuplicatesSymbols(g.NonTerminals)
 	g.terminals = removeDuplicatesSymbols(g.terminals)
 }
@@ -154,6 +313,18 @@ func (g *Grammar) AddProduction(head string, bodies [][]Symbol) *Symbol {
 	return &newHead
 }
 
+// Returns a NON terminal named value whose Id is not used by any NON
+// terminal of the grammar.
+func (g *Grammar) freshNonTerminal(value string) Symbol {
+	newSymbol := Symbol{IsTerminal: false, Value: value, Id: 0}
+	for _, nonTerminal := range g.NonTerminals {
+		if nonTerminal.Value == value && nonTerminal.Id >= newSymbol.Id {
+			newSymbol.Id = nonTerminal.Id + 1
+		}
+	}
+	return newSymbol
+}
+
 func (g *Grammar) AddProductionBodies(head Symbol, bodies [][]Symbol) *Symbol {
 
 	if _, exist := g.Productions[head]; !exist {
@@ -193,10 +364,45 @@ func (g *Grammar) SetProductionBodies(head Symbol, bodies [][]Symbol) bool {
 	return true
 }
 
+// Returns a deep copy of the grammar, which shares no slices with it.
+//
+// Every exported transformation of the package, like RemoveEpsilons or
+// SimplifyGrammar, works on a clone of its argument: the argument is never
+// modified and the result shares no memory with it, so the same grammar can
+// be given to several transformations, even concurrently.
+func (g *Grammar) Clone() *Grammar {
+	copied := &Grammar{
+		terminals:    copySymbols(g.terminals),
+		NonTerminals: copySymbols(g.NonTerminals),
+		StartSymbol:  g.StartSymbol,
+		Productions:  make(map[Symbol][][]Symbol, len(g.Productions)),
+	}
+	for head, bodies := range g.Productions {
+		copiedBodies := make([][]Symbol, 0, len(bodies))
+		for _, body := range bodies {
+			copiedBodies = append(copiedBodies, copySymbols(body))
+		}
+		copied.Productions[head] = copiedBodies
+	}
+	return copied
+}
+
+// Returns: a copy of the symbols without spare capacity, so appending to it
+// never writes over another slice. A nil slice stays nil.
+func copySymbols(symbols []Symbol) []Symbol {
+	if symbols == nil {
+		return nil
+	}
+	copied := make([]Symbol, len(symbols))
+	copy(copied, symbols)
+	return copied
+}
+
 func (g *Grammar) RecalculateTerminals() {
 	g.terminals = make([]Symbol, 0)
-	for _, bodies := range g.Productions {
-		for _, body := range bodies {
+	// In the order of the NON terminals, so the terminals are always in the same order
+	for _, head := range productionHeads(g) {
+		for _, body := range g.Productions[head] {
 			for _, symbol := range body {
 				if symbol.IsTerminal {
 					g.terminals = append(g.terminals, symbol)
@@ -273,12 +479,48 @@ func removeSymbols(items *[]Symbol, symbolToRemove *Symbol) *[]Symbol {
 	return &result
 }
 
+// Splits a production body on every "|" that is not escaped.
+func splitAlternatives(body string) []string {
+	alternatives := make([]string, 0)
+	var current strings.Builder
+	escaped := false
+
+	for _, char := range body {
+		switch {
+		case escaped:
+			escaped = false
+		case char == EscapeSymbol:
+			escaped = true
+		case char == '|':
+			alternatives = append(alternatives, current.String())
+			current.Reset()
+			continue
+		}
+		current.WriteRune(char)
+	}
+
+	return append(alternatives, current.String())
+}
+
 // Split the string into Symbols and return body, nonTerminals, and terminals
 func splitStringIntoSymbols(input string) (body []Symbol, nonTerminals []Symbol, terminals []Symbol) {
 	var current strings.Builder
 	inBraces := false
+	chars := []rune(input)
+
+	for i := 0; i < len(chars); i++ {
+		char := chars[i]
+
+		// Escaped terminals like \{ or \s
+		if char == EscapeSymbol && !inBraces && i+1 < len(chars) {
+			if terminalSymbol, length, exist := unescapeSequence(chars[i+1:]); exist {
+				body = append(body, terminalSymbol)
+				terminals = append(terminals, terminalSymbol)
+				i += length
+				continue
+			}
+		}
 
-	for _, char := range input {
 		switch char {
 		case '{':
 			inBraces = true // We are inside curly braces
@@ -335,6 +577,17 @@ func isComposedOfSymbols(validSymbols []Symbol, body []Symbol) bool {
 	return true
 }
 
+// Returns a copy of slice with item as its first element, keeping the order of the rest.
+func moveSymbolToFront(slice []Symbol, item Symbol) []Symbol {
+	result := []Symbol{item}
+	for _, s := range slice {
+		if s != item {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
 // Checks if a string exists in a slice
 func containsSymbol(slice []Symbol, item Symbol) bool {
 	for _, s := range slice {
@@ -439,7 +692,8 @@ func compareSymbolSlices(s1, s2 []Symbol) bool {
 		return false
 	}
 
-	// Ordenar ambos slices antes de compararlos
+	// Ordenar copias de ambos slices antes de compararlos
+	s1, s2 = copySymbols(s1), copySymbols(s2)
 	sort.Slice(s1, func(i, j int) bool {
 		return s1[i].String() < s1[j].String()
 	})
@@ -462,7 +716,8 @@ func compareProductionSlices(p1, p2 [][]Symbol) bool {
 		return false
 	}
 
-	// Ordenar ambas listas de producciones por su representación de cadena
+	// Ordenar copias de ambas listas de producciones por su representación de cadena
+	p1, p2 = append([][]Symbol{}, p1...), append([][]Symbol{}, p2...)
 	sort.Slice(p1, func(i, j int) bool {
 		return productionString(p1[i]) < productionString(p1[j])
 	})
@@ -490,10 +745,12 @@ func productionString(p []Symbol) string {
 
 // Función para ordenar las producciones de la gramática según el orden de los no terminales
 func OrderProductionsByNonTerminals(originalGrammar *Grammar) *Grammar {
+	originalGrammar = originalGrammar.Clone()
 	// Crear una nueva gramática para almacenar las producciones ordenadas
 	orderedGrammar := &Grammar{
 		terminals:    originalGrammar.terminals,
 		NonTerminals: originalGrammar.NonTerminals,
+		StartSymbol:  originalGrammar.GetStartSymbol(),
 		Productions:  make(map[Symbol][][]Symbol),
 	}
 
@@ -509,7 +766,7 @@ func OrderProductionsByNonTerminals(originalGrammar *Grammar) *Grammar {
 	return orderedGrammar
 }
 
-// Función que busca terminales en las producciones y devuelve los heads
+// Función que busca terminales en las producciones y devuelve los heads ({Value_Id})
 func FindHeadsProducingTerminal(grammar *Grammar, terminalValue string) []string {
 	heads := []string{} // Lista para almacenar los heads que producen directamente el terminal
 
@@ -519,9 +776,10 @@ func FindHeadsProducingTerminal(grammar *Grammar, terminalValue string) []string
 		if bodies, exists := grammar.Productions[nonTerminal]; exists {
 			for _, body := range bodies {
 				// Verificar si la producción tiene exactamente un símbolo y es un terminal
-				if len(body) == 1 && body[0].IsTerminal && body[0].Value == terminalValue {
-					// Añadir el value del head (nonTerminal) a la lista si produce directamente el terminal
-					heads = append(heads, nonTerminal.Value)
+				// ε representa la cadena vacía, nunca el caracter ε de la entrada
+				if len(body) == 1 && body[0].IsTerminal && body[0] != EpsilonSymbol && body[0].Value == terminalValue {
+					// Añadir el head (nonTerminal) a la lista si produce directamente el terminal
+					heads = append(heads, nonTerminal.String())
 				}
 			}
 		}
@@ -530,28 +788,22 @@ func FindHeadsProducingTerminal(grammar *Grammar, terminalValue string) []string
 	return heads
 }
 
-// Función que busca producciones con un par de no terminales concatenados y devuelve los heads
+// Función que busca producciones con un par de no terminales concatenados y devuelve los heads ({Value_Id})
 func FindHeadsProducingNonTerminals(grammar *Grammar, nonTerminal1, nonTerminal2 Symbol) []string {
 	heads := []string{} // Lista para almacenar los heads que producen el par de no terminales
 
-	// Concatenar los valores de los dos no terminales
-	concatenated := nonTerminal1.Value + nonTerminal2.Value
-
 	// Recorrer los no terminales en el orden de la lista de nonTerminals
 	for _, nonTerminal := range grammar.NonTerminals {
 		// Buscar si existen producciones para ese no terminal
 		if bodies, exists := grammar.Productions[nonTerminal]; exists {
 			for _, body := range bodies {
-				// Verificar si la producción tiene exactamente dos elementos
-				if len(body) == 2 {
-					// Concatenar los valores de los dos símbolos del cuerpo de la producción
-					bodyConcat := body[0].Value + body[1].Value
-
-					// Comparar la concatenación del cuerpo con la concatenación de los no terminales
-					if bodyConcat == concatenated {
-						// Añadir el value del head (nonTerminal) a la lista si produce el par concatenado
-						heads = append(heads, nonTerminal.Value)
-					}
+				// Verificar si la producción tiene exactamente dos elementos y que cada uno
+				// coincida con su no terminal. Se comparan los símbolos completos porque los
+				// nombres pueden tener varios caracteres ("A"+"BC" y "AB"+"C" no son el mismo
+				// par) y dos no terminales pueden compartir nombre con distinto Id
+				if len(body) == 2 && body[0] == nonTerminal1 && body[1] == nonTerminal2 {
+					// Añadir el head (nonTerminal) a la lista si produce el par de no terminales
+					heads = append(heads, nonTerminal.String())
 				}
 			}
 		}
@@ -0,0 +1,137 @@
+package grammar
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Returns: every field of the grammar as text, with the productions in a
+// fixed order, to check that a grammar was not modified.
+func grammarSnapshot(g *Grammar) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%#v\n%#v\n%#v\n", g.terminals, g.NonTerminals, g.StartSymbol))
+	for _, head := range productionHeads(g) {
+		sb.WriteString(fmt.Sprintf("%#v: %#v\n", head, g.Productions[head]))
+	}
+	return sb.String()
+}
+
+// Overwrites every symbol of the grammar, to find slices shared with
+// another grammar.
+func scrambleGrammar(g *Grammar) {
+	marker := Symbol{IsTerminal: true, Value: "scrambled"}
+	for i := range g.terminals {
+		g.terminals[i] = marker
+	}
+	for i := range g.NonTerminals {
+		g.NonTerminals[i] = marker
+	}
+	for _, bodies := range g.Productions {
+		for _, body := range bodies {
+			for i := range body {
+				body[i] = marker
+			}
+		}
+	}
+}
+
+func TestClone(t *testing.T) {
+	grammars, err := ParseGrammars("test.txt", []string{"S -> a{S}b|{A}", "A -> ε|c"})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+	g := grammars[0]
+	before := grammarSnapshot(g)
+
+	copied := g.Clone()
+	if grammarSnapshot(copied) != before {
+		t.Errorf("Expected the clone to be %q, but got %q", before, grammarSnapshot(copied))
+	}
+
+	scrambleGrammar(copied)
+	copied.AddProductionFromString("B -> b")
+	copied.SetStartSymbol("B")
+	if grammarSnapshot(g) != before {
+		t.Errorf("Expected the grammar to stay %q, but got %q", before, grammarSnapshot(g))
+	}
+}
+
+func TestTransformationsKeepInput(t *testing.T) {
+	transformations := map[string]func(g *Grammar) *Grammar{
+		"factorizeGrammar": factorizeGrammar,
+		"RemoveLeftRecursion": func(g *Grammar) *Grammar {
+			result, _ := RemoveLeftRecursion(g)
+			return result
+		},
+		"ReplaceNullables": func(g *Grammar) *Grammar {
+			return ReplaceNullables(g, *identifyIndirectNullables(g, *identifyDirectNullables(g)))
+		},
+		"RemoveEpsilons":                   RemoveEpsilons,
+		"RemoveUnaryProductions":           func(g *Grammar) *Grammar { return RemoveUnaryProductions(g, g.NonTerminals) },
+		"RemoveNonGeneratingSymbols":       RemoveNonGeneratingSymbols,
+		"RemoveNonReachableSymbols":        func(g *Grammar) *Grammar { return RemoveNonReachableSymbols(g, g.GetStartSymbol()) },
+		"RemoveUselessSymbols":             func(g *Grammar) *Grammar { return RemoveUselessSymbols(g, g.GetStartSymbol()) },
+		"CNFAddStartSymbol":                func(g *Grammar) *Grammar { return CNFAddStartSymbol(g, true) },
+		"CNFAddStartSymbolNotNeeded":       func(g *Grammar) *Grammar { return CNFAddStartSymbol(g, false) },
+		"CNFTerminalSubstitution":          CNFTerminalSubstitution,
+		"CNFSplitLargeProductions":         CNFSplitLargeProductions,
+		"OrderProductionsByNonTerminals":   OrderProductionsByNonTerminals,
+		"SimplifyGrammar":                  func(g *Grammar) *Grammar { return SimplifyGrammar(g, false) },
+		"SimplifyGrammarResult":            func(g *Grammar) *Grammar { return SimplifyGrammarResult(g, false).Grammar },
+		"CNFPipeline":                      func(g *Grammar) *Grammar { return CNFPipeline().Run(g, false, nil) },
+		"GNFRemoveLeftRecursion":           GNFRemoveLeftRecursion,
+		"GNFSubstituteLeadingNonTerminals": GNFSubstituteLeadingNonTerminals,
+		"GNFTerminalSubstitution":          GNFTerminalSubstitution,
+		"GNFPipeline":                      func(g *Grammar) *Grammar { return GNFPipeline().Run(g, false, nil) },
+	}
+
+	for name, g := range nativeRoundTripGrammars(t) {
+		before := grammarSnapshot(g)
+		for transformation, transform := range transformations {
+			result := transform(g)
+			if grammarSnapshot(g) != before {
+				t.Fatalf("%s modified %s:\nexpected %q,\n but got %q", transformation, name, before, grammarSnapshot(g))
+			}
+			// The result must not share memory with the input
+			if result != g {
+				scrambleGrammar(result)
+			}
+			if grammarSnapshot(g) != before {
+				t.Fatalf("The result of %s shares memory with %s", transformation, name)
+			}
+		}
+	}
+}
+
+func TestTransformationsRunConcurrently(t *testing.T) {
+	grammars, err := ParseGrammars("test.txt", []string{"E -> {E}+{T}|{T}", "T -> {T}*{F}|{F}", "F -> ({E})|x|{A}x", "A -> ε|a"})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+	before := grammarSnapshot(grammars[0])
+	// The order of the productions depends on the order of the maps, the
+	// metrics do not
+	expected := SimplifyGrammar(grammars[0], false).Metrics()
+
+	var wg sync.WaitGroup
+	results := make([]GrammarMetrics, 8)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = SimplifyGrammar(grammars[0], false).Metrics()
+		}()
+	}
+	wg.Wait()
+
+	for i, result := range results {
+		if result != expected {
+			t.Errorf("Expected run %d to give %+v, but got %+v", i, expected, result)
+		}
+	}
+	if grammarSnapshot(grammars[0]) != before {
+		t.Errorf("Expected the grammar to stay %q, but got %q", before, grammarSnapshot(grammars[0]))
+	}
+}
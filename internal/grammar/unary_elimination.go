@@ -37,37 +37,19 @@ func FindUnaryPairs(unaryBase map[Symbol][]Symbol) map[Symbol][]Symbol {
 	// Crear un nuevo mapa para almacenar las parejas unarias extendidas
 	unaryPairs := make(map[Symbol][]Symbol)
 
-	// Inicializar con las parejas unarias originales
+	// Inicializar con una copia de las parejas unarias originales
 	for key, value := range unaryBase {
-		unaryPairs[key] = value
+		unaryPairs[key] = copySymbols(value)
 	}
 
-	// Por cada clave, encontrar todas las producciones unarias posibles a partir de un no terminal
+	// Por cada clave, agregar las parejas de cada no terminal alcanzado, incluidos
+	// los que se agregan en el camino, hasta que ya no existan parejas por añadir
 	for key := range unaryPairs {
-		expanded := true // Bandera para indicar si se ha realizado una expansión
-
-		// Seguir buscando producciones hasta que ya no existan producciones por añadir
-		for expanded {
-			expanded = false
-
-			// Obtener los valores actuales
-			currentValues := unaryPairs[key]
-			if len(currentValues) > 0 {
-				lastValue := currentValues[len(currentValues)-1] // Comenzar a partir de la última producción
-
-				// Obtener los sub_valores de la sub_llave buscada,
-				// (último valor del listado del encabezado que se lee)
-				for sub_key, sub_value := range unaryPairs {
-					if lastValue == sub_key {
-						// Agregar todos los valores de la sub_llave a la llave original, asegurándose de no duplicar
-						for _, sv := range sub_value {
-							// Si los valores actuales no contienen los sub_valores añadirlo
-							if !containsSymbol(unaryPairs[key], sv) {
-								unaryPairs[key] = append(unaryPairs[key], sv)
-								expanded = true // Si se agregó un nuevo elemento, se establece la bandera
-							}
-						}
-					}
+		for i := 0; i < len(unaryPairs[key]); i++ {
+			for _, sv := range unaryBase[unaryPairs[key][i]] {
+				// Si los valores actuales no contienen los sub_valores añadirlo
+				if !containsSymbol(unaryPairs[key], sv) {
+					unaryPairs[key] = append(unaryPairs[key], sv)
 				}
 			}
 		}
@@ -81,12 +63,14 @@ func FindUnaryPairs(unaryBase map[Symbol][]Symbol) map[Symbol][]Symbol {
 Elimina las producciones unarias y ajusta la gramática
 */
 func RemoveUnaryProductions(originalGrammar *Grammar, nonTerminals []Symbol) *Grammar {
+	originalGrammar = originalGrammar.Clone()
 	unaryBase := InitializeUnaryPairs(originalGrammar)
 	unaryPairs := FindUnaryPairs(unaryBase)
 
 	newGrammar := &Grammar{
 		terminals:    originalGrammar.terminals,
 		NonTerminals: originalGrammar.NonTerminals,
+		StartSymbol:  originalGrammar.GetStartSymbol(),
 		Productions:  make(map[Symbol][][]Symbol),
 	}
 
@@ -114,8 +98,11 @@ func RemoveUnaryProductions(originalGrammar *Grammar, nonTerminals []Symbol) *Gr
 			}
 		}
 
-		// Asignar las producciones no unarias a la nueva gramática
-		newGrammar.Productions[key] = productions
+		// Asignar las producciones no unarias a la nueva gramática, sin
+		// agregar entradas vacías para los no terminales que no generan nada
+		if len(productions) > 0 {
+			newGrammar.Productions[key] = productions
+		}
 	}
 
 	return newGrammar
@@ -140,3 +140,26 @@ func TestRemoveUnaryProductions(t *testing.T) {
 		}
 	}
 }
+
+// TestFindUnaryPairsThroughEveryPair verifica que las parejas se expandan a
+// través de todos los no terminales alcanzados, no solo del último.
+func TestFindUnaryPairsThroughEveryPair(t *testing.T) {
+	// S -> A y S -> B, con A -> C: la pareja (S, C) se encuentra a través de A
+	S := Symbol{IsTerminal: false, Value: "S", Id: 1}
+	unaryPairs := FindUnaryPairs(map[Symbol][]Symbol{
+		S: {S, A, B},
+		A: {A, C},
+		B: {B},
+		C: {C},
+	})
+
+	expectedValues := []Symbol{S, A, B, C}
+	if len(unaryPairs[S]) != len(expectedValues) {
+		t.Errorf("Error: Se esperaban los pares unarios %v para S, pero se obtuvo %v", expectedValues, unaryPairs[S])
+	}
+	for _, expectedValue := range expectedValues {
+		if !containsSymbol(unaryPairs[S], expectedValue) {
+			t.Errorf("Error: El valor %v no se encontró en los pares unarios de S. Pares actuales: %v", expectedValue, unaryPairs[S])
+		}
+	}
+}
@@ -117,18 +117,22 @@ func findReachableSymbols(originalGrammar *Grammar, startSymbol Symbol) ([]Symbo
 
 // Función que elimina los símbolos no generadores de la gramática y retorna una nueva gramática.
 func RemoveNonGeneratingSymbols(originalGrammar *Grammar) *Grammar {
-	// Obtener los símbolos no generadores
-	_, nonGeneratingSymbols := findGeneratingSymbols(originalGrammar)
+	originalGrammar = originalGrammar.Clone()
+	// Obtener los símbolos generadores
+	generatingSymbols, _ := findGeneratingSymbols(originalGrammar)
 
 	// Crear una nueva gramática vacía
 	newGrammar := &Grammar{
 		terminals:    []Symbol{},
 		NonTerminals: []Symbol{},
+		StartSymbol:  originalGrammar.GetStartSymbol(),
 		Productions:  make(map[Symbol][][]Symbol),
 	}
 
 	// Procesar las producciones de la gramática original
-	for head, productions := range originalGrammar.Productions {
+	// En el orden de los no terminales, para que el resultado siempre sea el mismo
+	for _, head := range productionHeads(originalGrammar) {
+		productions := originalGrammar.Productions[head]
 		// Lista de producciones válidas (sin símbolos no generadores)
 		var validProductions [][]Symbol
 
@@ -136,9 +140,10 @@ func RemoveNonGeneratingSymbols(originalGrammar *Grammar) *Grammar {
 		for _, production := range productions {
 			containsNonGenerating := false
 
-			// Verificar si la producción contiene algún símbolo no generador
+			// Verificar si la producción contiene algún símbolo no generador, también
+			// los no terminales que no están en NonTerminals porque no tienen producciones
 			for _, symbol := range production {
-				if containsSymbol(nonGeneratingSymbols, symbol) {
+				if !symbol.IsTerminal && !containsSymbol(generatingSymbols, symbol) {
 					containsNonGenerating = true
 					break
 				}
@@ -158,8 +163,8 @@ func RemoveNonGeneratingSymbols(originalGrammar *Grammar) *Grammar {
 	}
 
 	// Recorrer la nueva gramática para identificar los terminales y no terminales
-	for _, productions := range newGrammar.Productions {
-		for _, production := range productions {
+	for _, head := range productionHeads(newGrammar) {
+		for _, production := range newGrammar.Productions[head] {
 			for _, symbol := range production {
 				if symbol.IsTerminal {
 					// Añadir a la lista de terminales si no está ya presente
@@ -182,6 +187,7 @@ func RemoveNonGeneratingSymbols(originalGrammar *Grammar) *Grammar {
 
 // Función que elimina los símbolos no alcanzables de la gramática y retorna una nueva gramática.
 func RemoveNonReachableSymbols(originalGrammar *Grammar, startSymbol Symbol) *Grammar {
+	originalGrammar = originalGrammar.Clone()
 
 	// Obtener los símbolos no alcanzables
 	_, unreachableSymbols := findReachableSymbols(originalGrammar, startSymbol)
@@ -190,11 +196,14 @@ func RemoveNonReachableSymbols(originalGrammar *Grammar, startSymbol Symbol) *Gr
 	newGrammar := &Grammar{
 		terminals:    []Symbol{},
 		NonTerminals: []Symbol{},
+		StartSymbol:  startSymbol,
 		Productions:  make(map[Symbol][][]Symbol),
 	}
 
 	// Procesar las producciones de la gramática original
-	for head, productions := range originalGrammar.Productions {
+	// En el orden de los no terminales, para que el resultado siempre sea el mismo
+	for _, head := range productionHeads(originalGrammar) {
+		productions := originalGrammar.Productions[head]
 		// Si el head (no terminal) está en los símbolos no alcanzables, lo omitimos
 		if containsSymbol(unreachableSymbols, head) {
 			continue
@@ -206,8 +215,8 @@ func RemoveNonReachableSymbols(originalGrammar *Grammar, startSymbol Symbol) *Gr
 	}
 
 	// Recorrer la nueva gramática para identificar los terminales y no terminales
-	for _, productions := range newGrammar.Productions {
-		for _, production := range productions {
+	for _, head := range productionHeads(newGrammar) {
+		for _, production := range newGrammar.Productions[head] {
 			for _, symbol := range production {
 				if symbol.IsTerminal {
 					// Añadir a la lista de terminales si no está ya presente
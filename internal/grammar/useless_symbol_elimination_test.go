@@ -125,3 +125,18 @@ func TestRemoveUselessSymbols(t *testing.T) {
 		t.Errorf("Error: La gramática resultante de RemoveUselessSymbols no coincide con la esperada.\nEsperado: %v\nObtenido: %v", expectedTestUselessSymbolElimination.String(true), result.String(true))
 	}
 }
+
+// Un no terminal sin producciones que no está en NonTerminals tampoco es generador
+func TestRemoveNonGeneratingSymbolsWithoutProductions(t *testing.T) {
+	grammars, err := ParseGrammars("test.txt", []string{"S -> {V}{B}|a", "V -> b"})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+	g := grammars[0]
+	g.NonTerminals = []Symbol{{Value: "S"}, {Value: "V"}}
+
+	result := RemoveUselessSymbols(g, g.GetStartSymbol())
+	if violations := uselessViolations(result); len(violations) > 0 {
+		t.Errorf("Expected only useful symbols, but got %v in %q", violations, result.String(true))
+	}
+}
@@ -0,0 +1,456 @@
+package grammar
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+Validadores de las formas de una gramática. Cada uno devuelve si la gramática
+tiene la forma y las producciones que no la cumplen, con la razón:
+
+	if ok, violations := g.IsCNF(); !ok {
+		for _, violation := range violations {
+			fmt.Println(violation)
+		}
+	}
+
+El pipeline revisa la forma que debe dejar cada pasada en cuanto la pasada
+reporta su etapa (por ejemplo IsEpsilonFree en la etapa epsilon-free), y
+avisa al Observer con un EventViolations si la gramática no la cumple.
+
+La recursión por la izquierda se busca también indirecta (A -> {B}a,
+B -> {A}b) y escondida detrás de símbolos anulables (A -> {B}{A}a con B
+anulable).
+*/
+
+// Names of the properties checked by the validators and CheckPassProperties.
+const (
+	PropertyLeftFactored      = "left-factored"
+	PropertyLeftRecursionFree = "left-recursion-free"
+	PropertyEpsilonFree       = "epsilon-free"
+	PropertyUnitFree          = "unit-free"
+	PropertyUseful            = "useful"
+	PropertyChomsky           = "chomsky-normal-form"
+	PropertyGreibach          = "greibach-normal-form"
+	PropertyLanguageKept      = "language-kept"
+)
+
+// A production that breaks a property of the grammar.
+type ProductionViolation struct {
+	Property string   `json:"property"`
+	Head     Symbol   `json:"head"`
+	Body     []Symbol `json:"body"`
+	Reason   string   `json:"reason"`
+}
+
+func (v ProductionViolation) String() string {
+	return fmt.Sprintf("%s -> %s: %s", v.Head.String(), strings.TrimSpace(productionString(v.Body)), v.Reason)
+}
+
+// Checks that every body is a terminal or two NON terminals. The start
+// symbol S may also have S -> ε if it is not used in any body.
+//
+// Returns: true if the grammar is in Chomsky Normal Form, and the bodies that are not.
+func (g *Grammar) IsCNF() (bool, []ProductionViolation) {
+	violations := chomskyViolations(g)
+	return len(violations) == 0, violations
+}
+
+// Checks that every body is a terminal followed by NON terminals. The start
+// symbol S may also have S -> ε if it is not used in any body.
+//
+// Returns: true if the grammar is in Greibach Normal Form, and the bodies that are not.
+func (g *Grammar) IsGNF() (bool, []ProductionViolation) {
+	violations := greibachViolations(g)
+	return len(violations) == 0, violations
+}
+
+// Checks that no body has ε, not even the bodies of the start symbol.
+//
+// Returns: true if the grammar has no ε productions, and the bodies with ε.
+func (g *Grammar) IsEpsilonFree() (bool, []ProductionViolation) {
+	violations := epsilonViolations(g)
+	return len(violations) == 0, violations
+}
+
+// Checks whether some body is a single NON terminal.
+//
+// Returns: true if the grammar has unit productions, and those productions.
+func (g *Grammar) HasUnitProductions() (bool, []ProductionViolation) {
+	violations := unitViolations(g)
+	return len(violations) > 0, violations
+}
+
+// Checks whether some NON terminal A derives a body that starts with A, in
+// one step (A -> {A}a), through other NON terminals (A -> {B}a, B -> {A}b) or
+// after nullable symbols (A -> {B}{A}a with B =>* ε).
+//
+// Returns: true if the grammar is left recursive, and the bodies that start
+// a left recursive derivation.
+func (g *Grammar) IsLeftRecursive() (bool, []ProductionViolation) {
+	violations := leftRecursionViolations(g)
+	return len(violations) > 0, violations
+}
+
+// Checks that no two bodies of the same NON terminal start with the same
+// symbol.
+//
+// Returns: true if the grammar is factored by the left, and the bodies that
+// share their first symbol.
+func (g *Grammar) IsLeftFactored() (bool, []ProductionViolation) {
+	violations := leftFactorViolations(g)
+	return len(violations) == 0, violations
+}
+
+// Property that the grammar of a stage must have.
+type stageProperty struct {
+	title string // Title of the EventViolations, in the words printed by the CLI.
+	check func(g *Grammar) []ProductionViolation
+}
+
+// Properties checked by the pipeline, by the name of the stage that must have them.
+var stageProperties = map[string]stageProperty{
+	StageLeftFactored:      {title: "La gramática no quedó factorizada por la izquierda", check: leftFactorViolations},
+	StageLeftRecursionFree: {title: "La gramática todavía tiene recursión por la izquierda", check: leftRecursionViolations},
+	StageEpsilonFree:       {title: "La gramática todavía tiene producciones epsilon", check: epsilonViolations},
+	StageUnitFree:          {title: "La gramática todavía tiene producciones unarias", check: unitViolations},
+	StageUseful:            {title: "La gramática todavía tiene símbolos inútiles", check: uselessViolations},
+	StageChomskyNormalForm: {title: "La gramática no está en forma normal de Chomsky", check: chomskyViolations},
+
+	StageGNFLeftRecursionFree: {title: "La gramática todavía tiene recursión por la izquierda", check: leftRecursionViolations},
+	StageGreibachNormalForm:   {title: "La gramática no está en forma normal de Greibach", check: greibachViolations},
+}
+
+// Returns: the productions of the grammar that break the property of the
+// stage, and the title to report them, or nil if the stage has no property.
+func stageViolations(stage string, g *Grammar) (string, []ProductionViolation) {
+	property, exist := stageProperties[stage]
+	if !exist {
+		return "", nil
+	}
+	return property.title, property.check(g)
+}
+
+// Calls visit with every production of the grammar, in the order of the
+// NON terminals, and collects the violations it returns.
+func collectViolations(g *Grammar, visit func(head Symbol, body []Symbol) []ProductionViolation) []ProductionViolation {
+	violations := make([]ProductionViolation, 0)
+	for _, head := range productionHeads(g) {
+		for _, body := range g.Productions[head] {
+			violations = append(violations, visit(head, body)...)
+		}
+	}
+	return violations
+}
+
+// Returns: true if some body of the grammar uses the start symbol.
+func startSymbolUsed(g *Grammar) bool {
+	start := g.GetStartSymbol()
+	for _, bodies := range g.Productions {
+		for _, body := range bodies {
+			if containsSymbol(body, start) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Returns: the bodies that are not a terminal or two NON terminals, except
+// S -> ε when the start symbol S is not used in any body.
+func chomskyViolations(g *Grammar) []ProductionViolation {
+	start, startUsed := g.GetStartSymbol(), startSymbolUsed(g)
+	return collectViolations(g, func(head Symbol, body []Symbol) []ProductionViolation {
+		violation := ProductionViolation{Property: PropertyChomsky, Head: head, Body: body}
+		switch {
+		case len(body) == 1 && body[0] == EpsilonSymbol:
+			if head == start && !startUsed {
+				return nil
+			}
+			violation.Reason = "ε is only allowed for a start symbol that is not used in any body"
+		case len(body) == 1 && body[0].IsTerminal:
+			return nil
+		case len(body) == 2 && !body[0].IsTerminal && !body[1].IsTerminal:
+			return nil
+		default:
+			violation.Reason = "is not a terminal or two NON terminals"
+		}
+		return []ProductionViolation{violation}
+	})
+}
+
+// Returns: the bodies that are not a terminal followed by NON terminals,
+// except S -> ε when the start symbol S is not used in any body.
+func greibachViolations(g *Grammar) []ProductionViolation {
+	start, startUsed := g.GetStartSymbol(), startSymbolUsed(g)
+	return collectViolations(g, func(head Symbol, body []Symbol) []ProductionViolation {
+		violation := ProductionViolation{Property: PropertyGreibach, Head: head, Body: body}
+		switch {
+		case len(body) == 1 && body[0] == EpsilonSymbol:
+			if head == start && !startUsed {
+				return nil
+			}
+			violation.Reason = "ε is only allowed for a start symbol that is not used in any body"
+		case len(body) == 0 || !body[0].IsTerminal || body[0] == EpsilonSymbol:
+			violation.Reason = "does not start with a terminal"
+		default:
+			for _, symbol := range body[1:] {
+				if symbol.IsTerminal {
+					violation.Reason = fmt.Sprintf("has the terminal %s after the first symbol", symbol.String())
+					break
+				}
+			}
+			if violation.Reason == "" {
+				return nil
+			}
+		}
+		return []ProductionViolation{violation}
+	})
+}
+
+// Returns: the bodies that have ε.
+func epsilonViolations(g *Grammar) []ProductionViolation {
+	return collectViolations(g, func(head Symbol, body []Symbol) []ProductionViolation {
+		if len(body) > 0 && !containsSymbol(body, EpsilonSymbol) {
+			return nil
+		}
+		return []ProductionViolation{{Property: PropertyEpsilonFree, Head: head, Body: body, Reason: "has ε"}}
+	})
+}
+
+// Returns: the bodies that are a single NON terminal.
+func unitViolations(g *Grammar) []ProductionViolation {
+	return collectViolations(g, func(head Symbol, body []Symbol) []ProductionViolation {
+		if len(body) != 1 || body[0].IsTerminal {
+			return nil
+		}
+		return []ProductionViolation{{Property: PropertyUnitFree, Head: head, Body: body, Reason: "is a single NON terminal"}}
+	})
+}
+
+// Returns: the productions of NON terminals that are not reachable from the
+// start symbol, and the bodies that use NON terminals that are not generating.
+func uselessViolations(g *Grammar) []ProductionViolation {
+	// Generadores: algún cuerpo tiene solo terminales y generadores
+	generating := make(map[Symbol]bool)
+	for changed := true; changed; {
+		changed = false
+		for head, bodies := range g.Productions {
+			for _, body := range bodies {
+				all := !generating[head]
+				for _, symbol := range body {
+					all = all && (symbol.IsTerminal || generating[symbol])
+				}
+				if all {
+					generating[head] = true
+					changed = true
+				}
+			}
+		}
+	}
+
+	// Alcanzables desde el símbolo inicial
+	reachable := map[Symbol]bool{g.GetStartSymbol(): true}
+	pending := []Symbol{g.GetStartSymbol()}
+	for len(pending) > 0 {
+		head := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		for _, body := range g.Productions[head] {
+			for _, symbol := range body {
+				if !symbol.IsTerminal && !reachable[symbol] {
+					reachable[symbol] = true
+					pending = append(pending, symbol)
+				}
+			}
+		}
+	}
+
+	return collectViolations(g, func(head Symbol, body []Symbol) []ProductionViolation {
+		violation := ProductionViolation{Property: PropertyUseful, Head: head, Body: body}
+		if !reachable[head] {
+			violation.Reason = fmt.Sprintf("%s is not reachable from the start symbol", head.String())
+			return []ProductionViolation{violation}
+		}
+		for _, symbol := range body {
+			if !symbol.IsTerminal && !generating[symbol] {
+				violation.Reason = fmt.Sprintf("%s is not generating", symbol.String())
+				return []ProductionViolation{violation}
+			}
+		}
+		return nil
+	})
+}
+
+// Returns: the bodies that share their first symbol with another body of
+// the same NON terminal.
+func leftFactorViolations(g *Grammar) []ProductionViolation {
+	violations := make([]ProductionViolation, 0)
+	for _, head := range productionHeads(g) {
+		bodies := g.Productions[head]
+		for i, body := range bodies {
+			if len(body) == 0 || body[0] == EpsilonSymbol {
+				continue
+			}
+			others := make([]string, 0)
+			for j, other := range bodies {
+				if i != j && len(other) > 0 && other[0] == body[0] {
+					others = append(others, strings.TrimSpace(productionString(other)))
+				}
+			}
+			if len(others) > 0 {
+				violations = append(violations, ProductionViolation{
+					Property: PropertyLeftFactored,
+					Head:     head,
+					Body:     body,
+					Reason:   fmt.Sprintf("starts with %s, like %s", body[0].String(), strings.Join(others, ", ")),
+				})
+			}
+		}
+	}
+	return violations
+}
+
+// Returns: the bodies A -> α such that A =>+ Aβ starting with α, with the
+// left recursive derivation as the reason.
+func leftRecursionViolations(g *Grammar) []ProductionViolation {
+	nullables := nullableSymbols(g)
+
+	// Esquinas izquierdas: los no terminales con los que puede empezar cada
+	// cuerpo, y los sucesores de los ciclos A =>+ A
+	corners := leftCornerGraph(g)
+	successors := cycleGraph(g)
+
+	return collectViolations(g, func(head Symbol, body []Symbol) []ProductionViolation {
+		// Un ciclo no se puede quitar, así que se reporta primero
+		for _, next := range cycleSuccessors(body, nullables) {
+			if path := leftCornerPath(successors, next, head); path != nil {
+				cycle := strings.Join(symbolsToStrings(append([]Symbol{head}, path...)), " => ")
+				reason := "is part of the cycle " + cycle + ", remove the ε and unit productions first"
+				return []ProductionViolation{{Property: PropertyLeftRecursionFree, Head: head, Body: body, Reason: reason}}
+			}
+		}
+
+		for position, corner := range leftCorners(body, nullables) {
+			path := leftCornerPath(corners, corner, head)
+			if path == nil {
+				continue
+			}
+
+			reason := "is directly left recursive"
+			if len(path) > 1 {
+				steps := make([]string, 0, len(path)+1)
+				for _, symbol := range append([]Symbol{head}, path...) {
+					steps = append(steps, symbol.String())
+				}
+				reason = "is indirectly left recursive: " + strings.Join(steps, " => ")
+			}
+			if position > 0 {
+				hidden := leftCorners(body, nullables)[:position]
+				reason += fmt.Sprintf(", after the nullable %s", strings.Join(symbolsToStrings(hidden), " "))
+			}
+			return []ProductionViolation{{Property: PropertyLeftRecursionFree, Head: head, Body: body, Reason: reason}}
+		}
+		return nil
+	})
+}
+
+// Returns: the NON terminals of the body that the head derives alone, A => B,
+// because every other symbol of the body is nullable.
+func cycleSuccessors(body []Symbol, nullables map[Symbol]bool) []Symbol {
+	successors := make([]Symbol, 0, 1)
+	required := 0
+	for _, symbol := range body {
+		if symbol == EpsilonSymbol {
+			continue
+		}
+		if symbol.IsTerminal {
+			return nil
+		}
+		if !nullables[symbol] {
+			required++
+		}
+	}
+	for _, symbol := range body {
+		if symbol == EpsilonSymbol || containsSymbol(successors, symbol) {
+			continue
+		}
+		// Con un símbolo no anulable solo ese puede quedar solo
+		if required == 0 || required == 1 && !nullables[symbol] {
+			successors = append(successors, symbol)
+		}
+	}
+	if required > 1 {
+		return nil
+	}
+	return successors
+}
+
+// Returns: the symbols of the body that can be the first one of a
+// derivation: the first symbol and every symbol after nullable ones, until a
+// terminal. Only the NON terminals are returned, ε is skipped.
+func leftCorners(body []Symbol, nullables map[Symbol]bool) []Symbol {
+	corners := make([]Symbol, 0, 1)
+	for _, symbol := range body {
+		if symbol == EpsilonSymbol {
+			continue
+		}
+		if symbol.IsTerminal {
+			break
+		}
+		corners = append(corners, symbol)
+		if !nullables[symbol] {
+			break
+		}
+	}
+	return corners
+}
+
+// Searches the shortest chain of left corners from a NON terminal to target.
+//
+// Returns: the chain, starting with from and ending with target, or nil if
+// target can not be reached.
+func leftCornerPath(corners map[Symbol][]Symbol, from Symbol, target Symbol) []Symbol {
+	previous := map[Symbol]Symbol{from: from}
+	pending := []Symbol{from}
+	for len(pending) > 0 {
+		current := pending[0]
+		pending = pending[1:]
+		if current == target {
+			path := []Symbol{current}
+			for current != from {
+				current = previous[current]
+				path = append([]Symbol{current}, path...)
+			}
+			return path
+		}
+		for _, next := range corners[current] {
+			if _, seen := previous[next]; !seen {
+				previous[next] = current
+				pending = append(pending, next)
+			}
+		}
+	}
+	return nil
+}
+
+// Returns: the NON terminals that derive the empty string.
+func nullableSymbols(g *Grammar) map[Symbol]bool {
+	nullables := make(map[Symbol]bool)
+	for changed := true; changed; {
+		changed = false
+		for head, bodies := range g.Productions {
+			for _, body := range bodies {
+				nullable := !nullables[head]
+				for _, symbol := range body {
+					nullable = nullable && (symbol == EpsilonSymbol || !symbol.IsTerminal && nullables[symbol])
+				}
+				if nullable {
+					nullables[head] = true
+					changed = true
+				}
+			}
+		}
+	}
+	return nullables
+}
@@ -0,0 +1,184 @@
+package grammar
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Devuelve las violaciones como texto, para compararlas
+func violationStrings(violations []ProductionViolation) []string {
+	result := make([]string, 0, len(violations))
+	for _, violation := range violations {
+		result = append(result, violation.String())
+	}
+	return result
+}
+
+// Lee una gramática de prueba
+func parseTestGrammar(t *testing.T, lines ...string) *Grammar {
+	t.Helper()
+	grammars, err := ParseGrammars("test.txt", lines)
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+	return grammars[0]
+}
+
+func TestValidators(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		validate func(g *Grammar) (bool, []ProductionViolation)
+		expected bool
+		found    []string
+	}{
+		{
+			name:     "CNF",
+			lines:    []string{"S -> {A}{B}|ε", "A -> a", "B -> {A}{B}|b"},
+			validate: (*Grammar).IsCNF,
+			expected: true,
+			found:    []string{},
+		},
+		{
+			name:     "not CNF",
+			lines:    []string{"S -> {A}{S}|a{A}|ε", "A -> a|{S}"},
+			validate: (*Grammar).IsCNF,
+			expected: false,
+			found: []string{
+				"{S_0} -> a{A_0}: is not a terminal or two NON terminals",
+				"{S_0} -> ε: ε is only allowed for a start symbol that is not used in any body",
+				"{A_0} -> {S_0}: is not a terminal or two NON terminals",
+			},
+		},
+		{
+			name:     "GNF",
+			lines:    []string{"S -> a{S}{B}|b", "B -> b"},
+			validate: (*Grammar).IsGNF,
+			expected: true,
+			found:    []string{},
+		},
+		{
+			name:     "not GNF",
+			lines:    []string{"S -> {B}a|a{S}b|ε", "B -> b"},
+			validate: (*Grammar).IsGNF,
+			expected: false,
+			found: []string{
+				"{S_0} -> {B_0}a: does not start with a terminal",
+				"{S_0} -> a{S_0}b: has the terminal b after the first symbol",
+				"{S_0} -> ε: ε is only allowed for a start symbol that is not used in any body",
+			},
+		},
+		{
+			name:     "ε productions",
+			lines:    []string{"S -> a{S}|ε", "A -> bε"},
+			validate: (*Grammar).IsEpsilonFree,
+			expected: false,
+			found:    []string{"{S_0} -> ε: has ε", "{A_0} -> bε: has ε"},
+		},
+		{
+			name:     "unit productions",
+			lines:    []string{"S -> {A}|a", "A -> {S}{S}|{B}", "B -> b"},
+			validate: (*Grammar).HasUnitProductions,
+			expected: true,
+			found:    []string{"{S_0} -> {A_0}: is a single NON terminal", "{A_0} -> {B_0}: is a single NON terminal"},
+		},
+		{
+			name:     "not factored",
+			lines:    []string{"S -> a{S}b|a|b{S}|ε"},
+			validate: (*Grammar).IsLeftFactored,
+			expected: false,
+			found:    []string{"{S_0} -> a{S_0}b: starts with a, like a", "{S_0} -> a: starts with a, like a{S_0}b"},
+		},
+	}
+
+	for _, test := range tests {
+		ok, violations := test.validate(parseTestGrammar(t, test.lines...))
+		if ok != test.expected {
+			t.Errorf("%s: Expected %v, but got %v", test.name, test.expected, ok)
+		}
+		if found := violationStrings(violations); !reflect.DeepEqual(found, test.found) {
+			t.Errorf("%s: Expected %q, but got %q", test.name, test.found, found)
+		}
+	}
+}
+
+func TestIsLeftRecursive(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		found []string
+	}{
+		{
+			name:  "direct",
+			lines: []string{"E -> {E}+{T}|{T}", "T -> x"},
+			found: []string{"{E_0} -> {E_0}+{T_0}: is directly left recursive"},
+		},
+		{
+			name:  "indirect",
+			lines: []string{"A -> {B}a|c", "B -> {A}b|d"},
+			found: []string{
+				"{A_0} -> {B_0}a: is indirectly left recursive: {A_0} => {B_0} => {A_0}",
+				"{B_0} -> {A_0}b: is indirectly left recursive: {B_0} => {A_0} => {B_0}",
+			},
+		},
+		{
+			name:  "hidden",
+			lines: []string{"S -> {N}{S}a|b", "N -> n|ε"},
+			found: []string{"{S_0} -> {N_0}{S_0}a: is directly left recursive, after the nullable {N_0}"},
+		},
+		{
+			name:  "not recursive",
+			lines: []string{"S -> a{S}|{N}b{S}|ε", "N -> n|ε"},
+			found: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		recursive, violations := parseTestGrammar(t, test.lines...).IsLeftRecursive()
+		if recursive != (len(test.found) > 0) {
+			t.Errorf("%s: Expected left recursion to be %v, but got %v", test.name, len(test.found) > 0, recursive)
+		}
+		if found := violationStrings(violations); !reflect.DeepEqual(found, test.found) {
+			t.Errorf("%s: Expected %q, but got %q", test.name, test.found, found)
+		}
+	}
+}
+
+func TestPipelineChecksStages(t *testing.T) {
+	g := parseTestGrammar(t, "S -> a{S}b|{A}", "A -> ε|c")
+
+	// Una pasada chomsky que no hace nada
+	skip := funcPass{name: PassChomsky, apply: func(g *Grammar, ctx *PassContext) *Grammar {
+		ctx.Stage(StageChomskyNormalForm, StageChomskyNormalForm, g)
+		return g
+	}}
+
+	events := 0
+	result := NewPipeline(EpsilonPass(), UnitPass(), skip).RunResultWith(g, func(event Event) {
+		if event.Kind == EventViolations {
+			events++
+			if event.Pass != PassChomsky || event.Stage != StageChomskyNormalForm {
+				t.Errorf("Expected the violations of the chomsky pass, but got %q in %q", event.Pass, event.Stage)
+			}
+		}
+	})
+	if events != 1 {
+		t.Errorf("Expected 1 EventViolations, but got %d", events)
+	}
+
+	for _, stage := range result.Stages {
+		if stage.Name != StageChomskyNormalForm && len(stage.Violations) > 0 {
+			t.Errorf("Expected no violations in %s, but got %v", stage.Name, stage.Violations)
+		}
+	}
+	stage, _ := result.Stage(StageChomskyNormalForm)
+	expected := []string{"{S_0} -> a{S_0}b: is not a terminal or two NON terminals", "{S_0} -> ab: is not a terminal or two NON terminals"}
+	if found := violationStrings(stage.Violations); !reflect.DeepEqual(found, expected) {
+		t.Errorf("Expected %q, but got %q", expected, found)
+	}
+
+	final, _ := SimplifyGrammarResult(g, false).Stage(StageChomskyNormalForm)
+	if len(final.Violations) > 0 {
+		t.Errorf("Expected the chomsky pass to produce CNF, but got %v", final.Violations)
+	}
+}
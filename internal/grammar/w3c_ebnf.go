@@ -0,0 +1,446 @@
+package grammar
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	ast "github.com/DanielRasho/Computation-Theory/internal/abstract_syntax_tree"
+)
+
+// Lector de gramáticas escritas en la notación EBNF de la W3C (la de la
+// especificación de XML), que usan muchas especificaciones de lenguajes:
+//
+//	[1] Name    ::= [a-z]+ ("," Name)*
+//	    Digits  ::= [0-9]+ | "0x" [0-9a-fA-F]+
+//	    Text    ::= [^"]* /* cualquier caracter excepto " */
+//
+// Soporta:
+//   - Alternativas con |, concatenación con espacios, grupos ( ) y los operadores ?, * y +.
+//   - Cadenas "abc" o 'abc', y caracteres #x41.
+//   - Clases de caracteres [abc], rangos [a-z] o [#x30-#x39], y clases negadas
+//     [^abc], que se interpretan sobre los caracteres ASCII imprimibles, el
+//     tabulador y los saltos de línea.
+//   - Comentarios /* ... */ y números de producción [1] antes de cada regla.
+//
+// Una regla continúa en las líneas siguientes hasta la siguiente línea con
+// "Nombre ::=". Los no terminales auxiliares de los operadores tienen el nombre
+// de la regla donde aparecen, con un Id nuevo. Los guiones y puntos de los
+// nombres se convierten en guiones bajos.
+
+// Symbol that separates the name of a W3C EBNF rule from its expression.
+const W3CDefinition = "::="
+
+// w3cParser reads the W3C EBNF rules of a file, one rule at a time.
+type w3cParser struct {
+	ruleReader
+}
+
+// Parses the lines of a W3C EBNF file. Like ParseGrammars, grammars are
+// separated by a "---" line.
+//
+// Returns: the list of grammars, and a ParseErrors if any error was found.
+func ParseW3CEBNF(file string, lines []string) ([]*Grammar, error) {
+	p := &w3cParser{ruleReader: ruleReader{grammarParser: &grammarParser{file: file}}}
+	grammars := make([]*Grammar, 0)
+	p.resetGrammar()
+
+	var comment *sourceChar // Start of an unclosed comment.
+	for index, line := range lines {
+		p.line = index + 1
+
+		if line == GrammarSeparator && comment == nil {
+			p.parseW3CRule()
+			if g := p.closeGrammar(); g != nil {
+				grammars = append(grammars, g)
+			}
+			continue
+		}
+
+		chars := stripW3CComments(toSourceChars(line, p.line), &comment)
+		if strings.TrimSpace(sourceString(chars)) == "" {
+			continue
+		}
+
+		if isW3CRuleStart(sourceString(chars)) {
+			p.parseW3CRule()
+		} else if len(p.rule) > 0 {
+			// Continuation of the current rule
+			p.rule = append(p.rule, sourceChar{char: ' ', line: p.line, column: 0})
+		}
+		p.rule = append(p.rule, chars...)
+	}
+	if comment != nil {
+		p.errorAt(*comment, "unclosed comment", "close the comment with */")
+	}
+	p.parseW3CRule()
+	if g := p.closeGrammar(); g != nil {
+		grammars = append(grammars, g)
+	}
+
+	if len(p.errors) > 0 {
+		return grammars, p.errors
+	}
+	return grammars, nil
+}
+
+// Parses the rule accumulated in p.rule, adding its productions to the current grammar.
+func (p *w3cParser) parseW3CRule() {
+	if len(p.rule) == 0 {
+		return
+	}
+	defer func() { p.rule, p.i, p.symbols = nil, 0, nil }()
+
+	// [n]
+	p.skipSpaces()
+	if p.peek() == '[' {
+		for p.i < len(p.rule) && p.peek() != ']' {
+			p.i++
+		}
+		p.i++
+		p.skipSpaces()
+	}
+
+	// NAME
+	if !isW3CNameStart(p.peek()) {
+		p.errorAtCurrent("missing rule name", "rules have the form Name ::= expression")
+		return
+	}
+	head := p.name()
+
+	// ::=
+	p.skipSpaces()
+	if !strings.HasPrefix(sourceString(p.rule[p.i:]), W3CDefinition) {
+		p.errorAtCurrent("missing '::=' after the rule name", "rules have the form Name ::= expression")
+		return
+	}
+	p.i += len(W3CDefinition)
+
+	node, ok := p.choice()
+	if !ok {
+		return
+	}
+	p.skipSpaces()
+	if p.i < len(p.rule) {
+		if p.peek() == ')' {
+			p.errorAtCurrent("unexpected ')'", "remove it or open the group with '('")
+		} else {
+			p.errorAtCurrent("unexpected character", "alternatives are separated by '|'")
+		}
+		return
+	}
+
+	p.addRule(head, node)
+}
+
+// choice = sequence ("|" sequence)*
+func (p *w3cParser) choice() (ast.Node, bool) {
+	node, ok := p.sequence()
+	if !ok {
+		return nil, false
+	}
+	for {
+		p.skipSpaces()
+		if p.peek() != '|' {
+			return node, true
+		}
+		p.i++
+		next, ok := p.sequence()
+		if !ok {
+			return nil, false
+		}
+		node = ast.NewOperatorNode("|", []ast.Node{node, next})
+	}
+}
+
+// sequence = item+
+func (p *w3cParser) sequence() (ast.Node, bool) {
+	nodes := make([]ast.Node, 0)
+	for {
+		p.skipSpaces()
+		if p.i == len(p.rule) || p.peek() == '|' || p.peek() == ')' {
+			break
+		}
+		if p.peek() == '-' {
+			p.errorAtCurrent("exclusions with '-' are not supported", "rewrite the rule without '-', context free grammars cannot remove strings from a language")
+			return nil, false
+		}
+		node, ok := p.item()
+		if !ok {
+			return nil, false
+		}
+		nodes = append(nodes, node)
+	}
+
+	if len(nodes) == 0 {
+		p.errorAtCurrent("empty alternative", `use "" to write the empty string`)
+		return nil, false
+	}
+	return p.concatenate(nodes), true
+}
+
+// item = primary ("?" | "*" | "+")*
+func (p *w3cParser) item() (ast.Node, bool) {
+	node, ok := p.primary()
+	if !ok {
+		return nil, false
+	}
+	for p.peek() == '?' || p.peek() == '*' || p.peek() == '+' {
+		node = ast.NewOperatorNode(string(p.peek()), []ast.Node{node})
+		p.i++
+	}
+	return node, true
+}
+
+// primary = Name | string | #xN | [class] | "(" choice ")"
+func (p *w3cParser) primary() (ast.Node, bool) {
+	switch current := p.peek(); {
+	case isW3CNameStart(current):
+		return p.operand(p.name()), true
+
+	case current == '"' || current == '\'':
+		open := p.rule[p.i]
+		p.i++
+		nodes := make([]ast.Node, 0)
+		for p.i < len(p.rule) && p.peek() != current {
+			nodes = append(nodes, p.operand(Symbol{Value: string(p.peek()), IsTerminal: true, Id: 0}))
+			p.i++
+		}
+		if p.i == len(p.rule) {
+			p.errorAt(open, "unclosed string", fmt.Sprintf("close the string with %c", current))
+			return nil, false
+		}
+		p.i++
+		return p.concatenate(nodes), true
+
+	case current == '#':
+		char, ok := p.hexChar()
+		if !ok {
+			return nil, false
+		}
+		return p.operand(Symbol{Value: string(char), IsTerminal: true, Id: 0}), true
+
+	case current == '[':
+		return p.charClass()
+
+	case current == '(':
+		open := p.rule[p.i]
+		p.i++
+		node, ok := p.choice()
+		if !ok {
+			return nil, false
+		}
+		p.skipSpaces()
+		if p.peek() != ')' {
+			p.errorAt(open, "unclosed '('", "close the group with ')'")
+			return nil, false
+		}
+		p.i++
+		return node, true
+
+	case current == '?' || current == '*' || current == '+':
+		p.errorAtCurrent("operator without operand", "write the symbol or group it applies to before it")
+		return nil, false
+
+	default:
+		p.errorAtCurrent("unexpected character", `expressions are made of names, strings like "a", characters like #x41, classes like [a-z] and groups ( )`)
+		return nil, false
+	}
+}
+
+// Parses a character class like [a-z0-9_] or [^"], starting at the "[".
+func (p *w3cParser) charClass() (ast.Node, bool) {
+	open := p.rule[p.i]
+	p.i++
+
+	negated := p.peek() == '^'
+	if negated {
+		p.i++
+	}
+
+	included := make(map[rune]bool)
+	for p.i < len(p.rule) && p.peek() != ']' {
+		first, ok := p.classChar()
+		if !ok {
+			return nil, false
+		}
+		last := first
+		// A "-" before "]" is the character itself
+		if p.peek() == '-' && p.i+1 < len(p.rule) && p.rule[p.i+1].char != ']' {
+			p.i++
+			if last, ok = p.classChar(); !ok {
+				return nil, false
+			}
+		}
+		if last < first {
+			p.errorAt(open, "empty character range", "write the smallest character first, like [a-z]")
+			return nil, false
+		}
+		if last-first+1 > maxValueRange {
+			p.errorAt(open, "character class too large", fmt.Sprintf("character classes can have at most %d characters", maxValueRange))
+			return nil, false
+		}
+		for char := first; char <= last; char++ {
+			included[char] = true
+		}
+	}
+	if p.i == len(p.rule) {
+		p.errorAt(open, "unclosed '['", "close the character class with ']'")
+		return nil, false
+	}
+	p.i++
+
+	chars := make([]rune, 0)
+	if negated {
+		for _, char := range w3cNegationUniverse() {
+			if !included[char] {
+				chars = append(chars, char)
+			}
+		}
+	} else {
+		for char := rune(0); len(chars) < len(included); char++ {
+			if included[char] {
+				chars = append(chars, char)
+			}
+		}
+	}
+
+	if len(chars) == 0 {
+		p.errorAt(open, "empty character class", "write at least one character between the brackets")
+		return nil, false
+	}
+	if len(chars) > maxValueRange {
+		p.errorAt(open, "character class too large", fmt.Sprintf("character classes can have at most %d characters", maxValueRange))
+		return nil, false
+	}
+	return p.alternateChars(chars), true
+}
+
+// Reads a character of a class, written as itself or as #xN.
+func (p *w3cParser) classChar() (rune, bool) {
+	if p.peek() == '#' {
+		return p.hexChar()
+	}
+	char := p.peek()
+	p.i++
+	return char, true
+}
+
+// Reads a character written as #xN.
+func (p *w3cParser) hexChar() (rune, bool) {
+	start := p.rule[p.i]
+	p.i++
+	if p.peek() != 'x' {
+		p.errorAt(start, "invalid character value", "write characters as #x followed by hexadecimal digits, like #x41")
+		return 0, false
+	}
+	p.i++
+
+	digitsStart := p.i
+	for p.i < len(p.rule) && strings.ContainsRune("0123456789abcdefABCDEF", p.peek()) {
+		p.i++
+	}
+	value, err := strconv.ParseInt(sourceString(p.rule[digitsStart:p.i]), 16, 32)
+	if err != nil || value > unicode.MaxRune {
+		p.errorAt(start, "invalid character value", "write characters as #x followed by hexadecimal digits, like #x41")
+		return 0, false
+	}
+	return rune(value), true
+}
+
+// Reads a rule name and returns its NON terminal.
+func (p *w3cParser) name() Symbol {
+	start := p.i
+	for p.i < len(p.rule) && isW3CNameChar(p.peek()) {
+		p.i++
+	}
+	name := strings.NewReplacer("-", "_", ".", "_").Replace(sourceString(p.rule[start:p.i]))
+	return Symbol{Value: name, IsTerminal: false, Id: 0}
+}
+
+func isW3CNameStart(char rune) bool {
+	return unicode.IsLetter(char) || char == '_'
+}
+
+func isW3CNameChar(char rune) bool {
+	return isW3CNameStart(char) || unicode.IsDigit(char) || char == '-' || char == '.'
+}
+
+// Returns: true if the line starts a W3C EBNF rule, a name followed by ::=,
+// with an optional production number like [12] before the name.
+func isW3CRuleStart(line string) bool {
+	rest := strings.TrimLeftFunc(line, unicode.IsSpace)
+	if strings.HasPrefix(rest, "[") {
+		end := strings.IndexRune(rest, ']')
+		if end < 0 {
+			return false
+		}
+		number := strings.TrimSpace(rest[1:end])
+		if number == "" || strings.Trim(number, "0123456789") != "" {
+			return false
+		}
+		rest = strings.TrimLeftFunc(rest[end+1:], unicode.IsSpace)
+	}
+
+	chars := []rune(rest)
+	if len(chars) == 0 || !isW3CNameStart(chars[0]) {
+		return false
+	}
+	end := 1
+	for end < len(chars) && isW3CNameChar(chars[end]) {
+		end++
+	}
+	return strings.HasPrefix(strings.TrimLeftFunc(string(chars[end:]), unicode.IsSpace), W3CDefinition)
+}
+
+// returns: the characters a negated class is taken from, the printable
+// ASCII characters, the tab and the line breaks.
+func w3cNegationUniverse() []rune {
+	universe := []rune{'\t', '\n', '\r'}
+	for char := rune(0x20); char <= 0x7E; char++ {
+		universe = append(universe, char)
+	}
+	return universe
+}
+
+// Removes the /* */ comments of a line, ignoring the ones inside strings and
+// character classes. comment points to the start of a comment that is still
+// open at the end of the previous line, or nil.
+func stripW3CComments(chars []sourceChar, comment **sourceChar) []sourceChar {
+	result := make([]sourceChar, 0, len(chars))
+	quote := rune(0) // Quote of the current string, 0 outside strings.
+	inClass := false
+
+	for i := 0; i < len(chars); i++ {
+		char := chars[i].char
+		startsComment := i+1 < len(chars) && char == '/' && chars[i+1].char == '*'
+		endsComment := i+1 < len(chars) && char == '*' && chars[i+1].char == '/'
+
+		switch {
+		case *comment != nil:
+			if endsComment {
+				*comment = nil
+				i++
+			}
+			continue
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case inClass:
+			inClass = char != ']'
+		case startsComment:
+			start := chars[i]
+			*comment = &start
+			i++
+			continue
+		case char == '"' || char == '\'':
+			quote = char
+		case char == '[':
+			inClass = true
+		}
+		result = append(result, chars[i])
+	}
+	return result
+}
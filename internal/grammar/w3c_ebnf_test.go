@@ -0,0 +1,137 @@
+package grammar
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseW3CEBNF(t *testing.T) {
+	lines := []string{
+		"/* Lista de nombres */",
+		`[1] List ::= Name ("," Name)*`,
+		`[2] Name ::= [ab]+`,
+		`    Sign ::= '+' | #x2D /* menos */`,
+		`           | [^#x20-#x7E]`,
+	}
+
+	grammars, err := ParseW3CEBNF("test.ebnf", lines)
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+
+	// Helpers are named after the rule where they appear
+	expected := "{List_0} -> {Name_0}{List_1}\n" +
+		"{List_1} -> ,{Name_0}{List_1}|ε\n" +
+		"{Name_0} -> {Name_1}\n" +
+		"{Name_1} -> a{Name_1}|b{Name_1}|a|b\n" +
+		"{Sign_0} -> +|-|\\t|\\n|\\r\n"
+	if got := grammars[0].String(false); got != expected {
+		t.Errorf("Expected %q,\n but got %q", expected, got)
+	}
+	if start := grammars[0].GetStartSymbol(); start.Value != "List" {
+		t.Errorf("Expected start symbol List, but got %s", start.String())
+	}
+}
+
+func TestParseW3CEBNFUnicodeNames(t *testing.T) {
+	grammars, err := ParseW3CEBNF("test.ebnf", []string{
+		`Ñame  ::= Año "."`,
+		`[2] Año ::= 'ñ'`,
+		`        | "a"`,
+	})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+
+	// Cada regla empieza en su propia línea aunque el nombre no sea ASCII
+	expected := "{Ñame_0} -> {Año_0}.\n{Año_0} -> ñ|a\n"
+	if got := grammars[0].String(false); got != expected {
+		t.Errorf("Expected %q,\n but got %q", expected, got)
+	}
+}
+
+func TestParseW3CEBNFCommentsInStrings(t *testing.T) {
+	grammars, err := ParseW3CEBNF("test.ebnf", []string{`Comment ::= "/*" [^*/]* '*/'`})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+
+	simplified := SimplifyGrammar(grammars[0], false)
+	start := simplified.GetStartSymbol()
+	if !CYKParse(simplified, "/*ab*/", start) {
+		t.Errorf("Expected /*ab*/ to be accepted")
+	}
+	if CYKParse(simplified, "/*a*b*/", start) {
+		t.Errorf("Expected /*a*b*/ to be rejected")
+	}
+}
+
+func TestSimplifyGrammarW3CEBNF(t *testing.T) {
+	grammars, err := ParseW3CEBNF("test.ebnf", []string{
+		`Name ::= [a-z]+ ("," Name)*`,
+	})
+	if err != nil {
+		t.Fatalf("Expected no errors, but got:\n%v", err)
+	}
+
+	simplified := SimplifyGrammar(grammars[0], false)
+	start := simplified.GetStartSymbol()
+
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"abc", true},
+		{"ab,c,xyz", true},
+		{"ab,", false},
+		{"aB", false},
+	}
+	for _, tt := range tests {
+		if got := CYKParse(simplified, tt.input, start); got != tt.expected {
+			t.Errorf("CYKParse(%q) = %v, expected %v", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestParseW3CEBNFErrors(t *testing.T) {
+	lines := []string{
+		`G = "x"`,
+		`A ::= "a" | | "b"`,
+		`B ::= [a-z`,
+		`C ::= "x" - "y"`,
+		`D ::= #q41`,
+		`E ::= [z-a]`,
+		`F ::= (a`,
+		`H ::= *a`,
+	}
+
+	expected := []ParseError{
+		{Line: 1, Column: 3, Char: "=", Message: "missing '::=' after the rule name"},
+		{Line: 2, Column: 13, Char: "|", Message: "empty alternative"},
+		{Line: 3, Column: 7, Char: "[", Message: "unclosed '['"},
+		{Line: 4, Column: 11, Char: "-", Message: "exclusions with '-' are not supported"},
+		{Line: 5, Column: 7, Char: "#", Message: "invalid character value"},
+		{Line: 6, Column: 7, Char: "[", Message: "empty character range"},
+		{Line: 7, Column: 7, Char: "(", Message: "unclosed '('"},
+		{Line: 8, Column: 7, Char: "*", Message: "operator without operand"},
+	}
+
+	_, err := ParseW3CEBNF("test.ebnf", lines)
+
+	var parseErrors ParseErrors
+	if !errors.As(err, &parseErrors) {
+		t.Fatalf("Expected ParseErrors, but got %v", err)
+	}
+	if len(parseErrors) != len(expected) {
+		t.Fatalf("Expected %d errors, but got %d:\n%v", len(expected), len(parseErrors), err)
+	}
+	for i, e := range expected {
+		got := parseErrors[i]
+		if got.File != "test.ebnf" || got.Line != e.Line || got.Column != e.Column || got.Char != e.Char || got.Message != e.Message {
+			t.Errorf("Expected error %d to be %v, but got %v", i, e, got)
+		}
+		if got.Hint == "" {
+			t.Errorf("Expected error %d to have a hint", i)
+		}
+	}
+}
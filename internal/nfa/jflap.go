@@ -0,0 +1,295 @@
+package nfa
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+/*
+Lectura y escritura de autómatas finitos en el formato de JFLAP (.jff):
+
+	<structure>
+	  <type>fa</type>
+	  <automaton>
+	    <state id="0" name="q0"><x>100</x><y>100</y><initial/></state>
+	    <state id="1" name="q1"><x>250</x><y>100</y><final/></state>
+	    <transition><from>0</from><to>1</to><read>a</read></transition>
+	  </automaton>
+	</structure>
+
+Una transición con <read/> vacío es una transición ε. JFLAP permite leer
+varios caracteres en una transición, y al importarla como AFN se divide en
+una cadena de transiciones de un caracter con estados intermedios. Las
+versiones anteriores de JFLAP escriben los estados directamente dentro de
+<structure>, sin <automaton>, y también se aceptan.
+*/
+
+// JFLAPType es el tipo de los archivos de JFLAP con autómatas finitos.
+const JFLAPType = "fa"
+
+type jflapFile struct {
+	XMLName   xml.Name        `xml:"structure"`
+	Type      string          `xml:"type"`
+	Automaton *jflapAutomaton `xml:"automaton"`
+	jflapAutomaton
+}
+
+type jflapAutomaton struct {
+	States      []jflapState      `xml:"state"`
+	Transitions []jflapTransition `xml:"transition"`
+}
+
+type jflapState struct {
+	Id      string    `xml:"id,attr"`
+	Name    string    `xml:"name,attr"`
+	X       float64   `xml:"x"`
+	Y       float64   `xml:"y"`
+	Initial *struct{} `xml:"initial"`
+	Final   *struct{} `xml:"final"`
+}
+
+type jflapTransition struct {
+	From string `xml:"from"`
+	To   string `xml:"to"`
+	Read string `xml:"read"`
+}
+
+// ParseJFLAP lee un autómata finito de JFLAP como un AFN. Todos los estados
+// finales quedan marcados con IsFinal, y EndState es el primero de ellos.
+func ParseJFLAP(content string) (*NFA, error) {
+	automaton, err := decodeJFLAP(content)
+	if err != nil {
+		return nil, err
+	}
+
+	states, start, err := jflapStates(automaton)
+	if err != nil {
+		return nil, err
+	}
+	nfa := NewNFA(start, nil, []Transition{})
+	for _, state := range automaton.States {
+		if states[state.Id].IsFinal && nfa.EndState == nil {
+			nfa.EndState = states[state.Id]
+		}
+	}
+
+	for _, transition := range automaton.Transitions {
+		from, to, err := jflapTransitionStates(states, transition)
+		if err != nil {
+			return nil, err
+		}
+
+		read := []rune(transition.Read)
+		if len(read) == 0 {
+			nfa.Transitions = append(nfa.Transitions, NewTransition(from, []*State{to}, "ε"))
+			continue
+		}
+		// Una transición que lee varios caracteres pasa por estados intermedios
+		current := from
+		for index, char := range read {
+			next := to
+			if index != len(read)-1 {
+				next = NewState(fmt.Sprintf("%s_%s_%d", from.Name, to.Name, index+1), false)
+			}
+			nfa.Transitions = append(nfa.Transitions, NewTransition(current, []*State{next}, string(char)))
+			current = next
+		}
+	}
+
+	return nfa, nil
+}
+
+// ParseJFLAPDFA lee un autómata finito de JFLAP como un AFD. Falla si el
+// autómata no es determinista: si tiene transiciones ε, transiciones que leen
+// varios caracteres o dos transiciones con el mismo símbolo desde un estado.
+func ParseJFLAPDFA(content string) (*DFA, error) {
+	automaton, err := decodeJFLAP(content)
+	if err != nil {
+		return nil, err
+	}
+
+	states, start, err := jflapStates(automaton)
+	if err != nil {
+		return nil, err
+	}
+	dfa := NewDFA()
+	dfaStates := make(map[*State]*DFAState)
+	for _, state := range automaton.States {
+		nfaState := states[state.Id]
+		dfaStates[nfaState] = dfa.addState(nfaState.Name, nfaState.IsFinal, map[*State]bool{nfaState: true})
+	}
+	dfa.StartState = dfaStates[start]
+
+	for _, transition := range automaton.Transitions {
+		from, to, err := jflapTransitionStates(states, transition)
+		if err != nil {
+			return nil, err
+		}
+		if len([]rune(transition.Read)) != 1 {
+			return nil, fmt.Errorf("the automaton is not deterministic: the transition from %s to %s reads %q instead of a single character",
+				from.Name, to.Name, transition.Read)
+		}
+		if previous, exist := dfa.Transitions[dfaStates[from]][transition.Read]; exist && previous != dfaStates[to] {
+			return nil, fmt.Errorf("the automaton is not deterministic: %s goes to %s and %s with %q",
+				from.Name, previous.Name, to.Name, transition.Read)
+		}
+		dfa.addTransition(dfaStates[from], transition.Read, dfaStates[to])
+	}
+
+	return dfa, nil
+}
+
+// decodeJFLAP decodifica el XML de un archivo de JFLAP y revisa que sea un
+// autómata finito.
+func decodeJFLAP(content string) (*jflapAutomaton, error) {
+	var file jflapFile
+	if err := xml.Unmarshal([]byte(content), &file); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(file.Type) != JFLAPType {
+		return nil, fmt.Errorf("the JFLAP file is of type %q, not a finite automaton (%s)", file.Type, JFLAPType)
+	}
+	if file.Automaton != nil {
+		return file.Automaton, nil
+	}
+	return &file.jflapAutomaton, nil
+}
+
+// jflapStates crea los estados del autómata, indexados por su id.
+func jflapStates(automaton *jflapAutomaton) (map[string]*State, *State, error) {
+	states := make(map[string]*State)
+	var start *State
+	for _, state := range automaton.States {
+		if _, exist := states[state.Id]; exist {
+			return nil, nil, fmt.Errorf("the state id %q is repeated", state.Id)
+		}
+		name := state.Name
+		if name == "" {
+			name = "q" + state.Id
+		}
+		states[state.Id] = NewState(name, state.Final != nil)
+
+		if state.Initial != nil {
+			if start != nil {
+				return nil, nil, fmt.Errorf("the automaton has two initial states, %s and %s", start.Name, name)
+			}
+			start = states[state.Id]
+		}
+	}
+	if start == nil {
+		return nil, nil, fmt.Errorf("the automaton has no initial state")
+	}
+	return states, start, nil
+}
+
+// jflapTransitionStates busca los estados de origen y destino de una transición.
+func jflapTransitionStates(states map[string]*State, transition jflapTransition) (*State, *State, error) {
+	from, fromExist := states[strings.TrimSpace(transition.From)]
+	to, toExist := states[strings.TrimSpace(transition.To)]
+	if !fromExist || !toExist {
+		return nil, nil, fmt.Errorf("the transition from %q to %q uses a state that does not exist", transition.From, transition.To)
+	}
+	return from, to, nil
+}
+
+// GenerateJFLAP escribe un AFN como un archivo de JFLAP. Los estados se
+// ordenan en una cuadrícula, y las transiciones ε se escriben con <read/> vacío.
+func GenerateJFLAP(nfa *NFA) string {
+	// Recorrer los estados en el orden en que aparecen
+	order := []*State{}
+	ids := make(map[*State]string)
+	addState := func(state *State) {
+		if _, exist := ids[state]; state != nil && !exist {
+			ids[state] = fmt.Sprint(len(order))
+			order = append(order, state)
+		}
+	}
+	addState(nfa.StartState)
+	for _, transition := range nfa.Transitions {
+		addState(transition.From)
+		for _, to := range transition.To {
+			addState(to)
+		}
+	}
+	addState(nfa.EndState)
+
+	automaton := jflapAutomaton{}
+	for _, state := range order {
+		isFinal := state.IsFinal || state == nfa.EndState
+		automaton.States = append(automaton.States, newJFLAPState(ids[state], state.Name, len(automaton.States), state == nfa.StartState, isFinal))
+	}
+	for _, transition := range nfa.Transitions {
+		read := transition.Symbol
+		if read == "ε" {
+			read = ""
+		}
+		for _, to := range transition.To {
+			automaton.Transitions = append(automaton.Transitions, jflapTransition{From: ids[transition.From], To: ids[to], Read: read})
+		}
+	}
+
+	return encodeJFLAP(automaton)
+}
+
+// GenerateDFAJFLAP escribe un AFD como un archivo de JFLAP.
+func GenerateDFAJFLAP(dfa *DFA) string {
+	order := append([]*DFAState{}, dfa.States...)
+	if dfa.StartState != nil && !containsDFAState(order, dfa.StartState) {
+		order = append([]*DFAState{dfa.StartState}, order...)
+	}
+	ids := make(map[*DFAState]string)
+	for index, state := range order {
+		ids[state] = fmt.Sprint(index)
+	}
+
+	automaton := jflapAutomaton{}
+	for _, state := range order {
+		automaton.States = append(automaton.States, newJFLAPState(ids[state], state.Name, len(automaton.States), state == dfa.StartState, state.IsFinal))
+	}
+	for _, from := range order {
+		// Ordenar los símbolos para que el archivo siempre sea el mismo
+		symbols := make([]string, 0, len(dfa.Transitions[from]))
+		for symbol := range dfa.Transitions[from] {
+			symbols = append(symbols, symbol)
+		}
+		sort.Strings(symbols)
+		for _, symbol := range symbols {
+			automaton.Transitions = append(automaton.Transitions, jflapTransition{From: ids[from], To: ids[dfa.Transitions[from][symbol]], Read: symbol})
+		}
+	}
+
+	return encodeJFLAP(automaton)
+}
+
+// newJFLAPState crea un estado de JFLAP ubicado en la posición index de una
+// cuadrícula de cinco columnas.
+func newJFLAPState(id string, name string, index int, isInitial bool, isFinal bool) jflapState {
+	state := jflapState{Id: id, Name: name, X: float64(100 + 150*(index%5)), Y: float64(100 + 150*(index/5))}
+	if isInitial {
+		state.Initial = &struct{}{}
+	}
+	if isFinal {
+		state.Final = &struct{}{}
+	}
+	return state
+}
+
+func encodeJFLAP(automaton jflapAutomaton) string {
+	content, err := xml.MarshalIndent(jflapFile{Type: JFLAPType, Automaton: &automaton}, "", "\t")
+	if err != nil {
+		// Las estructuras solo tienen campos que siempre se pueden codificar
+		panic(err)
+	}
+	return xml.Header + string(content) + "\n"
+}
+
+func containsDFAState(states []*DFAState, state *DFAState) bool {
+	for _, current := range states {
+		if current == state {
+			return true
+		}
+	}
+	return false
+}
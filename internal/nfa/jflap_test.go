@@ -0,0 +1,119 @@
+package nfa
+
+import (
+	"strings"
+	"testing"
+)
+
+// AFN de JFLAP que acepta a(bc)* y también la cadena vacía por una transición λ.
+const jflapNFA = `<?xml version="1.0" encoding="UTF-8" standalone="no"?><!--Created with JFLAP 7.1.--><structure>
+	<type>fa</type>
+	<automaton>
+		<state id="0" name="q0"><x>60.0</x><y>100.0</y><initial/></state>
+		<state id="1" name="q1"><x>200.0</x><y>100.0</y><final/></state>
+		<state id="2" name="q2"><x>340.0</x><y>100.0</y><final/></state>
+		<transition><from>0</from><to>1</to><read>a</read></transition>
+		<transition><from>1</from><to>1</to><read>bc</read></transition>
+		<transition><from>0</from><to>2</to><read/></transition>
+	</automaton>
+</structure>`
+
+// Simula el AFN con ε-cerraduras, como el runner de la simulación.
+func accepts(nfa *NFA, input string) bool {
+	current := EpsilonClosure(nfa.StartState, nfa.Transitions)
+	for _, char := range input {
+		current = EpsilonClosureOfSet(Mover(current, string(char), nfa.Transitions), nfa.Transitions)
+	}
+	for _, state := range current {
+		if state.IsFinal {
+			return true
+		}
+	}
+	return false
+}
+
+func TestParseJFLAP(t *testing.T) {
+	nfa, err := ParseJFLAP(jflapNFA)
+	if err != nil {
+		t.Fatalf("Expected no errors, but got %v", err)
+	}
+	if nfa.StartState.Name != "q0" || nfa.EndState.Name != "q1" {
+		t.Errorf("Expected q0 to be the start state and q1 the end state, but got %s and %s", nfa.StartState.Name, nfa.EndState.Name)
+	}
+
+	tests := map[string]bool{"": true, "a": true, "abc": true, "abcbc": true, "ab": false, "bc": false}
+	for input, expected := range tests {
+		if got := accepts(nfa, input); got != expected {
+			t.Errorf("accepts(%q) = %v, expected %v", input, got, expected)
+		}
+	}
+
+	// Escribir el AFN y volver a leerlo da el mismo lenguaje
+	reread, err := ParseJFLAP(GenerateJFLAP(nfa))
+	if err != nil {
+		t.Fatalf("Expected no errors, but got %v", err)
+	}
+	for input, expected := range tests {
+		if got := accepts(reread, input); got != expected {
+			t.Errorf("After writing, accepts(%q) = %v, expected %v", input, got, expected)
+		}
+	}
+}
+
+func TestParseJFLAPWithoutAutomatonElement(t *testing.T) {
+	// JFLAP 6 escribe los estados directamente dentro de <structure>
+	content := `<structure><type>fa</type>
+		<state id="0"><initial/><final/></state>
+		<transition><from>0</from><to>0</to><read>x</read></transition>
+	</structure>`
+	nfa, err := ParseJFLAP(content)
+	if err != nil {
+		t.Fatalf("Expected no errors, but got %v", err)
+	}
+	if !accepts(nfa, "xxx") || nfa.StartState.Name != "q0" {
+		t.Errorf("Expected a state q0 that accepts x*, but got %v", nfa.StartState)
+	}
+}
+
+func TestParseJFLAPDFA(t *testing.T) {
+	content := strings.Replace(jflapNFA, `<read>bc</read>`, `<read>b</read>`, 1)
+	content = strings.Replace(content, `<read/>`, `<read>c</read>`, 1)
+
+	dfa, err := ParseJFLAPDFA(content)
+	if err != nil {
+		t.Fatalf("Expected no errors, but got %v", err)
+	}
+	if len(dfa.States) != 3 || dfa.StartState.Name != "q0" || !dfa.States[2].IsFinal {
+		t.Errorf("Expected 3 states starting at q0, but got %v", dfa.States)
+	}
+	if next := dfa.Transitions[dfa.StartState]["c"]; next == nil || next.Name != "q2" {
+		t.Errorf("Expected q0 to go to q2 with c, but got %v", next)
+	}
+
+	reread, err := ParseJFLAPDFA(GenerateDFAJFLAP(dfa))
+	if err != nil {
+		t.Fatalf("Expected no errors, but got %v", err)
+	}
+	if GenerateDFAJFLAP(reread) != GenerateDFAJFLAP(dfa) {
+		t.Errorf("Expected the same file after writing and reading the DFA")
+	}
+}
+
+func TestParseJFLAPErrors(t *testing.T) {
+	tests := map[string]string{
+		"λ transition in a DFA": jflapNFA,
+		"grammar file":          `<structure><type>grammar</type></structure>`,
+		"missing initial state": `<structure><type>fa</type><automaton><state id="0"/></automaton></structure>`,
+		"unknown state":         `<structure><type>fa</type><automaton><state id="0"><initial/></state><transition><from>0</from><to>9</to><read>a</read></transition></automaton></structure>`,
+		"nondeterministic":      strings.Replace(jflapNFA, `<read/>`, `<read>a</read>`, 1),
+		"malformed XML":         `<structure><type>fa</type>`,
+	}
+	for name, content := range tests {
+		if _, err := ParseJFLAPDFA(content); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := ParseJFLAP(tests["grammar file"]); err == nil {
+		t.Errorf("Expected an error when reading a grammar as an automaton")
+	}
+}
@@ -37,6 +37,17 @@ func RegexToPostfix(expresion string, showLogs bool) (string, []Symbol, error) {
 	return finalPostFixExpression, postFixSymbols, nil
 }
 
+// Converts an already tokenized infix expresion to postfix, adding the
+// concatenation operator where needed. Unlike RegexToPostfix, the ? and +
+// operators are kept as they are instead of being rewritten.
+func SymbolsToPostfix(symbols []Symbol, showLogs bool) ([]Symbol, error) {
+	symbolsFormatted, err := addConcatenationSymbol(&symbols)
+	if err != nil {
+		return nil, err
+	}
+	return shuntingYard(&symbolsFormatted, showLogs), nil
+}
+
 func shuntingYard(tokens *[]Symbol, showLogs bool) []Symbol {
 	postfix := make([]Symbol, 0)
 	stack := stack.New()
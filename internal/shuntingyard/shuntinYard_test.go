@@ -167,3 +167,19 @@ func TestInterchangeOperatorsCharacters(t *testing.T) {
 		t.Fatalf("| dit not remain as character. Given %v", val)
 	}
 }
+
+func TestSymbolsToPostfix(t *testing.T) {
+	// E(,E)* where E is a single operand
+	symbols := []Symbol{
+		NewCharacter("E"),
+		OPERATORS["("], NewCharacter(","), NewCharacter("E"), OPERATORS[")"], OPERATORS["*"],
+		OPERATORS["|"],
+		NewCharacter("a"), OPERATORS["?"],
+	}
+	response, err := SymbolsToPostfix(symbols, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := strings.Split("E,E·*·a?|", "")
+	areSlicesEqual(t, response, expect)
+}
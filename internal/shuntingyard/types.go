@@ -23,6 +23,11 @@ func (c *Character) String() string {
 	return c.value
 }
 
+// NewCharacter creates an operand with the given value.
+func NewCharacter(value string) *Character {
+	return &Character{value: value, precedence: 60}
+}
+
 type Operator struct {
 	value      string
 	precedence int